@@ -0,0 +1,835 @@
+// The report renders the results of the comparison made when it was generated; nothing
+// is compared in the browser. Rendering is lazy so that reports with tens of thousands of
+// resources stay responsive: the report opens on the embedded summary, the results are
+// decoded only once a filter or the detailed tab needs them, each kind renders only the
+// rows scrolled into view, a resource is rendered when it is expanded and nested JSON
+// when it is opened.
+const ROW_HEIGHT = 64;
+const LIST_OVERSCAN = 10;
+const LAZY_JSON_DEPTH = 2;
+const FILTER_DEBOUNCE_MS = 200;
+const CLUSTER_SCOPED = '(cluster-scoped)';
+
+// Status filters as they appear in the URL hash, mapped to difference types
+const STATUS_FILTERS = {
+    'only-a': 'only_in_file1',
+    'only-b': 'only_in_file2',
+    'different': 'different',
+    'not-compared': 'not_compared',
+    'unknown': 'unknown'
+};
+
+// Statuses of the embedded summary and results, mapped to difference types
+const SUMMARY_STATUSES = {
+    'only-in-A': 'only_in_file1',
+    'only-in-B': 'only_in_file2',
+    'different': 'different',
+    'not-compared': 'not_compared',
+    'unknown': 'unknown'
+};
+
+let reportResults = null;
+let reportDataPromise = null;
+let comparisonShown = false;
+let kindLists = [];
+let lazyJsonValues = [];
+let filters = readFiltersFromHash();
+let filterTimer = null;
+
+document.addEventListener('DOMContentLoaded', function() {
+    // toggle does not bubble, so lazy JSON is rendered from a capturing listener
+    document.addEventListener('toggle', renderLazyJson, true);
+    window.addEventListener('hashchange', function() {
+        filters = readFiltersFromHash();
+        syncFilterControls();
+        refreshComparison();
+    });
+    initFilterControls();
+    if (hasFilters()) {
+        refreshComparison();
+    } else {
+        displaySummary();
+    }
+});
+
+// refreshComparison decodes the embedded results on first use and shows them with the
+// current filters
+function refreshComparison() {
+    loadReportData().then(performComparison).catch(function(err) {
+        console.error('Failed to decode report data', err);
+        document.getElementById('stats-grid').innerHTML = '<div class="loading">Failed to decode report data: ' + escapeHtml(String(err)) + '</div>';
+    });
+}
+
+function loadReportData() {
+    if (!reportDataPromise) {
+        reportDataPromise = decodeReportData(encodedResults).then(results => {
+            reportResults = results;
+        });
+    }
+    return reportDataPromise;
+}
+
+async function decodeReportData(encoded) {
+    const bytes = Uint8Array.from(atob(encoded), c => c.charCodeAt(0));
+    const stream = new Blob([bytes]).stream().pipeThrough(new DecompressionStream('gzip'));
+    return JSON.parse(await new Response(stream).text());
+}
+
+function showTab(tabName) {
+    const contents = document.querySelectorAll('.tab-content');
+    contents.forEach(content => content.classList.remove('active'));
+    
+    const tabs = document.querySelectorAll('.tab');
+    tabs.forEach(tab => tab.classList.remove('active'));
+    
+    document.getElementById(tabName).classList.add('active');
+    event.target.classList.add('active');
+    
+    if (tabName === 'detailed' && !comparisonShown) {
+        document.getElementById('detailed-content').innerHTML = '<div class="loading">Loading comparison...</div>';
+        refreshComparison();
+    }
+}
+
+function performComparison() {
+    if (!reportResults) {
+        console.error('Data not available for comparison');
+        return;
+    }
+    
+    const comparison = groupResults(reportResults.filter(resultFilter()));
+    filterDifferences(comparison);
+    displayOverview(comparison);
+    displayBreakdown(comparison);
+    displayDetailed(comparison);
+    displayFilterSummary(comparison);
+    comparisonShown = true;
+}
+
+// displaySummary shows the embedded unfiltered comparison without decoding the results
+function displaySummary() {
+    const stats = { totalFile1: reportSummary.totalA, totalFile2: reportSummary.totalB, kinds: reportSummary.kinds.length };
+    Object.entries(SUMMARY_STATUSES).forEach(([status, type]) => {
+        stats[type] = reportSummary.counts[status] || 0;
+    });
+    renderOverview(stats);
+    
+    const kinds = {};
+    reportSummary.kinds.forEach(kind => {
+        kinds[kind.kind] = { file1Count: kind.countA, file2Count: kind.countB };
+    });
+    displayBreakdown({ kinds: kinds });
+    
+    const differences = Object.values(SUMMARY_STATUSES).reduce((total, type) => total + stats[type], 0);
+    renderFilterSummary(differences, stats.totalFile1 + stats.totalFile2);
+}
+
+function hasFilters() {
+    return Boolean(filters.kind || filters.namespace || filters.name || filters.field || filters.statuses.length > 0);
+}
+
+// Filters are kept in the URL hash, e.g. #kind=Deployment&status=different&field=spec,
+// so that a filtered view can be shared as a link
+function readFiltersFromHash() {
+    const params = new URLSearchParams(location.hash.slice(1));
+    return {
+        kind: params.get('kind') || '',
+        namespace: params.get('namespace') || '',
+        name: params.get('name') || '',
+        regex: params.get('regex') === '1',
+        field: params.get('field') || '',
+        statuses: (params.get('status') || '').split(',').filter(status => status in STATUS_FILTERS)
+    };
+}
+
+function writeFiltersToHash() {
+    const params = new URLSearchParams();
+    if (filters.kind) params.set('kind', filters.kind);
+    if (filters.namespace) params.set('namespace', filters.namespace);
+    if (filters.name) params.set('name', filters.name);
+    if (filters.regex) params.set('regex', '1');
+    if (filters.field) params.set('field', filters.field);
+    if (filters.statuses.length > 0) params.set('status', filters.statuses.join(','));
+    
+    // replaceState does not fire hashchange, so editing filters does not recompare twice
+    const hash = params.toString();
+    history.replaceState(null, '', hash ? '#' + hash : location.pathname + location.search);
+}
+
+function initFilterControls() {
+    const kinds = new Set(reportSummary.kinds.map(kind => kind.kind || 'Unknown'));
+    const namespaces = new Set(reportSummary.namespaces.map(namespace => namespace || CLUSTER_SCOPED));
+    fillFilterOptions('filter-kind', kinds);
+    fillFilterOptions('filter-namespace', namespaces);
+    syncFilterControls();
+    
+    ['filter-kind', 'filter-namespace', 'filter-regex'].forEach(id => {
+        document.getElementById(id).addEventListener('change', applyFilterControls);
+    });
+    ['filter-name', 'filter-field'].forEach(id => {
+        document.getElementById(id).addEventListener('input', function() {
+            clearTimeout(filterTimer);
+            filterTimer = setTimeout(applyFilterControls, FILTER_DEBOUNCE_MS);
+        });
+    });
+    document.querySelectorAll('.filter-status').forEach(checkbox => {
+        checkbox.addEventListener('change', applyFilterControls);
+    });
+    document.getElementById('filter-clear').addEventListener('click', function() {
+        filters = { kind: '', namespace: '', name: '', regex: false, field: '', statuses: [] };
+        syncFilterControls();
+        writeFiltersToHash();
+        refreshComparison();
+    });
+}
+
+function fillFilterOptions(id, values) {
+    const select = document.getElementById(id);
+    Array.from(values).sort().forEach(value => {
+        select.add(new Option(value, value));
+    });
+}
+
+function syncFilterControls() {
+    setSelectValue('filter-kind', filters.kind);
+    setSelectValue('filter-namespace', filters.namespace);
+    document.getElementById('filter-name').value = filters.name;
+    document.getElementById('filter-regex').checked = filters.regex;
+    document.getElementById('filter-field').value = filters.field;
+    document.querySelectorAll('.filter-status').forEach(checkbox => {
+        checkbox.checked = filters.statuses.includes(checkbox.value);
+    });
+}
+
+function setSelectValue(id, value) {
+    const select = document.getElementById(id);
+    // A shared link may name a kind or namespace this report does not contain
+    if (value && !Array.from(select.options).some(option => option.value === value)) {
+        select.add(new Option(value, value));
+    }
+    select.value = value;
+}
+
+function applyFilterControls() {
+    clearTimeout(filterTimer);
+    filters = {
+        kind: document.getElementById('filter-kind').value,
+        namespace: document.getElementById('filter-namespace').value,
+        name: document.getElementById('filter-name').value,
+        regex: document.getElementById('filter-regex').checked,
+        field: document.getElementById('filter-field').value,
+        statuses: Array.from(document.querySelectorAll('.filter-status:checked')).map(checkbox => checkbox.value)
+    };
+    writeFiltersToHash();
+    refreshComparison();
+}
+
+function nameMatcher() {
+    const input = document.getElementById('filter-name');
+    input.classList.remove('invalid');
+    if (!filters.name) return null;
+    
+    if (filters.regex) {
+        try {
+            const pattern = new RegExp(filters.name);
+            return name => pattern.test(name);
+        } catch (err) {
+            // An incomplete pattern filters nothing until it is valid
+            input.classList.add('invalid');
+            return null;
+        }
+    }
+    const needle = filters.name.toLowerCase();
+    return name => name.toLowerCase().includes(needle);
+}
+
+function resultFilter() {
+    const matchesName = nameMatcher();
+    return result => {
+        if (filters.kind && (result.kind || 'Unknown') !== filters.kind) return false;
+        if (filters.namespace && (result.namespace || CLUSTER_SCOPED) !== filters.namespace) return false;
+        return !matchesName || matchesName(result.name);
+    };
+}
+
+function filterDifferences(comparison) {
+    const types = filters.statuses.map(status => STATUS_FILTERS[status]);
+    Object.values(comparison.kinds).forEach(data => {
+        data.differences = data.differences
+            .filter(diff => types.length === 0 || types.includes(diff.type))
+            .map(diff => {
+                if (!filters.field) return diff;
+                // Only differing resources have field paths; keep just the matching fields
+                if (diff.type !== 'different') return null;
+                const changes = diff.changes.filter(change => change.path.includes(filters.field));
+                return changes.length > 0 ? Object.assign({}, diff, { changes: changes }) : null;
+            })
+            .filter(diff => diff !== null);
+    });
+}
+
+function displayFilterSummary(comparison) {
+    const differences = Object.values(comparison.kinds).reduce((total, kind) => total + kind.differences.length, 0);
+    renderFilterSummary(differences, comparison.totalFile1 + comparison.totalFile2);
+}
+
+function renderFilterSummary(differences, shown) {
+    const total = reportSummary.totalA + reportSummary.totalB;
+    document.getElementById('filter-summary').textContent = 'Showing ' + differences + ' differences across ' + shown + ' of ' + total + ' resources';
+}
+
+// groupResults groups the results by kind into the differences the report lists. Every
+// result counts towards the totals of the clusters it was found in, and the resources
+// generated by its controllers towards those of their own kind.
+function groupResults(results) {
+    const comparison = { totalFile1: 0, totalFile2: 0, kinds: {}, owned: [] };
+    const kindOf = kind => {
+        if (!comparison.kinds[kind]) comparison.kinds[kind] = { file1Count: 0, file2Count: 0, differences: [] };
+        return comparison.kinds[kind];
+    };
+    const count = (kind, countA, countB) => {
+        kindOf(kind).file1Count += countA;
+        kindOf(kind).file2Count += countB;
+        comparison.totalFile1 += countA;
+        comparison.totalFile2 += countB;
+    };
+    
+    results.forEach(result => {
+        const identical = result.status === 'identical';
+        const kind = result.kind || 'Unknown';
+        count(kind, identical || result.objectA ? 1 : 0, identical || result.objectB ? 1 : 0);
+        
+        const owned = result.owned || [];
+        owned.forEach(summary => count(summary.kind, summary.countA, summary.countB));
+        if (owned.some(ownedDiffers)) comparison.owned.push(result);
+        
+        if (result.status in SUMMARY_STATUSES) {
+            kindOf(kind).differences.push(Object.assign({ type: SUMMARY_STATUSES[result.status], changes: [] }, result));
+        }
+    });
+    return comparison;
+}
+
+// ownedDiffers tells whether the generated resources of one kind differ in number or, for
+// Pods, readiness between the clusters
+function ownedDiffers(summary) {
+    return summary.countA !== summary.countB || (summary.kind === 'Pod' && summary.readyA !== summary.readyB);
+}
+
+function ownedSummaryText(summary) {
+    if (summary.kind === 'Pod') {
+        return 'Pods: ' + summary.readyA + '/' + summary.countA + ' ready in A, ' + summary.readyB + '/' + summary.countB + ' in B';
+    }
+    return summary.kind + 's: ' + summary.countA + ' in A, ' + summary.countB + ' in B';
+}
+
+function displayOverview(comparison) {
+    const stats = {
+        totalFile1: comparison.totalFile1,
+        totalFile2: comparison.totalFile2,
+        kinds: Object.keys(comparison.kinds).length,
+        only_in_file1: 0, only_in_file2: 0, different: 0, not_compared: 0, unknown: 0
+    };
+    
+    Object.values(comparison.kinds).forEach(kind => {
+        kind.differences.forEach(diff => {
+            if (diff.type in stats) stats[diff.type]++;
+        });
+    });
+    
+    renderOverview(stats);
+}
+
+function renderOverview(stats) {
+    // Resources that could not be compared or fetched are not counted as differences
+    const totalDifferences = stats.only_in_file1 + stats.only_in_file2 + stats.different;
+    
+    document.getElementById('stats-grid').innerHTML = '<div class="stat-card"><span class="stat-number">' + stats.totalFile1 + '</span><div class="stat-label">Resources in Cluster A</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + stats.totalFile2 + '</span><div class="stat-label">Resources in Cluster B</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + stats.kinds + '</span><div class="stat-label">Resource Types</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + totalDifferences + '</span><div class="stat-label">Total Differences</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + stats.only_in_file1 + '</span><div class="stat-label">Only in Cluster A</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + stats.only_in_file2 + '</span><div class="stat-label">Only in Cluster B</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + stats.different + '</span><div class="stat-label">Different Resources</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + stats.not_compared + '</span><div class="stat-label">Not Compared</div></div>' +
+        '<div class="stat-card"><span class="stat-number">' + stats.unknown + '</span><div class="stat-label">Unknown (Fetch Failed)</div></div>';
+}
+
+function displayBreakdown(comparison) {
+    const breakdownContent = document.getElementById('breakdown-content');
+    
+    const file1Html = Object.entries(comparison.kinds).map(([kind, data]) => 
+        '<div class="resource-item"><span class="resource-name">' + escapeHtml(kind) + '</span><span class="resource-count">' + data.file1Count + '</span></div>'
+    ).join('');
+    
+    const file2Html = Object.entries(comparison.kinds).map(([kind, data]) => 
+        '<div class="resource-item"><span class="resource-name">' + escapeHtml(kind) + '</span><span class="resource-count">' + data.file2Count + '</span></div>'
+    ).join('');
+    
+    breakdownContent.innerHTML = '<div class="resource-list"><h3>🅰️ Cluster A Resources</h3>' + file1Html + '</div>' +
+        '<div class="resource-list"><h3>🅱️ Cluster B Resources</h3>' + file2Html + '</div>';
+}
+
+function displayDetailed(comparison) {
+    const detailedContent = document.getElementById('detailed-content');
+    kindLists = [];
+    lazyJsonValues = [];
+    let html = '';
+    
+    Object.entries(comparison.kinds).forEach(([kind, data]) => {
+        if (data.differences.length === 0) return;
+        
+        kindLists.push({ differences: data.differences, expanded: new Set(), viewport: null });
+        html += '<div class="resource-diff" data-list="' + (kindLists.length - 1) + '"><div class="resource-header" onclick="toggleResourceDiff(this)"><h3>' + escapeHtml(kind) + ' (' + data.differences.length + ' differences)</h3><span class="toggle-icon">▶</span></div><div class="resource-content"><div class="virtual-list"><div class="virtual-spacer"></div><div class="virtual-rows"></div><div class="virtual-spacer"></div></div></div></div>';
+    });
+    
+    if (comparison.owned.length > 0) {
+        const items = comparison.owned.map(owner => {
+            const name = (owner.namespace ? owner.namespace + '/' : '') + owner.name;
+            const counts = owner.owned.map(ownedSummaryText).join('; ');
+            return '<li><strong>' + escapeHtml(owner.kind + ' ' + name) + '</strong><span class="owned-counts">' + escapeHtml(counts) + '</span></li>';
+        }).join('');
+        html += '<div class="resource-diff"><div class="resource-header" onclick="toggleResourceDiff(this)"><h3>🧩 Generated resources (' + comparison.owned.length + ' owners differ)</h3><span class="toggle-icon">▶</span></div><div class="resource-content"><ul class="owned-list">' + items + '</ul></div></div>';
+    }
+    
+    if (html === '') {
+        html = '<div class="loading">No detailed differences found</div>';
+    }
+    
+    detailedContent.innerHTML = html;
+}
+
+function initVirtualList(list, element) {
+    const count = list.differences.length;
+    list.viewport = element.querySelector('.virtual-list');
+    list.topSpacer = list.viewport.firstElementChild;
+    list.host = list.topSpacer.nextElementSibling;
+    list.bottomSpacer = list.viewport.lastElementChild;
+    list.heights = new Array(count).fill(ROW_HEIGHT);
+    list.offsets = new Array(count + 1);
+    list.rows = new Map();
+    list.start = -1;
+    list.end = -1;
+    computeOffsets(list);
+    list.viewport.addEventListener('scroll', () => renderVirtualList(list));
+}
+
+function computeOffsets(list) {
+    list.offsets[0] = 0;
+    for (let i = 0; i < list.heights.length; i++) {
+        list.offsets[i + 1] = list.offsets[i] + list.heights[i];
+    }
+}
+
+function findRowAt(list, y) {
+    let low = 0;
+    let high = list.heights.length - 1;
+    while (low < high) {
+        const mid = (low + high + 1) >> 1;
+        if (list.offsets[mid] <= y) low = mid;
+        else high = mid - 1;
+    }
+    return low;
+}
+
+function renderVirtualList(list) {
+    const count = list.differences.length;
+    const top = list.viewport.scrollTop;
+    // The viewport has no height until its first rows are rendered
+    const bottom = top + (list.viewport.clientHeight || window.innerHeight);
+    const start = Math.max(0, findRowAt(list, top) - LIST_OVERSCAN);
+    const end = Math.min(count, findRowAt(list, bottom) + 1 + LIST_OVERSCAN);
+    if (start === list.start && end === list.end) return;
+    
+    // Rows still in view are kept, so expanded content is not rebuilt while scrolling
+    const rows = new Map();
+    for (let i = start; i < end; i++) {
+        rows.set(i, list.rows.get(i) || createResourceRow(list, i));
+    }
+    list.rows = rows;
+    list.start = start;
+    list.end = end;
+    list.host.replaceChildren(...rows.values());
+    measureVirtualList(list);
+}
+
+function measureVirtualList(list) {
+    let changed = false;
+    list.rows.forEach((row, i) => {
+        const height = row.offsetHeight;
+        if (height > 0 && height !== list.heights[i]) {
+            list.heights[i] = height;
+            changed = true;
+        }
+    });
+    if (changed) computeOffsets(list);
+    
+    list.topSpacer.style.height = list.offsets[list.start] + 'px';
+    list.bottomSpacer.style.height = (list.offsets[list.differences.length] - list.offsets[list.end]) + 'px';
+}
+
+function createResourceRow(list, index) {
+    const diff = list.differences[index];
+    let statusBadge = '';
+    if (diff.type === 'different') statusBadge = '<span class="status-badge status-different">Different</span>';
+    else if (diff.type === 'only_in_file1') statusBadge = '<span class="status-badge status-only-a">Only in A</span>';
+    else if (diff.type === 'only_in_file2') statusBadge = '<span class="status-badge status-only-b">Only in B</span>';
+    else if (diff.type === 'not_compared') statusBadge = '<span class="status-badge status-not-compared">Not compared</span>';
+    else if (diff.type === 'unknown') statusBadge = '<span class="status-badge status-unknown">Unknown</span>';
+    
+    const row = document.createElement('div');
+    row.className = 'virtual-row';
+    row.dataset.index = index;
+    row.innerHTML = '<div class="individual-resource"><div class="individual-header" onclick="toggleIndividualResource(this)"><span>' + escapeHtml(diff.name) + ' ' + statusBadge + '</span><span class="toggle-icon">▶</span></div><div class="individual-content"></div></div>';
+    if (list.expanded.has(index)) {
+        expandResourceRow(row, diff);
+    }
+    return row;
+}
+
+function expandResourceRow(row, diff) {
+    const resourceElement = row.firstElementChild;
+    const content = resourceElement.lastElementChild;
+    if (!content.hasChildNodes()) {
+        content.innerHTML = renderResourceDetails(diff);
+    }
+    resourceElement.classList.add('expanded');
+}
+
+function renderResourceDetails(diff) {
+    let html = '';
+    // A resource that was not compared was skipped in the cluster it is missing from
+    const resource = diff.objectA || diff.objectB;
+    const skippedCluster = diff.objectA ? 'Cluster B' : 'Cluster A';
+    if (diff.type === 'different') {
+        html += '<div class="resource-metadata"><div class="metadata-item"><div class="metadata-label">Namespace</div><div class="metadata-value">' + escapeHtml(diff.namespace || 'default') + '</div></div></div>';
+        
+        html += '<div class="resource-view-tabs"><button type="button" class="view-tab active" onclick="showResourceView(this, \'yaml\')">Side-by-side YAML</button><button type="button" class="view-tab" onclick="showResourceView(this, \'fields\')">Changed fields</button></div>';
+        html += '<div class="resource-view" data-view="yaml">' + renderYamlDiff(diff.objectA, diff.objectB) + '</div>';
+        html += '<div class="resource-view" data-view="fields" hidden></div>';
+    } else if (diff.type === 'not_compared') {
+        html += '<div class="resource-metadata"><div class="metadata-item"><div class="metadata-label">Status</div><div class="metadata-value">Not compared: listing ' + escapeHtml(diff.skipped.resource) + ' in ' + skippedCluster + ' was ' + escapeHtml(diff.skipped.reason) + '</div></div></div>';
+        html += '<div class="diff-value not-compared"><strong>Resource Definition:</strong><br>' + renderRichJson(resource) + '</div>';
+    } else if (diff.type === 'unknown') {
+        html += '<div class="resource-metadata"><div class="metadata-item"><div class="metadata-label">Status</div><div class="metadata-value">Unknown: fetching ' + escapeHtml(diff.skipped.resource) + ' from ' + skippedCluster + ' failed: ' + escapeHtml(diff.skipped.error) + '</div></div></div>';
+        html += '<div class="diff-value unknown"><strong>Resource Definition:</strong><br>' + renderRichJson(resource) + '</div>';
+    } else {
+        const cluster = diff.type === 'only_in_file1' ? 'Cluster A' : 'Cluster B';
+        const valueClass = diff.type === 'only_in_file1' ? 'missing' : 'added';
+        html += '<div class="resource-metadata"><div class="metadata-item"><div class="metadata-label">Status</div><div class="metadata-value">Only exists in ' + cluster + '</div></div></div>';
+        html += '<div class="diff-value ' + valueClass + '"><strong>Resource Definition:</strong><br>' + renderRichJson(resource) + '</div>';
+    }
+    return html;
+}
+
+function listOf(element) {
+    const kindElement = element.closest('.resource-diff');
+    return kindElement ? kindLists[Number(kindElement.dataset.list)] : null;
+}
+
+function toggleResourceDiff(header) {
+    const kindElement = header.parentElement;
+    kindElement.classList.toggle('expanded');
+    
+    const list = listOf(kindElement);
+    if (list && kindElement.classList.contains('expanded')) {
+        if (!list.viewport) initVirtualList(list, kindElement);
+        renderVirtualList(list);
+    }
+}
+
+function renderFieldDiffs(diff) {
+    let html = '';
+    diff.changes.forEach(change => {
+        html += '<div class="diff-row"><div class="diff-field">' + escapeHtml(change.path) + '</div><div class="diff-value different"><strong>Cluster A:</strong><br>' + renderRichJson(change.valueA) + '</div><div class="diff-value different"><strong>Cluster B:</strong><br>' + renderRichJson(change.valueB) + '</div></div>';
+    });
+    return html;
+}
+
+function showResourceView(button, view) {
+    const row = button.closest('.virtual-row');
+    const list = listOf(row);
+    button.parentElement.querySelectorAll('.view-tab').forEach(tab => tab.classList.toggle('active', tab === button));
+    
+    row.querySelectorAll('.resource-view').forEach(element => {
+        const selected = element.dataset.view === view;
+        if (selected && element.dataset.view === 'fields' && !element.hasChildNodes()) {
+            element.innerHTML = renderFieldDiffs(list.differences[Number(row.dataset.index)]);
+        }
+        element.hidden = !selected;
+    });
+    measureVirtualList(list);
+    renderVirtualList(list);
+}
+
+function toggleIndividualResource(header) {
+    const row = header.closest('.virtual-row');
+    const list = listOf(row);
+    const index = Number(row.dataset.index);
+    
+    if (list.expanded.has(index)) {
+        list.expanded.delete(index);
+        header.parentElement.classList.remove('expanded');
+    } else {
+        list.expanded.add(index);
+        expandResourceRow(row, list.differences[index]);
+    }
+    measureVirtualList(list);
+    renderVirtualList(list);
+}
+
+function renderLazyJson(event) {
+    const details = event.target;
+    if (!details.classList || !details.classList.contains('json-lazy')) return;
+    
+    if (details.open && !details.dataset.rendered) {
+        const entry = lazyJsonValues[Number(details.dataset.lazy)];
+        details.insertAdjacentHTML('beforeend', renderRichJson(entry.value, entry.depth, entry.depth + LAZY_JSON_DEPTH));
+        details.dataset.rendered = 'true';
+    }
+    
+    const row = details.closest('.virtual-row');
+    if (row) {
+        const list = listOf(row);
+        measureVirtualList(list);
+        renderVirtualList(list);
+    }
+}
+
+// The side-by-side view renders both objects as YAML with sorted keys and aligns them
+// with a line diff; the embedded objects of differing resources are already without the
+// fields the comparison ignores. Unchanged runs longer than twice YAML_DIFF_CONTEXT lines
+// are folded.
+const YAML_DIFF_CONTEXT = 3;
+const MAX_LINE_DIFF_CELLS = 4000000;
+
+function renderYamlDiff(resource1, resource2) {
+    const lines1 = yamlLines(resource1, '');
+    const lines2 = yamlLines(resource2, '');
+    const rows = sideBySideRows(diffLines(lines1, lines2));
+    
+    let html = '<table class="yaml-diff"><colgroup><col class="yaml-line-number"><col><col class="yaml-line-number"><col></colgroup>';
+    html += '<thead><tr><th colspan="2">Cluster A: ' + escapeHtml(contextA) + '</th><th colspan="2">Cluster B: ' + escapeHtml(contextB) + '</th></tr></thead><tbody>';
+    
+    let i = 0;
+    while (i < rows.length) {
+        if (rows[i].kind !== 'same') {
+            html += renderYamlRow(rows[i++]);
+            continue;
+        }
+        let end = i;
+        while (end < rows.length && rows[end].kind === 'same') end++;
+        
+        const keepStart = i === 0 ? 0 : YAML_DIFF_CONTEXT;
+        const keepEnd = end === rows.length ? 0 : YAML_DIFF_CONTEXT;
+        if (end - i > keepStart + keepEnd + 1) {
+            rows.slice(i, i + keepStart).forEach(row => html += renderYamlRow(row));
+            const folded = rows.slice(i + keepStart, end - keepEnd);
+            html += '</tbody><tbody class="yaml-fold"><tr><td colspan="4" onclick="expandYamlFold(this)">⋯ ' + folded.length + ' unchanged lines</td></tr></tbody><tbody hidden>';
+            folded.forEach(row => html += renderYamlRow(row));
+            html += '</tbody><tbody>';
+            rows.slice(end - keepEnd, end).forEach(row => html += renderYamlRow(row));
+        } else {
+            rows.slice(i, end).forEach(row => html += renderYamlRow(row));
+        }
+        i = end;
+    }
+    return html + '</tbody></table>';
+}
+
+function renderYamlRow(row) {
+    const left = row.left ? '<td class="yaml-line-number">' + row.left.number + '</td><td class="yaml-code ' + row.kind + '-a">' + escapeHtml(row.left.text) + '</td>' : '<td class="yaml-line-number"></td><td class="yaml-code yaml-empty"></td>';
+    const right = row.right ? '<td class="yaml-line-number">' + row.right.number + '</td><td class="yaml-code ' + row.kind + '-b">' + escapeHtml(row.right.text) + '</td>' : '<td class="yaml-line-number"></td><td class="yaml-code yaml-empty"></td>';
+    return '<tr>' + left + right + '</tr>';
+}
+
+function expandYamlFold(cell) {
+    const fold = cell.closest('tbody');
+    const list = listOf(fold.closest('.virtual-row'));
+    fold.nextElementSibling.hidden = false;
+    fold.remove();
+    
+    measureVirtualList(list);
+    renderVirtualList(list);
+}
+
+function yamlLines(value, indent) {
+    if (Array.isArray(value) && value.length > 0) {
+        const lines = [];
+        value.forEach(item => {
+            if (isYamlCollection(item)) {
+                const itemLines = yamlLines(item, indent + '  ');
+                lines.push(indent + '- ' + itemLines[0].slice(indent.length + 2), ...itemLines.slice(1));
+            } else {
+                lines.push(...yamlScalarLines(indent + '- ', item, indent + '  '));
+            }
+        });
+        return lines;
+    }
+    if (isYamlCollection(value)) {
+        const lines = [];
+        Object.keys(value).sort().forEach(key => {
+            const child = value[key];
+            const prefix = indent + yamlString(key) + ':';
+            if (isYamlCollection(child)) {
+                // Sequences under a key are not indented, like kubectl output
+                lines.push(prefix, ...yamlLines(child, Array.isArray(child) ? indent : indent + '  '));
+            } else {
+                lines.push(...yamlScalarLines(prefix + ' ', child, indent + '  '));
+            }
+        });
+        return lines;
+    }
+    return yamlScalarLines(indent, value, indent + '  ');
+}
+
+function isYamlCollection(value) {
+    if (Array.isArray(value)) return value.length > 0;
+    return value !== null && typeof value === 'object' && Object.keys(value).length > 0;
+}
+
+function yamlScalarLines(prefix, value, blockIndent) {
+    if (Array.isArray(value)) return [prefix + '[]'];
+    if (value !== null && typeof value === 'object') return [prefix + '{}'];
+    if (typeof value === 'string' && value.includes('\n')) {
+        // Multi-line strings are block scalars so each line of e.g. a config file diffs on its own
+        const trailingNewline = value.endsWith('\n');
+        const body = trailingNewline ? value.slice(0, -1) : value;
+        return [prefix + (trailingNewline ? '|' : '|-')].concat(body.split('\n').map(line => line ? blockIndent + line : ''));
+    }
+    if (typeof value === 'string') return [prefix + yamlString(value)];
+    return [prefix + String(value)];
+}
+
+function yamlString(text) {
+    const needsQuotes = text === '' ||
+        /^[\s\-?:,\[\]{}#&*!|>'"%@`]/.test(text) ||
+        /: |:$| #|\s$|\t/.test(text) ||
+        /^(true|false|yes|no|on|off|null|~|[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?|0x[0-9a-fA-F]+)$/i.test(text);
+    return needsQuotes ? JSON.stringify(text) : text;
+}
+
+function diffLines(lines1, lines2) {
+    let prefix = 0;
+    while (prefix < lines1.length && prefix < lines2.length && lines1[prefix] === lines2[prefix]) prefix++;
+    let suffix = 0;
+    while (suffix < lines1.length - prefix && suffix < lines2.length - prefix &&
+        lines1[lines1.length - 1 - suffix] === lines2[lines2.length - 1 - suffix]) suffix++;
+    
+    const middle1 = lines1.slice(prefix, lines1.length - suffix);
+    const middle2 = lines2.slice(prefix, lines2.length - suffix);
+    const ops = lines1.slice(0, prefix).map(text => ({ op: ' ', text: text }));
+    
+    const n = middle1.length;
+    const m = middle2.length;
+    if ((n + 1) * (m + 1) > MAX_LINE_DIFF_CELLS) {
+        // Too large to align; show the changed region as removed then added
+        middle1.forEach(text => ops.push({ op: '-', text: text }));
+        middle2.forEach(text => ops.push({ op: '+', text: text }));
+    } else {
+        // lcs[i * (m + 1) + j] is the LCS length of middle1[i:] and middle2[j:]
+        const lcs = new Uint32Array((n + 1) * (m + 1));
+        for (let i = n - 1; i >= 0; i--) {
+            for (let j = m - 1; j >= 0; j--) {
+                lcs[i * (m + 1) + j] = middle1[i] === middle2[j]
+                    ? lcs[(i + 1) * (m + 1) + j + 1] + 1
+                    : Math.max(lcs[(i + 1) * (m + 1) + j], lcs[i * (m + 1) + j + 1]);
+            }
+        }
+        let i = 0;
+        let j = 0;
+        while (i < n || j < m) {
+            if (i < n && j < m && middle1[i] === middle2[j]) {
+                ops.push({ op: ' ', text: middle1[i++] });
+                j++;
+            } else if (j < m && (i === n || lcs[i * (m + 1) + j + 1] >= lcs[(i + 1) * (m + 1) + j])) {
+                ops.push({ op: '+', text: middle2[j++] });
+            } else {
+                ops.push({ op: '-', text: middle1[i++] });
+            }
+        }
+    }
+    
+    lines1.slice(lines1.length - suffix).forEach(text => ops.push({ op: ' ', text: text }));
+    return ops;
+}
+
+function sideBySideRows(ops) {
+    const rows = [];
+    let number1 = 0;
+    let number2 = 0;
+    let i = 0;
+    while (i < ops.length) {
+        if (ops[i].op === ' ') {
+            rows.push({ kind: 'same', left: { number: ++number1, text: ops[i].text }, right: { number: ++number2, text: ops[i].text } });
+            i++;
+            continue;
+        }
+        // Pair a run of removed lines with the added lines that follow as changed lines
+        const removed = [];
+        const added = [];
+        while (i < ops.length && ops[i].op !== ' ') {
+            (ops[i].op === '-' ? removed : added).push(ops[i].text);
+            i++;
+        }
+        for (let k = 0; k < Math.max(removed.length, added.length); k++) {
+            const left = k < removed.length ? { number: ++number1, text: removed[k] } : null;
+            const right = k < added.length ? { number: ++number2, text: added[k] } : null;
+            rows.push({ kind: left && right ? 'changed' : (left ? 'removed' : 'added'), left: left, right: right });
+        }
+    }
+    return rows;
+}
+
+function renderRichJson(value, depth = 0, limit = LAZY_JSON_DEPTH) {
+    if (value === null) return '<span class="json-null">null</span>';
+    if (value === undefined) return '<span class="json-null">undefined</span>';
+    if (typeof value === 'string') return '<span class="json-string">"' + escapeHtml(value) + '"</span>';
+    if (typeof value === 'number') return '<span class="json-number">' + value + '</span>';
+    if (typeof value === 'boolean') return '<span class="json-boolean">' + value + '</span>';
+    
+    if (typeof value === 'object' && depth >= limit) {
+        const size = Array.isArray(value) ? value.length : Object.keys(value).length;
+        if (size > 0) {
+            lazyJsonValues.push({ value: value, depth: depth });
+            const label = Array.isArray(value) ? '[' + size + ' items]' : '{' + size + ' keys}';
+            return '<details class="json-lazy" data-lazy="' + (lazyJsonValues.length - 1) + '"><summary>' + label + '</summary></details>';
+        }
+    }
+    
+    if (Array.isArray(value)) {
+        if (value.length === 0) return '<span class="json-array">[]</span>';
+        let html = '<div class="json-array">[<br>';
+        value.forEach((item, index) => {
+            const indent = '&nbsp;'.repeat((depth + 1) * 2);
+            html += indent + renderRichJson(item, depth + 1, limit);
+            if (index < value.length - 1) html += ',';
+            html += '<br>';
+        });
+        html += '&nbsp;'.repeat(depth * 2) + ']</div>';
+        return html;
+    }
+    
+    if (typeof value === 'object') {
+        const keys = Object.keys(value);
+        if (keys.length === 0) return '<span class="json-object">{}</span>';
+        let html = '<div class="json-object">{<br>';
+        keys.forEach((key, index) => {
+            const indent = '&nbsp;'.repeat((depth + 1) * 2);
+            html += indent + '<span class="json-key">"' + escapeHtml(key) + '"</span>: ' + renderRichJson(value[key], depth + 1, limit);
+            if (index < keys.length - 1) html += ',';
+            html += '<br>';
+        });
+        html += '&nbsp;'.repeat(depth * 2) + '}</div>';
+        return html;
+    }
+    
+    return escapeHtml(String(value));
+}
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
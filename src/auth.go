@@ -1,19 +1,67 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/huh"
+	"golang.org/x/term"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-// isGoogleCloudContext checks if a context is a Google Cloud context
-func isGoogleCloudContext(contextName string) bool {
-	return strings.Contains(contextName, "connectgateway") ||
-		strings.Contains(contextName, "gke_") ||
-		strings.Contains(contextName, "google")
+// googleCloudServerSuffixes are API server host suffixes only used by Google Cloud clusters
+var googleCloudServerSuffixes = []string{
+	"connectgateway.googleapis.com", // Connect Gateway, including regional endpoints
+	".gke.goog",                     // GKE DNS-based control plane endpoints
+}
+
+// isGoogleCloudContext checks if a context is a Google Cloud context. Detection is
+// driven by the kubeconfig contents (cluster server URL and credential plugin), so
+// renamed contexts are still recognised and context names alone never match.
+func isGoogleCloudContext(kubeconfig, contextName string) bool {
+	config, err := loadKubeconfig(kubeconfig)
+	if err != nil {
+		return false
+	}
+
+	kubeContext, ok := config.Contexts[contextName]
+	if !ok {
+		return false
+	}
+
+	if cluster, ok := config.Clusters[kubeContext.Cluster]; ok && isGoogleCloudServer(cluster.Server) {
+		return true
+	}
+
+	authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]
+	return ok && isGKEPlugin(authInfo)
+}
+
+// isGoogleCloudServer checks if an API server URL points at a Google Cloud endpoint
+func isGoogleCloudServer(server string) bool {
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return false
+	}
+
+	host := serverURL.Hostname()
+	for _, suffix := range googleCloudServerSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
 }
 
 // checkGCloudAuth verifies if gcloud authentication is active
@@ -31,18 +79,138 @@ func checkGCloudAuth() error {
 	return nil
 }
 
-// promptGCloudLogin prompts user to authenticate with Google Cloud
-func promptGCloudLogin() error {
-	fmt.Println("\n⚠️  Google Cloud authentication required!")
-	fmt.Println("🔐 Your gcloud credentials have expired or are not set up.")
-	fmt.Println("📍 This is required to access Google Kubernetes Engine (GKE) clusters.")
+// gcloudAuthMode selects how expired Google Cloud credentials are refreshed
+type gcloudAuthMode string
+
+const (
+	// gcloudAuthBrowser runs `gcloud auth login`, which opens a browser
+	gcloudAuthBrowser gcloudAuthMode = "browser"
+	// gcloudAuthNoBrowser runs `gcloud auth login --no-launch-browser` and prompts for the code
+	gcloudAuthNoBrowser gcloudAuthMode = "no-browser"
+	// gcloudAuthADC makes gke-gcloud-auth-plugin use application default credentials
+	gcloudAuthADC gcloudAuthMode = "adc"
+	// gcloudAuthServiceAccount activates a service account key file without prompting
+	gcloudAuthServiceAccount gcloudAuthMode = "service-account"
+	// gcloudAuthNone never attempts to refresh credentials and fails fast instead
+	gcloudAuthNone gcloudAuthMode = "none"
+)
+
+// gcloudAuthOptions holds the Google Cloud re-authentication settings
+type gcloudAuthOptions struct {
+	Mode    gcloudAuthMode
+	KeyFile string
+}
+
+// gcloudAuth is the Google Cloud re-authentication configuration for this run
+var gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthBrowser}
+
+// stdinIsTerminal reports whether stdin is attached to a terminal, i.e. whether
+// the user can answer prompts and interactive login flows
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// authSession holds where the authentication preflight reports its progress and
+// whether it may run interactive logins
+type authSession struct {
+	out         io.Writer
+	interactive bool
+}
+
+// promptUnavailable explains why a login command cannot be run, or returns nil when
+// the user can answer it
+func (s authSession) promptUnavailable(commandLine string) error {
+	if !s.interactive {
+		return fmt.Errorf("cannot run '%s' because the comparison runs in the background", commandLine)
+	}
+	if !stdinIsTerminal() {
+		return fmt.Errorf("cannot run '%s' because stdin is not a terminal", commandLine)
+	}
+	return nil
+}
+
+// parseGCloudAuthOptions validates the --gcloud-auth and --gcloud-key-file flags.
+// Passing only a key file implies the service-account mode.
+func parseGCloudAuthOptions(mode, keyFile string) (gcloudAuthOptions, error) {
+	options := gcloudAuthOptions{Mode: gcloudAuthMode(mode), KeyFile: keyFile}
+	if options.Mode == "" {
+		options.Mode = gcloudAuthBrowser
+	}
+	if keyFile != "" && options.Mode == gcloudAuthBrowser {
+		options.Mode = gcloudAuthServiceAccount
+	}
+
+	switch options.Mode {
+	case gcloudAuthBrowser, gcloudAuthNoBrowser, gcloudAuthADC, gcloudAuthNone:
+		if keyFile != "" {
+			return options, fmt.Errorf("--gcloud-key-file can only be used with --gcloud-auth=%s", gcloudAuthServiceAccount)
+		}
+	case gcloudAuthServiceAccount:
+		if keyFile == "" {
+			return options, fmt.Errorf("--gcloud-auth=%s requires --gcloud-key-file", gcloudAuthServiceAccount)
+		}
+		if _, err := os.Stat(keyFile); err != nil {
+			return options, fmt.Errorf("service account key file: %w", err)
+		}
+	default:
+		return options, fmt.Errorf("unknown gcloud auth mode %q (expected %s, %s, %s, %s or %s)", mode,
+			gcloudAuthBrowser, gcloudAuthNoBrowser, gcloudAuthADC, gcloudAuthServiceAccount, gcloudAuthNone)
+	}
+
+	return options, nil
+}
+
+// withGCloudAuthMode returns the exec config adjusted for the Google Cloud auth mode:
+// in ADC mode gke-gcloud-auth-plugin is told to use application default credentials
+// instead of the gcloud CLI login
+func withGCloudAuthMode(execConfig *clientcmdapi.ExecConfig) *clientcmdapi.ExecConfig {
+	const adcFlag = "--use_application_default_credentials"
+
+	if execConfig == nil || gcloudAuth.Mode != gcloudAuthADC {
+		return execConfig
+	}
+	if strings.TrimSuffix(filepath.Base(execConfig.Command), ".exe") != "gke-gcloud-auth-plugin" || contains(execConfig.Args, adcFlag) {
+		return execConfig
+	}
+
+	adjusted := execConfig.DeepCopy()
+	adjusted.Args = append(adjusted.Args, adcFlag)
+	return adjusted
+}
+
+// promptGCloudLogin refreshes Google Cloud credentials according to the configured
+// auth mode, only prompting when the mode is interactive and stdin is a terminal
+func promptGCloudLogin(session authSession) error {
+	fmt.Fprintln(session.out, "\n⚠️  Google Cloud authentication required!")
+	fmt.Fprintln(session.out, "🔐 Your gcloud credentials have expired or are not set up.")
+	fmt.Fprintln(session.out, "📍 This is required to access Google Kubernetes Engine (GKE) clusters.")
+
+	switch gcloudAuth.Mode {
+	case gcloudAuthNone:
+		return fmt.Errorf("gcloud credentials are invalid and --gcloud-auth=%s disables re-authentication", gcloudAuthNone)
+	case gcloudAuthADC:
+		return fmt.Errorf("application default credentials are not usable; set GOOGLE_APPLICATION_CREDENTIALS, attach a service account, or run 'gcloud auth application-default login'")
+	case gcloudAuthServiceAccount:
+		return activateGCloudServiceAccount(session, gcloudAuth.KeyFile)
+	}
+
+	loginArgs := []string{"auth", "login"}
+	if gcloudAuth.Mode == gcloudAuthNoBrowser {
+		loginArgs = append(loginArgs, "--no-launch-browser")
+	}
+	commandLine := "gcloud " + strings.Join(loginArgs, " ")
+
+	if err := session.promptUnavailable(commandLine); err != nil {
+		return fmt.Errorf("%w; authenticate beforehand or use --gcloud-auth=%s, --gcloud-auth=%s with --gcloud-key-file, or --gcloud-auth=%s",
+			err, gcloudAuthADC, gcloudAuthServiceAccount, gcloudAuthNone)
+	}
 
 	var confirm bool
 
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewConfirm().
-				Title("Run 'gcloud auth login' now?").
+				Title(fmt.Sprintf("Run '%s' now?", commandLine)).
 				Affirmative("Yes").
 				Negative("No").
 				Value(&confirm),
@@ -58,19 +226,24 @@ func promptGCloudLogin() error {
 		return fmt.Errorf("gcloud authentication is required to continue")
 	}
 
-	fmt.Println("\n🚀 Opening browser for Google Cloud authentication...")
-	fmt.Println("📱 Please complete the authentication process in your browser.")
+	if gcloudAuth.Mode == gcloudAuthNoBrowser {
+		fmt.Fprintln(session.out, "\n🔗 Starting Google Cloud authentication without a browser...")
+		fmt.Fprintln(session.out, "📱 Open the printed URL on any device, then paste the verification code here.")
+	} else {
+		fmt.Fprintln(session.out, "\n🚀 Opening browser for Google Cloud authentication...")
+		fmt.Fprintln(session.out, "📱 Please complete the authentication process in your browser.")
+	}
 
-	cmd := exec.Command("gcloud", "auth", "login")
-	cmd.Stdout = os.Stdout
+	cmd := exec.Command("gcloud", loginArgs...)
+	cmd.Stdout = session.out
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("gcloud auth login failed: %w", err)
+		return fmt.Errorf("%s failed: %w", commandLine, err)
 	}
 
-	fmt.Println("\n✅ Authentication completed!")
+	fmt.Fprintln(session.out, "\n✅ Authentication completed!")
 
 	// Verify authentication worked
 	if err := checkGCloudAuth(); err != nil {
@@ -80,22 +253,431 @@ func promptGCloudLogin() error {
 	return nil
 }
 
-// ensureGCloudAuth ensures Google Cloud authentication is active for the given context
-func ensureGCloudAuth(contextName string) error {
-	if !isGoogleCloudContext(contextName) {
-		return nil // Not a Google Cloud context, no auth needed
+// activateGCloudServiceAccount authenticates gcloud with a service account key file
+func activateGCloudServiceAccount(session authSession, keyFile string) error {
+	fmt.Fprintf(session.out, "\n🔑 Activating service account from %s...\n", keyFile)
+
+	cmd := exec.Command("gcloud", "auth", "activate-service-account", "--key-file="+keyFile)
+	cmd.Stdout = session.out
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gcloud auth activate-service-account failed: %w", err)
 	}
 
-	fmt.Printf("🔍 Checking Google Cloud authentication for context: %s\n", contextName)
+	fmt.Fprintln(session.out, "✅ Service account activated!")
 
 	if err := checkGCloudAuth(); err != nil {
-		fmt.Printf("⚠️  Authentication issue detected: %v\n", err)
-		if err := promptGCloudLogin(); err != nil {
-			return err
+		return fmt.Errorf("authentication verification failed: %w", err)
+	}
+
+	return nil
+}
+
+// credentialPlugin describes a kubeconfig credential mechanism that the auth
+// preflight knows how to recognise, verify and refresh
+type credentialPlugin struct {
+	// Name is shown to the user when reporting on the credentials
+	Name string
+	// Matches reports whether a kubeconfig user is configured for this plugin
+	Matches func(authInfo *clientcmdapi.AuthInfo) bool
+	// Login interactively refreshes the credentials the plugin relies on
+	Login func(authInfo *clientcmdapi.AuthInfo, session authSession) error
+}
+
+// credentialPlugins lists the supported credential plugins in detection order.
+// The generic exec entry must stay last as it matches any exec plugin.
+var credentialPlugins = []credentialPlugin{
+	{
+		Name:    "Google Cloud (gke-gcloud-auth-plugin)",
+		Matches: isGKEPlugin,
+		Login: func(authInfo *clientcmdapi.AuthInfo, session authSession) error {
+			return promptGCloudLogin(session)
+		},
+	},
+	{
+		Name:    "AWS EKS",
+		Matches: isAWSPlugin,
+		Login: func(authInfo *clientcmdapi.AuthInfo, session authSession) error {
+			return promptPluginLogin(session, awsLoginCommand(authInfo))
+		},
+	},
+	{
+		Name:    "Azure AKS (kubelogin)",
+		Matches: isAzurePlugin,
+		Login: func(authInfo *clientcmdapi.AuthInfo, session authSession) error {
+			return promptPluginLogin(session, azureLoginCommand(authInfo))
+		},
+	},
+	{
+		Name:    "OIDC (oidc-login)",
+		Matches: isOIDCPlugin,
+		Login: func(authInfo *clientcmdapi.AuthInfo, session authSession) error {
+			if authInfo.Exec == nil {
+				return fmt.Errorf("the oidc auth-provider cannot refresh an expired login; sign in again with your identity provider and update the kubeconfig")
+			}
+			return promptPluginLogin(session, execPluginCommand(authInfo))
+		},
+	},
+	{
+		Name: "exec credential plugin",
+		Matches: func(authInfo *clientcmdapi.AuthInfo) bool {
+			return authInfo.Exec != nil
+		},
+		Login: func(authInfo *clientcmdapi.AuthInfo, session authSession) error {
+			return promptPluginLogin(session, execPluginCommand(authInfo))
+		},
+	},
+}
+
+// execCommandName returns the executable name of the user's exec plugin, if any
+func execCommandName(authInfo *clientcmdapi.AuthInfo) string {
+	if authInfo.Exec == nil {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(authInfo.Exec.Command), ".exe")
+}
+
+// authProviderName returns the name of the user's legacy auth-provider, if any
+func authProviderName(authInfo *clientcmdapi.AuthInfo) string {
+	if authInfo.AuthProvider == nil {
+		return ""
+	}
+	return authInfo.AuthProvider.Name
+}
+
+// isGKEPlugin checks if the user authenticates with gke-gcloud-auth-plugin or the removed
+// gcp auth-provider, which ensureClusterAuth reports without offering a login
+func isGKEPlugin(authInfo *clientcmdapi.AuthInfo) bool {
+	return execCommandName(authInfo) == "gke-gcloud-auth-plugin" || authProviderName(authInfo) == "gcp"
+}
+
+// isAWSPlugin checks if the user authenticates with `aws eks get-token` or aws-iam-authenticator
+func isAWSPlugin(authInfo *clientcmdapi.AuthInfo) bool {
+	switch execCommandName(authInfo) {
+	case "aws-iam-authenticator":
+		return true
+	case "aws":
+		return contains(authInfo.Exec.Args, "eks") && contains(authInfo.Exec.Args, "get-token")
+	}
+	return false
+}
+
+// isAzurePlugin checks if the user authenticates with Azure kubelogin
+func isAzurePlugin(authInfo *clientcmdapi.AuthInfo) bool {
+	return execCommandName(authInfo) == "kubelogin" || authProviderName(authInfo) == "azure"
+}
+
+// isOIDCPlugin checks if the user authenticates with the kubectl oidc-login plugin or the oidc auth-provider
+func isOIDCPlugin(authInfo *clientcmdapi.AuthInfo) bool {
+	switch execCommandName(authInfo) {
+	case "kubectl-oidc_login":
+		return true
+	case "kubectl":
+		return len(authInfo.Exec.Args) > 0 && authInfo.Exec.Args[0] == "oidc-login"
+	}
+	return authProviderName(authInfo) == "oidc"
+}
+
+// detectCredentialPlugin returns the credential plugin used by a kubeconfig user,
+// or nil when the user relies on static credentials (token, client certificate, ...)
+func detectCredentialPlugin(authInfo *clientcmdapi.AuthInfo) *credentialPlugin {
+	for i := range credentialPlugins {
+		if credentialPlugins[i].Matches(authInfo) {
+			return &credentialPlugins[i]
 		}
-	} else {
-		fmt.Println("✅ Google Cloud authentication is active")
+	}
+	return nil
+}
+
+// execPluginArg returns the value of a --flag/-f style argument of the exec plugin
+func execPluginArg(authInfo *clientcmdapi.AuthInfo, names ...string) string {
+	if authInfo.Exec == nil {
+		return ""
+	}
+	args := authInfo.Exec.Args
+	for i, arg := range args {
+		for _, name := range names {
+			if arg == name && i+1 < len(args) {
+				return args[i+1]
+			}
+			if strings.HasPrefix(arg, name+"=") {
+				return strings.TrimPrefix(arg, name+"=")
+			}
+		}
+	}
+	return ""
+}
+
+// execPluginEnv returns the value of an environment variable set for the exec plugin
+func execPluginEnv(authInfo *clientcmdapi.AuthInfo, name string) string {
+	if authInfo.Exec == nil {
+		return ""
+	}
+	for _, env := range authInfo.Exec.Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+// execPluginCommand returns the exec plugin invocation itself, which prompts for
+// a fresh login when run from a terminal
+func execPluginCommand(authInfo *clientcmdapi.AuthInfo) []string {
+	return append([]string{execPluginPath(authInfo.Exec.Command, authInfo.LocationOfOrigin)}, authInfo.Exec.Args...)
+}
+
+// execPluginPath resolves a relative exec plugin path against the directory of the
+// kubeconfig file that defines it, as client-go does. Bare command names are left to
+// the PATH lookup.
+func execPluginPath(command, kubeconfigFile string) string {
+	if kubeconfigFile == "" || filepath.IsAbs(command) || !strings.ContainsRune(command, filepath.Separator) {
+		return command
+	}
+	return filepath.Join(filepath.Dir(kubeconfigFile), command)
+}
+
+// hasRemovedAuthProvider reports whether the user relies on a legacy auth-provider that
+// client-go no longer supports, which no login can fix
+func hasRemovedAuthProvider(authInfo *clientcmdapi.AuthInfo) bool {
+	switch authProviderName(authInfo) {
+	case "", "oidc":
+		return false
+	}
+	return authInfo.Exec == nil
+}
+
+// awsLoginCommand returns the AWS SSO login command for the profile used by the plugin
+func awsLoginCommand(authInfo *clientcmdapi.AuthInfo) []string {
+	profile := execPluginArg(authInfo, "--profile")
+	if profile == "" {
+		profile = execPluginEnv(authInfo, "AWS_PROFILE")
+	}
+	if profile == "" {
+		return []string{"aws", "sso", "login"}
+	}
+	return []string{"aws", "sso", "login", "--profile", profile}
+}
+
+// azureLoginCommand returns the login command matching kubelogin's login mode
+func azureLoginCommand(authInfo *clientcmdapi.AuthInfo) []string {
+	if authInfo.Exec == nil || execPluginArg(authInfo, "--login", "-l") == "azurecli" {
+		return []string{"az", "login"}
+	}
+	return execPluginCommand(authInfo)
+}
+
+// getContextAuthInfo returns the kubeconfig user referenced by the given context
+func getContextAuthInfo(kubeconfig, contextName string) (*clientcmdapi.AuthInfo, error) {
+	config, err := loadKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeContext, ok := config.Contexts[contextName]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return clientcmdapi.NewAuthInfo(), nil
+	}
+	return authInfo, nil
+}
+
+// execCredential is the subset of client.authentication.k8s.io ExecCredential we inspect
+type execCredential struct {
+	Status *struct {
+		Token                 string `json:"token"`
+		ClientCertificateData string `json:"clientCertificateData"`
+	} `json:"status"`
+}
+
+// verifyCredentials checks that the user's credential plugin currently produces a token
+func verifyCredentials(authInfo *clientcmdapi.AuthInfo) error {
+	if authInfo.Exec != nil {
+		return verifyExecPlugin(withGCloudAuthMode(authInfo.Exec), authInfo.LocationOfOrigin)
+	}
+
+	switch authProviderName(authInfo) {
+	case "":
+		return nil
+	case "oidc":
+		if authInfo.AuthProvider.Config["id-token"] == "" && authInfo.AuthProvider.Config["refresh-token"] == "" {
+			return fmt.Errorf("oidc auth-provider has no id-token or refresh-token")
+		}
+		return nil
+	default:
+		return fmt.Errorf("the %q auth-provider is no longer supported by client-go; migrate the kubeconfig to its exec credential plugin", authProviderName(authInfo))
+	}
+}
+
+// verifyExecPlugin runs an exec credential plugin non-interactively the same way
+// client-go would and checks that it returns a credential. kubeconfigFile is the file
+// that defines the plugin, against which a relative command path is resolved.
+func verifyExecPlugin(execConfig *clientcmdapi.ExecConfig, kubeconfigFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	apiVersion := execConfig.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1beta1"
+	}
+
+	cmd := exec.CommandContext(ctx, execPluginPath(execConfig.Command, kubeconfigFile), execConfig.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range execConfig.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf(`KUBERNETES_EXEC_INFO={"apiVersion":%q,"kind":"ExecCredential","spec":{"interactive":false}}`, apiVersion))
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) && execConfig.InstallHint != "" {
+			return fmt.Errorf("%s not found: %s", execConfig.Command, execConfig.InstallHint)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%s failed: %w: %s", execConfig.Command, err, message)
+		}
+		return fmt.Errorf("%s failed: %w", execConfig.Command, err)
+	}
+
+	var credential execCredential
+	if err := json.Unmarshal(output, &credential); err != nil {
+		return fmt.Errorf("%s returned an invalid ExecCredential: %w", execConfig.Command, err)
+	}
+	if credential.Status == nil || (credential.Status.Token == "" && credential.Status.ClientCertificateData == "") {
+		return fmt.Errorf("%s did not return a token", execConfig.Command)
+	}
+
+	return nil
+}
+
+// promptPluginLogin asks the user to run a login command and runs it attached to the terminal
+func promptPluginLogin(session authSession, command []string) error {
+	commandLine := strings.Join(command, " ")
+
+	if err := session.promptUnavailable(commandLine); err != nil {
+		return fmt.Errorf("%w; authenticate beforehand", err)
+	}
+
+	var confirm bool
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Run '%s' now?", commandLine)).
+				Affirmative("Yes").
+				Negative("No").
+				Value(&confirm),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return err
+	}
+
+	if !confirm {
+		return fmt.Errorf("re-authentication with '%s' is required to continue", commandLine)
+	}
+
+	fmt.Fprintf(session.out, "\n🚀 Running '%s'...\n", commandLine)
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdout = session.out
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", commandLine, err)
+	}
+
+	return nil
+}
+
+// reauthenticateContext runs the interactive re-login for a context whose credentials
+// were rejected by the API server, then verifies the plugin produces a token again
+func reauthenticateContext(kubeconfig, contextName string, session authSession) error {
+	authInfo, err := getContextAuthInfo(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+
+	plugin := detectCredentialPlugin(authInfo)
+	if plugin == nil {
+		return fmt.Errorf("the static credentials for context %s were rejected; update the kubeconfig", contextName)
+	}
+	if hasRemovedAuthProvider(authInfo) {
+		return verifyCredentials(authInfo)
+	}
+
+	if err := plugin.Login(authInfo, session); err != nil {
+		return err
+	}
+
+	return verifyCredentials(authInfo)
+}
+
+// isAuthError reports whether an API call failed because the context's credentials
+// were rejected (HTTP 401) or because its credential plugin could not produce any.
+// Plugin failures surface from client-go as transport errors, so those are confirmed
+// by re-running the plugin rather than by inspecting the error text.
+func isAuthError(err error, kubeconfig, contextName string) bool {
+	if apierrors.IsUnauthorized(err) {
+		return true
+	}
+
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return false
+	}
+
+	authInfo, authErr := getContextAuthInfo(kubeconfig, contextName)
+	if authErr != nil || detectCredentialPlugin(authInfo) == nil {
+		return false
+	}
+	return verifyCredentials(authInfo) != nil
+}
+
+// ensureClusterAuth runs the authentication preflight for a context: it detects the
+// credential plugin configured in kubeconfig, verifies that it produces a token and
+// offers the matching interactive re-login when it does not
+func ensureClusterAuth(kubeconfig, contextName string, session authSession) error {
+	authInfo, err := getContextAuthInfo(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+
+	plugin := detectCredentialPlugin(authInfo)
+	if plugin == nil {
+		return nil // Static credentials, nothing to refresh
+	}
+
+	fmt.Fprintf(session.out, "🔍 Checking %s credentials for context: %s\n", plugin.Name, contextName)
+
+	err = verifyCredentials(authInfo)
+	if err == nil {
+		fmt.Fprintf(session.out, "✅ %s credentials are valid\n", plugin.Name)
+		return nil
+	}
+	fmt.Fprintf(session.out, "⚠️  Authentication issue detected: %v\n", err)
+	if hasRemovedAuthProvider(authInfo) {
+		return err
+	}
+
+	if err := plugin.Login(authInfo, session); err != nil {
+		return err
+	}
+
+	if err := verifyCredentials(authInfo); err != nil {
+		return fmt.Errorf("authentication verification failed: %w", err)
 	}
 
+	fmt.Fprintf(session.out, "✅ %s credentials are valid\n", plugin.Name)
 	return nil
 }
@@ -1,82 +1,139 @@
 package main
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 var _ = Describe("Auth", func() {
 	Describe("isGoogleCloudContext function", func() {
-		Context("when context contains GKE patterns", func() {
-			It("should return true for gke_ prefix", func() {
-				result := isGoogleCloudContext("gke_project_zone_cluster")
-				Expect(result).To(BeTrue())
+		var tempDir string
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "k8s-compare-auth")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		// writeContext writes a kubeconfig with a single context using the given server and user
+		writeContext := func(contextName, server, user string) string {
+			content := `apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: ` + server + `
+users:
+- name: user
+  user:
+` + user + `
+contexts:
+- name: ` + contextName + `
+  context:
+    cluster: cluster
+    user: user
+`
+			path := filepath.Join(tempDir, "config.yaml")
+			Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+			return path
+		}
+
+		const tokenUser = "    token: static-token"
+		const gkeUser = `    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: gke-gcloud-auth-plugin`
+
+		Context("when the kubeconfig describes a Google Cloud cluster", func() {
+			It("should return true for a renamed context using gke-gcloud-auth-plugin", func() {
+				kubeconfig := writeContext("prod", "https://34.120.1.1", gkeUser)
+				Expect(isGoogleCloudContext(kubeconfig, "prod")).To(BeTrue())
 			})
 
-			It("should return true for connectgateway", func() {
-				result := isGoogleCloudContext("connectgateway_project_location_cluster")
-				Expect(result).To(BeTrue())
+			It("should return true for a Connect Gateway server", func() {
+				kubeconfig := writeContext("fleet", "https://connectgateway.googleapis.com/v1/projects/123/locations/global/gkeMemberships/prod", tokenUser)
+				Expect(isGoogleCloudContext(kubeconfig, "fleet")).To(BeTrue())
 			})
 
-			It("should return true for google", func() {
-				result := isGoogleCloudContext("google-cluster-context")
-				Expect(result).To(BeTrue())
+			It("should return true for a regional Connect Gateway server", func() {
+				kubeconfig := writeContext("fleet", "https://us-central1-connectgateway.googleapis.com/v1/projects/123/locations/us-central1/gkeMemberships/prod", tokenUser)
+				Expect(isGoogleCloudContext(kubeconfig, "fleet")).To(BeTrue())
 			})
-		})
 
-		Context("when context does not contain GKE patterns", func() {
-			It("should return false for minikube", func() {
-				result := isGoogleCloudContext("minikube")
-				Expect(result).To(BeFalse())
+			It("should return true for a GKE DNS endpoint", func() {
+				kubeconfig := writeContext("dns", "https://gke-0123456789abcdef.us-central1.gke.goog", tokenUser)
+				Expect(isGoogleCloudContext(kubeconfig, "dns")).To(BeTrue())
 			})
+		})
 
-			It("should return false for kind", func() {
-				result := isGoogleCloudContext("kind-cluster")
-				Expect(result).To(BeFalse())
+		Context("when the kubeconfig describes another cluster", func() {
+			It("should return false for a context whose name merely mentions google", func() {
+				kubeconfig := writeContext("google-cluster-context", "https://127.0.0.1:6443", tokenUser)
+				Expect(isGoogleCloudContext(kubeconfig, "google-cluster-context")).To(BeFalse())
 			})
 
-			It("should return false for docker-desktop", func() {
-				result := isGoogleCloudContext("docker-desktop")
-				Expect(result).To(BeFalse())
+			It("should return false for a gke_ named context without GKE configuration", func() {
+				kubeconfig := writeContext("gke_project_zone_cluster", "https://10.0.0.1", tokenUser)
+				Expect(isGoogleCloudContext(kubeconfig, "gke_project_zone_cluster")).To(BeFalse())
 			})
 
 			It("should return false for AWS EKS", func() {
-				result := isGoogleCloudContext("arn:aws:eks:us-west-2:123456789012:cluster/my-cluster")
-				Expect(result).To(BeFalse())
+				kubeconfig := writeContext("arn:aws:eks:us-west-2:123456789012:cluster/my-cluster",
+					"https://ABCDEF.gr7.us-west-2.eks.amazonaws.com", `    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args: ["eks", "get-token", "--cluster-name", "my-cluster"]`)
+				Expect(isGoogleCloudContext(kubeconfig, "arn:aws:eks:us-west-2:123456789012:cluster/my-cluster")).To(BeFalse())
 			})
 
-			It("should return false for Azure AKS", func() {
-				result := isGoogleCloudContext("aks-cluster")
-				Expect(result).To(BeFalse())
+			It("should not be fooled by googleapis in the URL path", func() {
+				kubeconfig := writeContext("proxy", "https://proxy.example.com/connectgateway.googleapis.com", tokenUser)
+				Expect(isGoogleCloudContext(kubeconfig, "proxy")).To(BeFalse())
 			})
 		})
 
-		Context("when context is empty or nil", func() {
-			It("should return false for empty string", func() {
-				result := isGoogleCloudContext("")
-				Expect(result).To(BeFalse())
+		Context("when the context cannot be resolved", func() {
+			It("should return false for an unknown context", func() {
+				kubeconfig := writeContext("prod", "https://34.120.1.1", gkeUser)
+				Expect(isGoogleCloudContext(kubeconfig, "staging")).To(BeFalse())
 			})
-		})
 
-		Context("edge cases", func() {
-			It("should be case sensitive", func() {
-				result := isGoogleCloudContext("GKE_project_zone_cluster")
-				Expect(result).To(BeFalse())
+			It("should return false for an empty context name", func() {
+				kubeconfig := writeContext("prod", "https://34.120.1.1", gkeUser)
+				Expect(isGoogleCloudContext(kubeconfig, "")).To(BeFalse())
 			})
+		})
+	})
 
-			It("should match partial strings", func() {
-				result := isGoogleCloudContext("my-gke_cluster-name")
-				Expect(result).To(BeTrue())
-			})
+	Describe("isAuthError function", func() {
+		It("should treat 401 Unauthorized as an auth error", func() {
+			err := apierrors.NewUnauthorized("token expired")
+			Expect(isAuthError(err, "", "any")).To(BeTrue())
+		})
 
-			It("should match google anywhere in string", func() {
-				result := isGoogleCloudContext("my-google-cluster")
-				Expect(result).To(BeTrue())
-			})
+		It("should not treat 403 Forbidden as an auth error", func() {
+			err := apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", errors.New("rbac"))
+			Expect(isAuthError(err, "", "any")).To(BeFalse())
+		})
+
+		It("should not treat errors mentioning auth as auth errors", func() {
+			err := errors.New(`namespaces "auth" not found`)
+			Expect(isAuthError(err, "", "any")).To(BeFalse())
 		})
 	})
 
-	// Note: checkGCloudAuth, promptGCloudLogin, and ensureGCloudAuth are harder to test
+	// Note: checkGCloudAuth and promptGCloudLogin are harder to test
 	// as they interact with external commands and user input. These would typically
 	// require mocking or integration tests.
 
@@ -114,30 +171,390 @@ var _ = Describe("Auth", func() {
 		})
 	})
 
-	Describe("ensureGCloudAuth function", func() {
-		Context("when context is not a Google Cloud context", func() {
-			It("should return nil without checking auth", func() {
-				// This test can work since it doesn't call external commands
-				err := ensureGCloudAuth("minikube")
-				Expect(err).To(BeNil())
+	Describe("parseGCloudAuthOptions function", func() {
+		It("should default to the browser flow", func() {
+			options, err := parseGCloudAuthOptions("", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options.Mode).To(Equal(gcloudAuthBrowser))
+		})
+
+		It("should accept the headless modes", func() {
+			for _, mode := range []string{"no-browser", "adc", "none"} {
+				options, err := parseGCloudAuthOptions(mode, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(options.Mode)).To(Equal(mode))
+			}
+		})
+
+		It("should imply the service-account mode from a key file", func() {
+			keyFile, err := os.CreateTemp("", "key-*.json")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(keyFile.Name())
+			keyFile.Close()
+
+			options, err := parseGCloudAuthOptions("browser", keyFile.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options.Mode).To(Equal(gcloudAuthServiceAccount))
+			Expect(options.KeyFile).To(Equal(keyFile.Name()))
+		})
+
+		It("should require a key file for the service-account mode", func() {
+			_, err := parseGCloudAuthOptions("service-account", "")
+			Expect(err).To(MatchError(ContainSubstring("--gcloud-key-file")))
+		})
+
+		It("should reject a missing key file", func() {
+			_, err := parseGCloudAuthOptions("service-account", "/nonexistent/key.json")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject unknown modes", func() {
+			_, err := parseGCloudAuthOptions("magic", "")
+			Expect(err).To(MatchError(ContainSubstring("unknown gcloud auth mode")))
+		})
+	})
+
+	Describe("headless Google Cloud authentication", func() {
+		var originalAuth gcloudAuthOptions
+		var originalIsTerminal func() bool
+
+		BeforeEach(func() {
+			originalAuth = gcloudAuth
+			originalIsTerminal = stdinIsTerminal
+		})
+
+		AfterEach(func() {
+			gcloudAuth = originalAuth
+			stdinIsTerminal = originalIsTerminal
+		})
+
+		Context("when adjusting the GKE exec plugin", func() {
+			gkePlugin := &clientcmdapi.ExecConfig{Command: "gke-gcloud-auth-plugin"}
+
+			It("should add the ADC flag in adc mode", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthADC}
+				adjusted := withGCloudAuthMode(gkePlugin)
+				Expect(adjusted.Args).To(ContainElement("--use_application_default_credentials"))
+				Expect(gkePlugin.Args).To(BeEmpty())
+			})
+
+			It("should leave the plugin untouched in browser mode", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthBrowser}
+				Expect(withGCloudAuthMode(gkePlugin)).To(Equal(gkePlugin))
+			})
+
+			It("should leave other plugins untouched in adc mode", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthADC}
+				awsPlugin := &clientcmdapi.ExecConfig{Command: "aws", Args: []string{"eks", "get-token"}}
+				Expect(withGCloudAuthMode(awsPlugin)).To(Equal(awsPlugin))
+			})
+		})
+
+		Context("when credentials need refreshing", func() {
+			It("should fail fast instead of prompting when stdin is not a terminal", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthBrowser}
+				stdinIsTerminal = func() bool { return false }
+
+				err := promptGCloudLogin(authSession{out: GinkgoWriter, interactive: true})
+				Expect(err).To(MatchError(ContainSubstring("stdin is not a terminal")))
+			})
+
+			It("should fail fast for the no-browser flow when stdin is not a terminal", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthNoBrowser}
+				stdinIsTerminal = func() bool { return false }
+
+				err := promptGCloudLogin(authSession{out: GinkgoWriter, interactive: true})
+				Expect(err).To(MatchError(ContainSubstring("--no-launch-browser")))
+			})
+
+			It("should never prompt in none mode", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthNone}
+				stdinIsTerminal = func() bool { return true }
+
+				err := promptGCloudLogin(authSession{out: GinkgoWriter, interactive: true})
+				Expect(err).To(MatchError(ContainSubstring("disables re-authentication")))
 			})
 
-			It("should return nil for kind cluster", func() {
-				err := ensureGCloudAuth("kind-cluster")
-				Expect(err).To(BeNil())
+			It("should explain how to provide application default credentials", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthADC}
+
+				err := promptGCloudLogin(authSession{out: GinkgoWriter, interactive: true})
+				Expect(err).To(MatchError(ContainSubstring("GOOGLE_APPLICATION_CREDENTIALS")))
+			})
+
+			It("should never prompt for comparisons run in the background", func() {
+				gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthBrowser}
+				stdinIsTerminal = func() bool { return true }
+
+				err := promptGCloudLogin(authSession{out: GinkgoWriter})
+				Expect(err).To(MatchError(ContainSubstring("runs in the background")))
+
+				err = promptPluginLogin(authSession{out: GinkgoWriter}, []string{"aws", "sso", "login"})
+				Expect(err).To(MatchError(ContainSubstring("runs in the background")))
 			})
 
-			It("should return nil for docker-desktop", func() {
-				err := ensureGCloudAuth("docker-desktop")
-				Expect(err).To(BeNil())
+			It("should fail fast for other credential plugins when stdin is not a terminal", func() {
+				stdinIsTerminal = func() bool { return false }
+
+				err := promptPluginLogin(authSession{out: GinkgoWriter, interactive: true}, []string{"aws", "sso", "login"})
+				Expect(err).To(MatchError(ContainSubstring("stdin is not a terminal")))
 			})
 		})
+	})
+
+	Describe("detectCredentialPlugin function", func() {
+		execUser := func(command string, args ...string) *clientcmdapi.AuthInfo {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{Command: command, Args: args}
+			return authInfo
+		}
+
+		Context("when the user has an exec plugin", func() {
+			It("should detect gke-gcloud-auth-plugin", func() {
+				plugin := detectCredentialPlugin(execUser("gke-gcloud-auth-plugin"))
+				Expect(plugin).NotTo(BeNil())
+				Expect(plugin.Name).To(ContainSubstring("Google Cloud"))
+			})
+
+			It("should detect aws eks get-token", func() {
+				plugin := detectCredentialPlugin(execUser("aws", "--region", "us-west-2", "eks", "get-token", "--cluster-name", "prod"))
+				Expect(plugin).NotTo(BeNil())
+				Expect(plugin.Name).To(Equal("AWS EKS"))
+			})
 
-		Context("when context is a Google Cloud context", func() {
-			PIt("should check authentication for GKE context", func() {
-				// This would require mocking checkGCloudAuth
-				Skip("Requires mocking checkGCloudAuth function")
+			It("should detect aws-iam-authenticator by its full path", func() {
+				plugin := detectCredentialPlugin(execUser("/usr/local/bin/aws-iam-authenticator", "token", "-i", "prod"))
+				Expect(plugin).NotTo(BeNil())
+				Expect(plugin.Name).To(Equal("AWS EKS"))
 			})
+
+			It("should detect Azure kubelogin", func() {
+				plugin := detectCredentialPlugin(execUser("kubelogin", "get-token", "--login", "azurecli", "--server-id", "6dae42f8"))
+				Expect(plugin).NotTo(BeNil())
+				Expect(plugin.Name).To(ContainSubstring("Azure"))
+			})
+
+			It("should detect kubectl oidc-login", func() {
+				plugin := detectCredentialPlugin(execUser("kubectl", "oidc-login", "get-token", "--oidc-issuer-url=https://issuer.example.com"))
+				Expect(plugin).NotTo(BeNil())
+				Expect(plugin.Name).To(ContainSubstring("OIDC"))
+			})
+
+			It("should fall back to the generic exec plugin", func() {
+				plugin := detectCredentialPlugin(execUser("my-token-helper"))
+				Expect(plugin).NotTo(BeNil())
+				Expect(plugin.Name).To(Equal("exec credential plugin"))
+			})
+		})
+
+		Context("when the user has a legacy auth-provider", func() {
+			It("should detect the oidc auth-provider", func() {
+				authInfo := clientcmdapi.NewAuthInfo()
+				authInfo.AuthProvider = &clientcmdapi.AuthProviderConfig{Name: "oidc"}
+
+				plugin := detectCredentialPlugin(authInfo)
+				Expect(plugin).NotTo(BeNil())
+				Expect(plugin.Name).To(ContainSubstring("OIDC"))
+			})
+		})
+
+		Context("when the user has static credentials", func() {
+			It("should return nil", func() {
+				authInfo := clientcmdapi.NewAuthInfo()
+				authInfo.Token = "static-token"
+				Expect(detectCredentialPlugin(authInfo)).To(BeNil())
+			})
+		})
+	})
+
+	Describe("login commands", func() {
+		It("should use the AWS profile from the plugin arguments", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{Command: "aws", Args: []string{"eks", "get-token", "--profile", "prod-admin"}}
+			Expect(awsLoginCommand(authInfo)).To(Equal([]string{"aws", "sso", "login", "--profile", "prod-admin"}))
+		})
+
+		It("should use the AWS profile from the plugin environment", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{
+				Command: "aws",
+				Args:    []string{"eks", "get-token"},
+				Env:     []clientcmdapi.ExecEnvVar{{Name: "AWS_PROFILE", Value: "staging"}},
+			}
+			Expect(awsLoginCommand(authInfo)).To(Equal([]string{"aws", "sso", "login", "--profile", "staging"}))
+		})
+
+		It("should use az login for kubelogin in azurecli mode", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{Command: "kubelogin", Args: []string{"get-token", "--login=azurecli"}}
+			Expect(azureLoginCommand(authInfo)).To(Equal([]string{"az", "login"}))
+		})
+
+		It("should re-run kubelogin itself for interactive login modes", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{Command: "kubelogin", Args: []string{"get-token", "-l", "devicecode"}}
+			Expect(azureLoginCommand(authInfo)).To(Equal([]string{"kubelogin", "get-token", "-l", "devicecode"}))
+		})
+	})
+
+	Describe("verifyCredentials function", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "k8s-compare-auth")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		writePlugin := func(script string) string {
+			path := filepath.Join(tempDir, "plugin.sh")
+			Expect(os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755)).To(Succeed())
+			return path
+		}
+
+		It("should succeed when the exec plugin returns a token", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{
+				Command: writePlugin(`echo '{"kind":"ExecCredential","status":{"token":"abc"}}'`),
+			}
+			Expect(verifyCredentials(authInfo)).To(Succeed())
+		})
+
+		It("should fail when the exec plugin returns no token", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{
+				Command: writePlugin(`echo '{"kind":"ExecCredential","status":{}}'`),
+			}
+			Expect(verifyCredentials(authInfo)).To(MatchError(ContainSubstring("did not return a token")))
+		})
+
+		It("should include the plugin's error output when it fails", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{
+				Command: writePlugin(`echo "token has expired" >&2; exit 1`),
+			}
+			Expect(verifyCredentials(authInfo)).To(MatchError(ContainSubstring("token has expired")))
+		})
+
+		It("should pass the configured environment to the plugin", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = &clientcmdapi.ExecConfig{
+				Command: writePlugin(`echo "{\"status\":{\"token\":\"$PLUGIN_TOKEN\"}}"`),
+				Env:     []clientcmdapi.ExecEnvVar{{Name: "PLUGIN_TOKEN", Value: "from-env"}},
+			}
+			Expect(verifyCredentials(authInfo)).To(Succeed())
+		})
+
+		It("should reject legacy cloud auth-providers", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.AuthProvider = &clientcmdapi.AuthProviderConfig{Name: "gcp"}
+			Expect(verifyCredentials(authInfo)).To(MatchError(ContainSubstring("no longer supported")))
+		})
+
+		It("should succeed for static credentials", func() {
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Token = "static-token"
+			Expect(verifyCredentials(authInfo)).To(Succeed())
+		})
+	})
+
+	Describe("ensureClusterAuth function", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "k8s-compare-auth")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should return nil for contexts with static credentials", func() {
+			kubeconfig := writeTestKubeconfig(tempDir, "config.yaml", "minikube", "kind-cluster")
+			Expect(ensureClusterAuth(kubeconfig, "minikube", authSession{out: GinkgoWriter, interactive: true})).To(Succeed())
+			Expect(ensureClusterAuth(kubeconfig, "kind-cluster", authSession{out: GinkgoWriter, interactive: true})).To(Succeed())
+		})
+
+		It("should return an error for an unknown context", func() {
+			kubeconfig := writeTestKubeconfig(tempDir, "config.yaml", "minikube")
+			Expect(ensureClusterAuth(kubeconfig, "docker-desktop", authSession{out: GinkgoWriter, interactive: true})).To(MatchError(ContainSubstring("not found")))
+		})
+
+		It("should accept an exec plugin that produces a token", func() {
+			plugin := filepath.Join(tempDir, "plugin.sh")
+			Expect(os.WriteFile(plugin, []byte("#!/bin/sh\necho '{\"status\":{\"token\":\"abc\"}}'\n"), 0755)).To(Succeed())
+
+			kubeconfig := filepath.Join(tempDir, "config.yaml")
+			content := `apiVersion: v1
+kind: Config
+clusters:
+- name: eks
+  cluster:
+    server: https://eks.example.com
+users:
+- name: eks-user
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: ` + plugin + `
+contexts:
+- name: eks
+  context:
+    cluster: eks
+    user: eks-user
+`
+			Expect(os.WriteFile(kubeconfig, []byte(content), 0600)).To(Succeed())
+
+			Expect(ensureClusterAuth(kubeconfig, "eks", authSession{out: GinkgoWriter, interactive: true})).To(Succeed())
+		})
+
+		It("should run a relative exec plugin from the kubeconfig's directory", func() {
+			Expect(os.Mkdir(filepath.Join(tempDir, "bin"), 0755)).To(Succeed())
+			plugin := filepath.Join(tempDir, "bin", "plugin.sh")
+			Expect(os.WriteFile(plugin, []byte("#!/bin/sh\necho '{\"status\":{\"token\":\"abc\"}}'\n"), 0755)).To(Succeed())
+
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.LocationOfOrigin = filepath.Join(tempDir, "config.yaml")
+			authInfo.Exec = &clientcmdapi.ExecConfig{Command: "./bin/plugin.sh"}
+
+			Expect(verifyCredentials(authInfo)).To(Succeed())
+		})
+
+		It("should not offer a login for the removed gcp auth-provider", func() {
+			kubeconfig := filepath.Join(tempDir, "config.yaml")
+			content := `apiVersion: v1
+kind: Config
+clusters:
+- name: gke
+  cluster:
+    server: https://gke.example.com
+users:
+- name: gke-user
+  user:
+    auth-provider:
+      name: gcp
+contexts:
+- name: gke
+  context:
+    cluster: gke
+    user: gke-user
+`
+			Expect(os.WriteFile(kubeconfig, []byte(content), 0600)).To(Succeed())
+			originalAuth := gcloudAuth
+			gcloudAuth = gcloudAuthOptions{Mode: gcloudAuthServiceAccount, KeyFile: filepath.Join(tempDir, "key.json")}
+			defer func() { gcloudAuth = originalAuth }()
+
+			var out strings.Builder
+			err := ensureClusterAuth(kubeconfig, "gke", authSession{out: &out, interactive: true})
+
+			Expect(err).To(MatchError(ContainSubstring("migrate the kubeconfig")))
+			Expect(out.String()).NotTo(ContainSubstring("Activating service account"))
 		})
 	})
 })
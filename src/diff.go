@@ -0,0 +1,516 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Statuses of a compared resource
+const (
+	statusOnlyInA     = "only-in-A"
+	statusOnlyInB     = "only-in-B"
+	statusDifferent   = "different"
+	statusIdentical   = "identical"
+	statusNotCompared = "not-compared"
+	statusUnknown     = "unknown"
+)
+
+// Types of a field change
+const (
+	changeAdded    = "added"
+	changeRemoved  = "removed"
+	changeModified = "modified"
+)
+
+// ignoredFields are volatile fields that are skipped, at any depth, when comparing resources
+var ignoredFields = []string{"resourceVersion", "uid", "generation", "creationTimestamp", "managedFields"}
+
+// ResourceIdentity identifies a resource across clusters
+type ResourceIdentity struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// String formats the identity as kind namespace/name
+func (id ResourceIdentity) String() string {
+	if id.Namespace == "" {
+		return id.Kind + " " + id.Name
+	}
+	return id.Kind + " " + id.Namespace + "/" + id.Name
+}
+
+// FieldChange is a difference in a single leaf field between the two clusters.
+// ValueA is absent for added fields and ValueB is absent for removed fields.
+type FieldChange struct {
+	Path   string
+	Type   string
+	ValueA interface{}
+	ValueB interface{}
+}
+
+// MarshalJSON omits the value of the side a field is missing from, while keeping
+// falsy values such as false, 0 or "" of the side it is present in
+func (c FieldChange) MarshalJSON() ([]byte, error) {
+	encoded := map[string]interface{}{"path": c.Path, "type": c.Type}
+	if c.Type != changeAdded {
+		encoded["valueA"] = c.ValueA
+	}
+	if c.Type != changeRemoved {
+		encoded["valueB"] = c.ValueB
+	}
+	return json.Marshal(encoded)
+}
+
+// ResourceComparison is the outcome of comparing one resource between the clusters
+type ResourceComparison struct {
+	ResourceIdentity
+	Status string `json:"status"`
+	// Changes lists the differing fields of a resource present in both clusters
+	Changes []FieldChange `json:"changes,omitempty"`
+	// IgnoredChanges lists differences in ignored fields only
+	IgnoredChanges []FieldChange `json:"ignoredChanges,omitempty"`
+	// Skipped is the scope that prevented the comparison for not-compared/unknown resources
+	Skipped *SkippedScope `json:"skipped,omitempty"`
+	// Owned counts the controller-generated resources collapsed into this resource
+	Owned   []OwnedSummary         `json:"owned,omitempty"`
+	ObjectA map[string]interface{} `json:"-"`
+	ObjectB map[string]interface{} `json:"-"`
+}
+
+// KindComparison groups the compared resources of one kind
+type KindComparison struct {
+	Kind      string
+	CountA    int
+	CountB    int
+	Resources []ResourceComparison
+}
+
+// Comparison is the full result of comparing two clusters
+type Comparison struct {
+	TotalA int
+	TotalB int
+	Kinds  []KindComparison
+	// HelmReleases compares the Helm releases decoded from the fetched release records
+	HelmReleases []HelmReleaseComparison
+	// Images compares the image of every workload container
+	Images []ContainerImageComparison
+	// RBAC compares the effective permissions of every subject when AnalyzeRBAC is set
+	RBAC []RBACSubjectComparison
+}
+
+// StatusCounts returns how many resources have each status
+func (k KindComparison) StatusCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, resource := range k.Resources {
+		counts[resource.Status]++
+	}
+	return counts
+}
+
+// StatusCounts returns how many resources have each status across all kinds
+func (c *Comparison) StatusCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, kind := range c.Kinds {
+		for status, count := range kind.StatusCounts() {
+			counts[status] += count
+		}
+	}
+	return counts
+}
+
+// kindSummary holds the number of resources of one kind per cluster and per status
+type kindSummary struct {
+	Kind   string
+	CountA int
+	CountB int
+	Counts map[string]int
+}
+
+// add accumulates the counts of another summary
+func (s *kindSummary) add(other kindSummary) {
+	s.CountA += other.CountA
+	s.CountB += other.CountB
+	for status, count := range other.Counts {
+		s.Counts[status] += count
+	}
+}
+
+// Summaries returns the per-kind resource counts of the comparison
+func (c *Comparison) Summaries() []kindSummary {
+	var summaries []kindSummary
+	for _, kind := range c.Kinds {
+		summaries = append(summaries, kindSummary{Kind: kind.Kind, CountA: kind.CountA, CountB: kind.CountB, Counts: kind.StatusCounts()})
+	}
+	return summaries
+}
+
+// compareClusters compares the fetched resources of both clusters, mirroring the
+// comparison performed by the HTML report: resources are matched by kind, name and,
+// when CompareNamespaces is set, namespace. Unless ExpandOwned is set, resources generated
+// by a fetched controller are counted under their owner rather than compared; the counts
+// per kind still include them.
+func compareClusters(config *ComparisonConfig) *Comparison {
+	groupedA := groupByKind(config.ClusterA.Data)
+	groupedB := groupByKind(config.ClusterB.Data)
+
+	// Helm release records are compared per release instead of as opaque Secrets
+	releasesA, resourcesA := decodeHelmReleases(config.ClusterA.Data)
+	releasesB, resourcesB := decodeHelmReleases(config.ClusterB.Data)
+
+	var ownedA, ownedB map[string][]map[string]interface{}
+	if !config.ExpandOwned {
+		resourcesA, ownedA = collapseOwnedResources(resourcesA)
+		resourcesB, ownedB = collapseOwnedResources(resourcesB)
+	}
+	comparedA, comparedB := groupByKind(resourcesA), groupByKind(resourcesB)
+
+	kinds := make(map[string]bool)
+	for kind := range groupedA {
+		kinds[kind] = true
+	}
+	for kind := range groupedB {
+		kinds[kind] = true
+	}
+
+	comparison := &Comparison{
+		TotalA: len(config.ClusterA.Data),
+		TotalB: len(config.ClusterB.Data),
+	}
+
+	for _, kind := range sortedKeys(kinds) {
+		comparison.Kinds = append(comparison.Kinds, KindComparison{
+			Kind:      kind,
+			CountA:    len(groupedA[kind]),
+			CountB:    len(groupedB[kind]),
+			Resources: withOwnedSummaries(compareResourceLists(comparedA[kind], comparedB[kind], config), ownedA, ownedB),
+		})
+	}
+
+	comparison.HelmReleases = compareHelmReleases(releasesA, releasesB, config.CompareNamespaces)
+	comparison.Images = compareImages(config)
+	if config.AnalyzeRBAC {
+		comparison.RBAC = compareRBAC(config)
+	}
+
+	return comparison
+}
+
+// withOwnedSummaries sets the counts of the resources collapsed into each compared resource
+func withOwnedSummaries(results []ResourceComparison, ownedA, ownedB map[string][]map[string]interface{}) []ResourceComparison {
+	ownedKey := func(object map[string]interface{}) string {
+		if object == nil {
+			return ""
+		}
+		id := resourceIdentity(object)
+		return ownerIndexKey(id.Kind, id.Namespace, id.Name)
+	}
+
+	for i, result := range results {
+		childrenA, childrenB := ownedA[ownedKey(result.ObjectA)], ownedB[ownedKey(result.ObjectB)]
+		if len(childrenA) > 0 || len(childrenB) > 0 {
+			results[i].Owned = summarizeOwned(childrenA, childrenB)
+		}
+	}
+	return results
+}
+
+// OwnedDifferences returns the compared resources whose collapsed controller-generated
+// resources differ in number or readiness between the clusters
+func (c *Comparison) OwnedDifferences() []ResourceComparison {
+	var differing []ResourceComparison
+	for _, kind := range c.Kinds {
+		for _, resource := range kind.Resources {
+			for _, summary := range resource.Owned {
+				if summary.differs() {
+					differing = append(differing, resource)
+					break
+				}
+			}
+		}
+	}
+	return differing
+}
+
+// groupByKind groups resources by their kind
+func groupByKind(resources []map[string]interface{}) map[string][]map[string]interface{} {
+	grouped := make(map[string][]map[string]interface{})
+	for _, resource := range resources {
+		kind, _ := resource["kind"].(string)
+		if kind == "" {
+			kind = "Unknown"
+		}
+		grouped[kind] = append(grouped[kind], resource)
+	}
+	return grouped
+}
+
+// compareResourceLists compares the resources of one kind, sorted by namespace and name
+func compareResourceLists(listA, listB []map[string]interface{}, config *ComparisonConfig) []ResourceComparison {
+	indexA := indexResources(listA, config.CompareNamespaces)
+	indexB := indexResources(listB, config.CompareNamespaces)
+
+	var results []ResourceComparison
+
+	for key, objectA := range indexA {
+		objectB, inB := indexB[key]
+		if !inB {
+			results = append(results, missingResource(objectA, statusOnlyInA, config.ClusterB.Skipped, config.CompareNamespaces))
+			continue
+		}
+
+		results = append(results, compareResourcePair(objectA, objectB))
+	}
+
+	for key, objectB := range indexB {
+		if _, inA := indexA[key]; !inA {
+			results = append(results, missingResource(objectB, statusOnlyInB, config.ClusterA.Skipped, config.CompareNamespaces))
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Namespace != results[j].Namespace {
+			return results[i].Namespace < results[j].Namespace
+		}
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].APIVersion < results[j].APIVersion
+	})
+
+	return results
+}
+
+// compareResourcePair compares a resource present in both clusters
+func compareResourcePair(objectA, objectB map[string]interface{}) ResourceComparison {
+	result := ResourceComparison{
+		ResourceIdentity: resourceIdentity(objectA),
+		Status:           statusIdentical,
+		ObjectA:          objectA,
+		ObjectB:          objectB,
+	}
+	result.Changes, result.IgnoredChanges = findResourceDifferences(objectA, objectB)
+	if len(result.Changes) > 0 {
+		result.Status = statusDifferent
+	}
+	return result
+}
+
+// indexResources keys resources by kind, name and optionally namespace
+func indexResources(resources []map[string]interface{}, useNamespace bool) map[string]map[string]interface{} {
+	index := make(map[string]map[string]interface{}, len(resources))
+	for _, resource := range resources {
+		index[resourceKey(resource, useNamespace)] = resource
+	}
+	return index
+}
+
+// resourceKey builds the key used to match a resource between clusters
+func resourceKey(resource map[string]interface{}, useNamespace bool) string {
+	id := resourceIdentity(resource)
+	if useNamespace {
+		return id.Kind + "/" + id.Namespace + "/" + id.Name
+	}
+	return id.Kind + "/" + id.Name
+}
+
+// resourceIdentity extracts the identity of a resource
+func resourceIdentity(resource map[string]interface{}) ResourceIdentity {
+	id := ResourceIdentity{Kind: "Unknown", Name: "unknown"}
+	if apiVersion, ok := resource["apiVersion"].(string); ok {
+		id.APIVersion = apiVersion
+	}
+	if kind, ok := resource["kind"].(string); ok && kind != "" {
+		id.Kind = kind
+	}
+	if metadata, ok := resource["metadata"].(map[string]interface{}); ok {
+		if name, ok := metadata["name"].(string); ok && name != "" {
+			id.Name = name
+		}
+		if namespace, ok := metadata["namespace"].(string); ok {
+			id.Namespace = namespace
+		}
+	}
+	return id
+}
+
+// missingResource builds the comparison for a resource found in only one cluster. When the
+// other cluster did not fetch the resource's scope, it is not compared (forbidden) or
+// unknown (fetch failed) instead of missing. Without namespace comparison a skipped scope
+// covers the resource whatever namespace it is in, since its counterpart may be in any.
+func missingResource(object map[string]interface{}, missingStatus string, otherSkipped []SkippedScope, compareNamespaces bool) ResourceComparison {
+	result := ResourceComparison{
+		ResourceIdentity: resourceIdentity(object),
+		Status:           missingStatus,
+	}
+	if missingStatus == statusOnlyInA {
+		result.ObjectA = object
+	} else {
+		result.ObjectB = object
+	}
+
+	for i, scope := range otherSkipped {
+		if scope.covers(result.ResourceIdentity, compareNamespaces) {
+			result.Skipped = &otherSkipped[i]
+			if scope.Reason == skipReasonError {
+				result.Status = statusUnknown
+			} else {
+				result.Status = statusNotCompared
+			}
+			break
+		}
+	}
+
+	return result
+}
+
+// covers reports whether a resource belongs to the scope: same API group and kind and,
+// when namespaces are compared, the same namespace
+func (s ResourceScope) covers(id ResourceIdentity, compareNamespaces bool) bool {
+	groupVersion, err := schema.ParseGroupVersion(id.APIVersion)
+	if err != nil || groupVersion.Group != s.Group || id.Kind != s.Kind {
+		return false
+	}
+	return !compareNamespaces || id.Namespace == s.Namespace
+}
+
+// findResourceDifferences returns the differing leaf fields of two resources, separating
+// differences in ignored fields from the ones that matter
+func findResourceDifferences(objectA, objectB map[string]interface{}) ([]FieldChange, []FieldChange) {
+	var changes, ignored []FieldChange
+	collectDifferences(objectA, objectB, "", false, &changes, &ignored)
+	return changes, ignored
+}
+
+// collectDifferences walks both values in parallel and records their differences
+func collectDifferences(valueA, valueB interface{}, path string, isIgnored bool, changes, ignored *[]FieldChange) {
+	switch a := valueA.(type) {
+	case map[string]interface{}:
+		if b, ok := valueB.(map[string]interface{}); ok {
+			keys := make(map[string]bool)
+			for key := range a {
+				keys[key] = true
+			}
+			for key := range b {
+				keys[key] = true
+			}
+
+			for _, key := range sortedKeys(keys) {
+				childPath := joinFieldPath(path, key)
+				childIgnored := isIgnored || contains(ignoredFields, key)
+
+				childA, inA := a[key]
+				childB, inB := b[key]
+				switch {
+				case !inA:
+					recordChange(childPath, changeAdded, nil, childB, childIgnored, changes, ignored)
+				case !inB:
+					recordChange(childPath, changeRemoved, childA, nil, childIgnored, changes, ignored)
+				default:
+					collectDifferences(childA, childB, childPath, childIgnored, changes, ignored)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if b, ok := valueB.([]interface{}); ok {
+			for i := 0; i < len(a) || i < len(b); i++ {
+				childPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(a):
+					recordChange(childPath, changeAdded, nil, b[i], isIgnored, changes, ignored)
+				case i >= len(b):
+					recordChange(childPath, changeRemoved, a[i], nil, isIgnored, changes, ignored)
+				default:
+					collectDifferences(a[i], b[i], childPath, isIgnored, changes, ignored)
+				}
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(valueA, valueB) {
+		recordChange(path, changeModified, valueA, valueB, isIgnored, changes, ignored)
+	}
+}
+
+// recordChange appends a field change to the ignored or the relevant changes
+func recordChange(path, changeType string, valueA, valueB interface{}, isIgnored bool, changes, ignored *[]FieldChange) {
+	change := FieldChange{Path: path, Type: changeType, ValueA: valueA, ValueB: valueB}
+	if isIgnored {
+		*ignored = append(*ignored, change)
+	} else {
+		*changes = append(*changes, change)
+	}
+}
+
+// joinFieldPath appends a map key to a field path, quoting keys that contain dots
+// (e.g. annotation names) so paths stay unambiguous
+func joinFieldPath(path, key string) string {
+	if needsQuoting(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// needsQuoting reports whether a map key cannot be written as a plain path segment
+func needsQuoting(key string) bool {
+	if key == "" {
+		return true
+	}
+	for _, r := range key {
+		if r == '.' || r == '[' || r == ']' || r == '"' || r == ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeObject returns a deep copy of a resource without the ignored fields, i.e.
+// the part of the resource that takes part in the comparison
+func normalizeObject(object map[string]interface{}) map[string]interface{} {
+	normalized, _ := normalizeValue(object).(map[string]interface{})
+	return normalized
+}
+
+// normalizeValue deep-copies a value, dropping ignored fields from every map
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if contains(ignoredFields, key) {
+				continue
+			}
+			normalized[key] = normalizeValue(child)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, child := range v {
+			normalized[i] = normalizeValue(child)
+		}
+		return normalized
+	default:
+		return v
+	}
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testResource builds an unstructured resource as returned by the dynamic client
+func testResource(kind, namespace, name string, spec map[string]interface{}) map[string]interface{} {
+	metadata := map[string]interface{}{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   metadata,
+		"spec":       spec,
+	}
+}
+
+var _ = Describe("Diff", func() {
+	Describe("compareClusters function", func() {
+		var config *ComparisonConfig
+
+		BeforeEach(func() {
+			config = &ComparisonConfig{
+				ClusterA:          ClusterConfig{Context: "context-a"},
+				ClusterB:          ClusterConfig{Context: "context-b"},
+				CompareNamespaces: true,
+			}
+		})
+
+		It("should classify resources by presence and content", func() {
+			config.ClusterA.Data = []map[string]interface{}{
+				testResource("ConfigMap", "default", "same", map[string]interface{}{"a": "1"}),
+				testResource("ConfigMap", "default", "changed", map[string]interface{}{"a": "1"}),
+				testResource("ConfigMap", "default", "only-a", nil),
+			}
+			config.ClusterB.Data = []map[string]interface{}{
+				testResource("ConfigMap", "default", "same", map[string]interface{}{"a": "1"}),
+				testResource("ConfigMap", "default", "changed", map[string]interface{}{"a": "2"}),
+				testResource("Secret", "default", "only-b", nil),
+			}
+
+			comparison := compareClusters(config)
+
+			Expect(comparison.TotalA).To(Equal(3))
+			Expect(comparison.TotalB).To(Equal(3))
+			Expect(comparison.Kinds).To(HaveLen(2))
+			Expect(comparison.Kinds[0].Kind).To(Equal("ConfigMap"))
+			Expect(comparison.Kinds[1].Kind).To(Equal("Secret"))
+
+			configMaps := comparison.Kinds[0].Resources
+			Expect(configMaps).To(HaveLen(3))
+			Expect(configMaps[0].Name).To(Equal("changed"))
+			Expect(configMaps[0].Status).To(Equal(statusDifferent))
+			Expect(configMaps[0].Changes).To(ConsistOf(FieldChange{Path: "spec.a", Type: changeModified, ValueA: "1", ValueB: "2"}))
+			Expect(configMaps[1].Status).To(Equal(statusOnlyInA))
+			Expect(configMaps[2].Status).To(Equal(statusIdentical))
+
+			Expect(comparison.Kinds[1].Resources[0].Status).To(Equal(statusOnlyInB))
+			Expect(comparison.StatusCounts()).To(Equal(map[string]int{
+				statusDifferent: 1, statusOnlyInA: 1, statusOnlyInB: 1, statusIdentical: 1,
+			}))
+		})
+
+		It("should match resources across namespaces when namespaces are not compared", func() {
+			config.CompareNamespaces = false
+			config.ClusterA.Data = []map[string]interface{}{testResource("ConfigMap", "team-a", "app", nil)}
+			config.ClusterB.Data = []map[string]interface{}{testResource("ConfigMap", "team-b", "app", nil)}
+
+			resources := compareClusters(config).Kinds[0].Resources
+
+			Expect(resources).To(HaveLen(1))
+			Expect(resources[0].Status).To(Equal(statusDifferent))
+			Expect(resources[0].Changes[0].Path).To(Equal("metadata.namespace"))
+		})
+
+		It("should mark resources in skipped scopes as not compared or unknown", func() {
+			config.ClusterA.Data = []map[string]interface{}{
+				testResource("Secret", "kube-system", "token", nil),
+				testResource("ConfigMap", "default", "settings", nil),
+			}
+			config.ClusterB.Skipped = []SkippedScope{
+				{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "kube-system"}, Reason: skipReasonForbidden},
+				{ResourceScope: ResourceScope{Resource: "configmaps", Kind: "ConfigMap", Namespace: "default"}, Reason: skipReasonError, Error: "timeout"},
+			}
+
+			comparison := compareClusters(config)
+
+			Expect(comparison.Kinds[0].Resources[0].Status).To(Equal(statusUnknown))
+			Expect(comparison.Kinds[0].Resources[0].Skipped.Error).To(Equal("timeout"))
+			Expect(comparison.Kinds[1].Resources[0].Status).To(Equal(statusNotCompared))
+		})
+
+		It("should only match skipped scopes of the same API group", func() {
+			knative := testResource("Service", "default", "web", nil)
+			knative["apiVersion"] = "serving.knative.dev/v1"
+			config.ClusterA.Data = []map[string]interface{}{knative}
+			config.ClusterB.Skipped = []SkippedScope{
+				{ResourceScope: ResourceScope{Resource: "services", Kind: "Service", Namespace: "default"}, Reason: skipReasonForbidden},
+			}
+
+			comparison := compareClusters(config)
+
+			Expect(comparison.Kinds[0].Resources[0].Status).To(Equal(statusOnlyInA))
+			Expect(comparison.Kinds[0].Resources[0].Skipped).To(BeNil())
+		})
+
+		It("should match skipped scopes in any namespace when namespaces are not compared", func() {
+			config.CompareNamespaces = false
+			config.ClusterA.Data = []map[string]interface{}{testResource("Secret", "staging", "token", nil)}
+			config.ClusterB.Skipped = []SkippedScope{
+				{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "production"}, Reason: skipReasonForbidden},
+			}
+
+			comparison := compareClusters(config)
+
+			Expect(comparison.Kinds[0].Resources[0].Status).To(Equal(statusNotCompared))
+		})
+	})
+
+	Describe("findResourceDifferences function", func() {
+		It("should report nested and list changes with their paths", func() {
+			objectA := map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"image": "nginx:1.25"},
+					},
+					"replicas": int64(2),
+				},
+			}
+			objectB := map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"image": "nginx:1.26"},
+						map[string]interface{}{"image": "sidecar"},
+					},
+					"paused": true,
+				},
+			}
+
+			changes, ignored := findResourceDifferences(objectA, objectB)
+
+			Expect(ignored).To(BeEmpty())
+			Expect(changes).To(Equal([]FieldChange{
+				{Path: "spec.containers[0].image", Type: changeModified, ValueA: "nginx:1.25", ValueB: "nginx:1.26"},
+				{Path: "spec.containers[1]", Type: changeAdded, ValueB: map[string]interface{}{"image": "sidecar"}},
+				{Path: "spec.paused", Type: changeAdded, ValueB: true},
+				{Path: "spec.replicas", Type: changeRemoved, ValueA: int64(2)},
+			}))
+		})
+
+		It("should record changes to ignored fields separately", func() {
+			objectA := map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"resourceVersion": "1",
+					"managedFields":   []interface{}{map[string]interface{}{"manager": "kubectl"}},
+				},
+			}
+			objectB := map[string]interface{}{
+				"metadata": map[string]interface{}{"resourceVersion": "2"},
+			}
+
+			changes, ignored := findResourceDifferences(objectA, objectB)
+
+			Expect(changes).To(BeEmpty())
+			Expect(ignored).To(HaveLen(2))
+			Expect(ignored[0].Path).To(Equal("metadata.managedFields"))
+			Expect(ignored[1].Path).To(Equal("metadata.resourceVersion"))
+		})
+
+		It("should quote keys that contain dots", func() {
+			objectA := map[string]interface{}{"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{"app.kubernetes.io/name": "web"},
+			}}
+			objectB := map[string]interface{}{"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{"app.kubernetes.io/name": "api"},
+			}}
+
+			changes, _ := findResourceDifferences(objectA, objectB)
+
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Path).To(Equal(`metadata.annotations["app.kubernetes.io/name"]`))
+		})
+	})
+
+	Describe("normalizeObject function", func() {
+		It("should drop ignored fields without modifying the original", func() {
+			object := map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":              "web",
+					"uid":               "1234",
+					"creationTimestamp": "2024-01-01T00:00:00Z",
+				},
+				"items": []interface{}{map[string]interface{}{"generation": int64(3), "value": "x"}},
+			}
+
+			normalized := normalizeObject(object)
+
+			Expect(normalized).To(Equal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "web"},
+				"items":    []interface{}{map[string]interface{}{"value": "x"}},
+			}))
+			Expect(object["metadata"]).To(HaveKey("uid"))
+		})
+	})
+
+	Describe("ResourceIdentity String method", func() {
+		It("should include the namespace of namespaced resources", func() {
+			Expect(ResourceIdentity{Kind: "Pod", Namespace: "default", Name: "web"}.String()).To(Equal("Pod default/web"))
+			Expect(ResourceIdentity{Kind: "Node", Name: "node-1"}.String()).To(Equal("Node node-1"))
+		})
+	})
+})
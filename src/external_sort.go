@@ -0,0 +1,189 @@
+package main
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// sortChunkSize is the number of resources sorted in memory at a time
+const sortChunkSize = 5000
+
+// keyedResource is a resource together with the key it is sorted and matched by
+type keyedResource struct {
+	Key    string                 `json:"key"`
+	Object map[string]interface{} `json:"object"`
+}
+
+// resourceIterator yields keyed resources; ok is false once it is exhausted
+type resourceIterator interface {
+	Next() (resource keyedResource, ok bool, err error)
+	Close() error
+}
+
+// sortSnapshot sorts the resources of an NDJSON snapshot by key using an external merge
+// sort: chunks of chunkSize resources are sorted in memory and written to temporary run
+// files, which the returned iterator merges. Memory use is bounded by the chunk size
+// rather than by the size of the snapshot.
+func sortSnapshot(path string, key func(map[string]interface{}) string, chunkSize int) (resourceIterator, error) {
+	tempDir, err := os.MkdirTemp("", "k8s-compare-sort")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	runs, err := writeSortedRuns(path, tempDir, key, chunkSize)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return nil, err
+	}
+
+	merger := &runMerger{tempDir: tempDir}
+	for _, run := range runs {
+		reader, err := openSnapshot(run)
+		if err != nil {
+			merger.Close()
+			return nil, err
+		}
+		merger.readers = append(merger.readers, reader)
+	}
+
+	for i, reader := range merger.readers {
+		if err := merger.push(i, reader); err != nil {
+			merger.Close()
+			return nil, err
+		}
+	}
+
+	return merger, nil
+}
+
+// writeSortedRuns splits a snapshot into sorted run files of at most chunkSize resources
+func writeSortedRuns(path, tempDir string, key func(map[string]interface{}) string, chunkSize int) ([]string, error) {
+	reader, err := openSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var runs []string
+	chunk := make([]keyedResource, 0, chunkSize)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		// A stable sort keeps duplicates in snapshot order, so the last one still wins
+		sort.SliceStable(chunk, func(i, j int) bool { return chunk[i].Key < chunk[j].Key })
+
+		run := filepath.Join(tempDir, fmt.Sprintf("run-%d.ndjson", len(runs)))
+		writer, err := createSnapshot(run)
+		if err != nil {
+			return err
+		}
+		for _, resource := range chunk {
+			if err := writer.Write(resource); err != nil {
+				writer.Close()
+				return err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+
+		runs = append(runs, run)
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for {
+		var object map[string]interface{}
+		if err := reader.Read(&object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		chunk = append(chunk, keyedResource{Key: key(object), Object: object})
+		if len(chunk) == chunkSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// runEntry is the current head of one sorted run
+type runEntry struct {
+	resource keyedResource
+	run      int
+}
+
+// runHeap orders run heads by key, then by run so equal keys keep snapshot order
+type runHeap []runEntry
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if h[i].resource.Key != h[j].resource.Key {
+		return h[i].resource.Key < h[j].resource.Key
+	}
+	return h[i].run < h[j].run
+}
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(runEntry)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// runMerger merges sorted run files into a single sorted iterator
+type runMerger struct {
+	tempDir string
+	readers []*snapshotReader
+	heads   runHeap
+}
+
+// push reads the next resource of a run onto the heap, if any
+func (m *runMerger) push(run int, reader *snapshotReader) error {
+	var resource keyedResource
+	if err := reader.Read(&resource); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("failed to read sorted run: %w", err)
+	}
+	heap.Push(&m.heads, runEntry{resource: resource, run: run})
+	return nil
+}
+
+// Next returns the resource with the smallest key across all runs
+func (m *runMerger) Next() (keyedResource, bool, error) {
+	if m.heads.Len() == 0 {
+		return keyedResource{}, false, nil
+	}
+
+	entry := heap.Pop(&m.heads).(runEntry)
+	if err := m.push(entry.run, m.readers[entry.run]); err != nil {
+		return keyedResource{}, false, err
+	}
+	return entry.resource, true, nil
+}
+
+// Close closes the run files and removes them
+func (m *runMerger) Close() error {
+	for _, reader := range m.readers {
+		reader.Close()
+	}
+	return os.RemoveAll(m.tempDir)
+}
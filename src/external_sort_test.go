@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("External sort", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "k8s-compare-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	nameKey := func(object map[string]interface{}) string {
+		return resourceIdentity(object).Name
+	}
+
+	collect := func(iterator resourceIterator) []string {
+		var keys []string
+		for {
+			resource, ok, err := iterator.Next()
+			Expect(err).NotTo(HaveOccurred())
+			if !ok {
+				return keys
+			}
+			keys = append(keys, resource.Key)
+		}
+	}
+
+	Describe("sortSnapshot function", func() {
+		It("should merge sorted runs larger than one chunk", func() {
+			path := filepath.Join(tempDir, "snapshot.ndjson")
+			var resources []map[string]interface{}
+			for _, i := range []int{7, 3, 9, 1, 5, 8, 2, 6, 4, 0} {
+				resources = append(resources, testResource("ConfigMap", "default", fmt.Sprintf("cm-%d", i), nil))
+			}
+			writeTestSnapshot(path, resources...)
+
+			sorted, err := sortSnapshot(path, nameKey, 3)
+			Expect(err).NotTo(HaveOccurred())
+			defer sorted.Close()
+
+			Expect(collect(sorted)).To(Equal([]string{"cm-0", "cm-1", "cm-2", "cm-3", "cm-4", "cm-5", "cm-6", "cm-7", "cm-8", "cm-9"}))
+		})
+
+		It("should keep duplicates in snapshot order across runs", func() {
+			path := filepath.Join(tempDir, "snapshot.ndjson")
+			writeTestSnapshot(path,
+				testResource("ConfigMap", "team-a", "app", nil),
+				testResource("ConfigMap", "team-b", "other", nil),
+				testResource("ConfigMap", "team-c", "app", nil),
+			)
+
+			sorted, err := sortSnapshot(path, nameKey, 2)
+			Expect(err).NotTo(HaveOccurred())
+			defer sorted.Close()
+
+			first, _, _ := sorted.Next()
+			second, _, _ := sorted.Next()
+			Expect(resourceIdentity(first.Object).Namespace).To(Equal("team-a"))
+			Expect(resourceIdentity(second.Object).Namespace).To(Equal("team-c"))
+		})
+
+		It("should remove its temporary run files on close", func() {
+			path := filepath.Join(tempDir, "snapshot.ndjson")
+			writeTestSnapshot(path, testResource("ConfigMap", "default", "a", nil))
+
+			sorted, err := sortSnapshot(path, nameKey, 1)
+			Expect(err).NotTo(HaveOccurred())
+			runDir := sorted.(*runMerger).tempDir
+			Expect(runDir).To(BeADirectory())
+
+			Expect(sorted.Close()).To(Succeed())
+			Expect(runDir).NotTo(BeADirectory())
+		})
+
+		It("should handle an empty snapshot", func() {
+			path := filepath.Join(tempDir, "snapshot.ndjson")
+			writeTestSnapshot(path)
+
+			sorted, err := sortSnapshot(path, nameKey, 10)
+			Expect(err).NotTo(HaveOccurred())
+			defer sorted.Close()
+
+			Expect(collect(sorted)).To(BeEmpty())
+		})
+	})
+})
@@ -1,34 +1,86 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 )
 
 // fetchResources fetches resources from both clusters
 func fetchResources(config *ComparisonConfig) error {
-	fmt.Println("\n📊 Step 4: Fetching resources...")
+	out := config.progress()
+	fmt.Fprintln(out, "\n📊 Step 4: Fetching resources...")
 
 	var err error
 
-	fmt.Printf("🔍 Fetching resources from Cluster A (%s)...\n", config.ClusterA.Context)
-	config.ClusterA.Data, err = fetchClusterResourcesWithContext(config.ClusterA.Context, config.ClusterA.Namespaces, config.ClusterA.Resources)
+	// Check list permissions up front so forbidden combinations are reported as
+	// "not compared" instead of showing up as resources missing from one cluster
+	runPermissionPreflight(out, &config.ClusterA, "Cluster A")
+	runPermissionPreflight(out, &config.ClusterB, "Cluster B")
+
+	fmt.Fprintf(out, "🔍 Fetching resources from Cluster A (%s)...\n", config.ClusterA.Context)
+	config.ClusterA.Data, config.ClusterA.Skipped, err = fetchClusterResourcesWithContext(out, config.ClusterA.Kubeconfig, config.ClusterA.Context, config.ClusterA.Namespaces, config.ClusterA.Resources, config.ClusterA.Skipped)
 	if err != nil {
-		if isGoogleCloudContext(config.ClusterA.Context) {
-			return fmt.Errorf("failed to fetch from Cluster A - this may be due to authentication or network issues with Google Cloud: %w", err)
-		}
-		return fmt.Errorf("failed to fetch resources from Cluster A: %w", err)
+		return fetchError(&config.ClusterA, "Cluster A", err)
 	}
-	fmt.Printf("✅ Cluster A: Found %d resources\n", len(config.ClusterA.Data))
+	fmt.Fprintf(out, "✅ Cluster A: Found %d resources\n", len(config.ClusterA.Data))
 
-	fmt.Printf("🔍 Fetching resources from Cluster B (%s)...\n", config.ClusterB.Context)
-	config.ClusterB.Data, err = fetchClusterResourcesWithContext(config.ClusterB.Context, config.ClusterB.Namespaces, config.ClusterB.Resources)
+	fmt.Fprintf(out, "🔍 Fetching resources from Cluster B (%s)...\n", config.ClusterB.Context)
+	config.ClusterB.Data, config.ClusterB.Skipped, err = fetchClusterResourcesWithContext(out, config.ClusterB.Kubeconfig, config.ClusterB.Context, config.ClusterB.Namespaces, config.ClusterB.Resources, config.ClusterB.Skipped)
 	if err != nil {
-		if isGoogleCloudContext(config.ClusterB.Context) {
-			return fmt.Errorf("failed to fetch from Cluster B - this may be due to authentication or network issues with Google Cloud: %w", err)
+		return fetchError(&config.ClusterB, "Cluster B", err)
+	}
+	fmt.Fprintf(out, "✅ Cluster B: Found %d resources\n", len(config.ClusterB.Data))
+
+	return nil
+}
+
+// fetchResourcesToSnapshots fetches resources from both clusters straight into NDJSON
+// snapshot files, without keeping them in memory
+func fetchResourcesToSnapshots(config *ComparisonConfig, pathA, pathB string) error {
+	out := config.progress()
+	fmt.Fprintln(out, "\n📊 Step 4: Fetching resources...")
+
+	runPermissionPreflight(out, &config.ClusterA, "Cluster A")
+	runPermissionPreflight(out, &config.ClusterB, "Cluster B")
+
+	for _, target := range []struct {
+		cluster *ClusterConfig
+		name    string
+		path    string
+	}{
+		{&config.ClusterA, "Cluster A", pathA},
+		{&config.ClusterB, "Cluster B", pathB},
+	} {
+		fmt.Fprintf(out, "🔍 Streaming resources from %s (%s) to %s...\n", target.name, target.cluster.Context, target.path)
+		if err := fetchClusterToSnapshot(out, target.cluster, target.path); err != nil {
+			return fetchError(target.cluster, target.name, err)
 		}
-		return fmt.Errorf("failed to fetch resources from Cluster B: %w", err)
 	}
-	fmt.Printf("✅ Cluster B: Found %d resources\n", len(config.ClusterB.Data))
 
 	return nil
 }
+
+// fetchClusterToSnapshot writes every resource of a cluster to a snapshot as it arrives
+func fetchClusterToSnapshot(out io.Writer, cluster *ClusterConfig, path string) error {
+	writer, err := createSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	sink, err := newSnapshotSink(writer, path)
+	if err != nil {
+		return errors.Join(err, writer.Close())
+	}
+
+	cluster.Skipped, err = streamClusterResources(out, cluster.Kubeconfig, cluster.Context, cluster.Namespaces, cluster.Resources, cluster.Skipped, sink)
+	return errors.Join(err, sink.Close(), writer.Close())
+}
+
+// fetchError wraps a fetch failure, hinting at Google Cloud authentication for GKE contexts
+func fetchError(cluster *ClusterConfig, clusterName string, err error) error {
+	if isGoogleCloudContext(cluster.Kubeconfig, cluster.Context) {
+		return fmt.Errorf("failed to fetch from %s - this may be due to authentication or network issues with Google Cloud: %w", clusterName, err)
+	}
+	return fmt.Errorf("failed to fetch resources from %s: %w", clusterName, err)
+}
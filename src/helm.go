@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// helmReleasePrefix starts the name of the Secrets (or ConfigMaps) Helm 3 stores releases in,
+// followed by <release>.v<revision>
+const helmReleasePrefix = "sh.helm.release.v1."
+
+// helmReleaseSecretType is the type of the Secrets Helm stores releases in
+const helmReleaseSecretType = "helm.sh/release.v1"
+
+// gzipMagic starts gzip-compressed release payloads
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// HelmRelease is the part of a decoded Helm release that is compared
+type HelmRelease struct {
+	Chart        string                 `json:"chart"`
+	ChartVersion string                 `json:"chartVersion"`
+	AppVersion   string                 `json:"appVersion,omitempty"`
+	Revision     int                    `json:"revision"`
+	Status       string                 `json:"status"`
+	Values       map[string]interface{} `json:"-"`
+}
+
+// HelmReleaseComparison compares the latest revision of a release in both clusters
+type HelmReleaseComparison struct {
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace"`
+	Status    string       `json:"status"`
+	ClusterA  *HelmRelease `json:"clusterA,omitempty"`
+	ClusterB  *HelmRelease `json:"clusterB,omitempty"`
+	// RevisionChange records differing revision numbers. It does not make the release
+	// differ, since each cluster numbers its revisions independently.
+	RevisionChange *FieldChange `json:"revisionChange,omitempty"`
+	// Changes lists the differing chart, app version and status fields
+	Changes []FieldChange `json:"changes,omitempty"`
+	// ValueChanges lists the differences in the user-supplied values, at paths starting with "values"
+	ValueChanges []FieldChange `json:"valueChanges,omitempty"`
+}
+
+// helmReleaseRecord is the subset of Helm's release record that is decoded
+type helmReleaseRecord struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// isHelmReleaseObject reports whether a resource is a Helm 3 release record, stored
+// either in a Secret (the default driver) or in a ConfigMap
+func isHelmReleaseObject(resource map[string]interface{}) bool {
+	id := resourceIdentity(resource)
+	if !strings.HasPrefix(id.Name, helmReleasePrefix) {
+		return false
+	}
+	switch id.Kind {
+	case "Secret":
+		secretType, _ := resource["type"].(string)
+		return secretType == helmReleaseSecretType
+	case "ConfigMap":
+		metadata, _ := resource["metadata"].(map[string]interface{})
+		labels, _ := metadata["labels"].(map[string]interface{})
+		return labels["owner"] == "helm"
+	}
+	return false
+}
+
+// decodeHelmRelease decodes the release stored in a Helm release Secret or ConfigMap.
+// Helm stores the release as base64-encoded, usually gzip-compressed JSON; Secret data is
+// base64-encoded once more by the API.
+func decodeHelmRelease(resource map[string]interface{}) (helmReleaseRecord, error) {
+	var record helmReleaseRecord
+
+	data, _ := resource["data"].(map[string]interface{})
+	encoded, ok := data["release"].(string)
+	if !ok {
+		return record, fmt.Errorf("no release data")
+	}
+
+	if resourceIdentity(resource).Kind == "Secret" {
+		payload, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return record, fmt.Errorf("invalid secret data: %w", err)
+		}
+		encoded = string(payload)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return record, fmt.Errorf("invalid release encoding: %w", err)
+	}
+
+	if bytes.HasPrefix(payload, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return record, fmt.Errorf("invalid release compression: %w", err)
+		}
+		if payload, err = io.ReadAll(reader); err != nil {
+			return record, fmt.Errorf("invalid release compression: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return record, fmt.Errorf("invalid release record: %w", err)
+	}
+	return record, nil
+}
+
+// decodeHelmReleases decodes the release records among the resources and returns them
+// along with the other resources. Records that cannot be decoded are returned with the
+// other resources, so they still take part in the resource comparison as Secrets.
+func decodeHelmReleases(resources []map[string]interface{}) ([]helmReleaseRecord, []map[string]interface{}) {
+	var records []helmReleaseRecord
+	others := make([]map[string]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		record, ok := decodedHelmRelease(resource)
+		if !ok {
+			others = append(others, resource)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, others
+}
+
+// decodedHelmRelease decodes a resource that is a Helm release record. Records that
+// cannot be decoded are reported as not being one, so they are compared as resources.
+func decodedHelmRelease(resource map[string]interface{}) (helmReleaseRecord, bool) {
+	if !isHelmReleaseObject(resource) {
+		return helmReleaseRecord{}, false
+	}
+	record, err := decodeHelmRelease(resource)
+	if err != nil || record.Name == "" {
+		return helmReleaseRecord{}, false
+	}
+	if record.Namespace == "" {
+		record.Namespace = resourceIdentity(resource).Namespace
+	}
+	return record, true
+}
+
+// latestHelmReleases keeps the latest revision of each release, keyed like resources
+func latestHelmReleases(records []helmReleaseRecord, useNamespace bool) map[string]helmReleaseRecord {
+	latest := make(map[string]helmReleaseRecord)
+	for _, record := range records {
+		key := record.Name
+		if useNamespace {
+			key = record.Namespace + "/" + record.Name
+		}
+		if current, found := latest[key]; !found || record.Version > current.Version {
+			latest[key] = record
+		}
+	}
+	return latest
+}
+
+// compareHelmReleases compares the latest revision of every Helm release decoded from
+// the fetched Secrets and ConfigMaps of both clusters, matched by name and, when
+// useNamespace is set, namespace
+func compareHelmReleases(recordsA, recordsB []helmReleaseRecord, useNamespace bool) []HelmReleaseComparison {
+	releasesA := latestHelmReleases(recordsA, useNamespace)
+	releasesB := latestHelmReleases(recordsB, useNamespace)
+
+	keys := make(map[string]bool)
+	for key := range releasesA {
+		keys[key] = true
+	}
+	for key := range releasesB {
+		keys[key] = true
+	}
+
+	var comparisons []HelmReleaseComparison
+	for _, key := range sortedKeys(keys) {
+		recordA, inA := releasesA[key]
+		recordB, inB := releasesB[key]
+
+		var comparison HelmReleaseComparison
+		switch {
+		case !inB:
+			comparison = HelmReleaseComparison{Name: recordA.Name, Namespace: recordA.Namespace, Status: statusOnlyInA, ClusterA: newHelmRelease(recordA)}
+		case !inA:
+			comparison = HelmReleaseComparison{Name: recordB.Name, Namespace: recordB.Namespace, Status: statusOnlyInB, ClusterB: newHelmRelease(recordB)}
+		default:
+			comparison = compareHelmReleasePair(newHelmRelease(recordA), newHelmRelease(recordB))
+			comparison.Name, comparison.Namespace = recordA.Name, recordA.Namespace
+		}
+		comparisons = append(comparisons, comparison)
+	}
+
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		if comparisons[i].Namespace != comparisons[j].Namespace {
+			return comparisons[i].Namespace < comparisons[j].Namespace
+		}
+		return comparisons[i].Name < comparisons[j].Name
+	})
+	return comparisons
+}
+
+// newHelmRelease extracts the compared fields of a release record
+func newHelmRelease(record helmReleaseRecord) *HelmRelease {
+	values := record.Config
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return &HelmRelease{
+		Chart:        record.Chart.Metadata.Name,
+		ChartVersion: record.Chart.Metadata.Version,
+		AppVersion:   record.Chart.Metadata.AppVersion,
+		Revision:     record.Version,
+		Status:       record.Info.Status,
+		Values:       values,
+	}
+}
+
+// compareHelmReleasePair compares a release present in both clusters
+func compareHelmReleasePair(releaseA, releaseB *HelmRelease) HelmReleaseComparison {
+	comparison := HelmReleaseComparison{Status: statusIdentical, ClusterA: releaseA, ClusterB: releaseB}
+
+	if releaseA.Revision != releaseB.Revision {
+		comparison.RevisionChange = &FieldChange{Path: "revision", Type: changeModified, ValueA: releaseA.Revision, ValueB: releaseB.Revision}
+	}
+
+	for _, field := range []struct {
+		path   string
+		valueA string
+		valueB string
+	}{
+		{"chart", releaseA.Chart, releaseB.Chart},
+		{"chartVersion", releaseA.ChartVersion, releaseB.ChartVersion},
+		{"appVersion", releaseA.AppVersion, releaseB.AppVersion},
+		{"status", releaseA.Status, releaseB.Status},
+	} {
+		if field.valueA != field.valueB {
+			comparison.Changes = append(comparison.Changes, FieldChange{Path: field.path, Type: changeModified, ValueA: field.valueA, ValueB: field.valueB})
+		}
+	}
+
+	// Values are user data, so keys named like ignored resource fields are compared too:
+	// both lists collect into the same slice
+	if !reflect.DeepEqual(releaseA.Values, releaseB.Values) {
+		collectDifferences(releaseA.Values, releaseB.Values, "values", false, &comparison.ValueChanges, &comparison.ValueChanges)
+	}
+
+	if len(comparison.Changes) > 0 || len(comparison.ValueChanges) > 0 {
+		comparison.Status = statusDifferent
+	}
+	return comparison
+}
+
+// String describes a release, e.g. "nginx 1.2.3 (app 2.0), revision 4, deployed"
+func (r *HelmRelease) String() string {
+	if r == nil {
+		return "-"
+	}
+	description := r.Chart + " " + r.ChartVersion
+	if r.AppVersion != "" {
+		description += " (app " + r.AppVersion + ")"
+	}
+	return fmt.Sprintf("%s, revision %d, %s", description, r.Revision, r.Status)
+}
+
+// ChangeLines describes the differences of a release, one per line, starting with the
+// revision drift
+func (c HelmReleaseComparison) ChangeLines() []string {
+	var lines []string
+	if c.RevisionChange != nil {
+		lines = append(lines, formatFieldChanges([]FieldChange{*c.RevisionChange})+" (not counted as a difference)")
+	}
+	changes := append(append([]FieldChange{}, c.Changes...), c.ValueChanges...)
+	if len(changes) > 0 {
+		lines = append(lines, strings.Split(formatFieldChanges(changes), "\n")...)
+	}
+	return lines
+}
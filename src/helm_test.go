@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// helmReleaseRecordJSON returns a Helm release record as stored by Helm
+func helmReleaseRecordJSON(name, namespace string, revision int, chartVersion, status string, values map[string]interface{}) []byte {
+	record, err := json.Marshal(map[string]interface{}{
+		"name":      name,
+		"namespace": namespace,
+		"version":   revision,
+		"info":      map[string]interface{}{"status": status},
+		"chart": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "nginx", "version": chartVersion, "appVersion": "1.25"},
+		},
+		"config":   values,
+		"manifest": "---\nkind: Deployment\n",
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return record
+}
+
+// helmReleaseSecret returns the Secret Helm stores a gzip-compressed release in
+func helmReleaseSecret(name, namespace string, revision int, chartVersion, status string, values map[string]interface{}) map[string]interface{} {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write(helmReleaseRecordJSON(name, namespace, revision, chartVersion, status, values))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(writer.Close()).To(Succeed())
+
+	helmEncoded := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	secret := testResource("Secret", namespace, fmt.Sprintf("%s%s.v%d", helmReleasePrefix, name, revision), nil)
+	delete(secret, "spec")
+	secret["type"] = helmReleaseSecretType
+	secret["data"] = map[string]interface{}{"release": base64.StdEncoding.EncodeToString([]byte(helmEncoded))}
+	return secret
+}
+
+// compareTestHelmReleases decodes and compares the Helm releases of both clusters
+func compareTestHelmReleases(config *ComparisonConfig) []HelmReleaseComparison {
+	recordsA, _ := decodeHelmReleases(config.ClusterA.Data)
+	recordsB, _ := decodeHelmReleases(config.ClusterB.Data)
+	return compareHelmReleases(recordsA, recordsB, config.CompareNamespaces)
+}
+
+var _ = Describe("Helm", func() {
+	Describe("decodeHelmRelease function", func() {
+		It("should decode a gzip-compressed release Secret", func() {
+			secret := helmReleaseSecret("web", "default", 3, "1.2.0", "deployed", map[string]interface{}{"replicas": float64(2)})
+
+			Expect(isHelmReleaseObject(secret)).To(BeTrue())
+			record, err := decodeHelmRelease(secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record.Name).To(Equal("web"))
+			Expect(record.Version).To(Equal(3))
+			Expect(record.Info.Status).To(Equal("deployed"))
+			Expect(record.Chart.Metadata.Version).To(Equal("1.2.0"))
+			Expect(record.Config).To(Equal(map[string]interface{}{"replicas": float64(2)}))
+		})
+
+		It("should decode an uncompressed release ConfigMap", func() {
+			configMap := testResource("ConfigMap", "default", helmReleasePrefix+"web.v1", nil)
+			configMap["metadata"].(map[string]interface{})["labels"] = map[string]interface{}{"owner": "helm"}
+			configMap["data"] = map[string]interface{}{
+				"release": base64.StdEncoding.EncodeToString(helmReleaseRecordJSON("web", "default", 1, "1.0.0", "deployed", nil)),
+			}
+
+			Expect(isHelmReleaseObject(configMap)).To(BeTrue())
+			record, err := decodeHelmRelease(configMap)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record.Chart.Metadata.Version).To(Equal("1.0.0"))
+		})
+
+		It("should not treat other Secrets as releases", func() {
+			secret := testResource("Secret", "default", helmReleasePrefix+"web.v1", nil)
+			secret["type"] = "Opaque"
+			Expect(isHelmReleaseObject(secret)).To(BeFalse())
+		})
+
+		It("should report invalid release data", func() {
+			secret := testResource("Secret", "default", helmReleasePrefix+"web.v1", nil)
+			secret["data"] = map[string]interface{}{"release": "not base64!"}
+
+			_, err := decodeHelmRelease(secret)
+			Expect(err).To(MatchError(ContainSubstring("invalid secret data")))
+		})
+	})
+
+	Describe("compareHelmReleases function", func() {
+		var config *ComparisonConfig
+
+		BeforeEach(func() {
+			config = &ComparisonConfig{
+				ClusterA: ClusterConfig{Data: []map[string]interface{}{
+					helmReleaseSecret("web", "default", 1, "1.0.0", "superseded", map[string]interface{}{"replicas": float64(1)}),
+					helmReleaseSecret("web", "default", 2, "1.2.0", "deployed", map[string]interface{}{
+						"replicas": float64(3),
+						"image":    map[string]interface{}{"tag": "v2"},
+						"uid":      "a",
+					}),
+					helmReleaseSecret("api", "default", 5, "2.0.0", "deployed", nil),
+					helmReleaseSecret("cache", "default", 1, "0.1.0", "deployed", nil),
+				}},
+				ClusterB: ClusterConfig{Data: []map[string]interface{}{
+					helmReleaseSecret("web", "default", 7, "1.1.0", "failed", map[string]interface{}{
+						"replicas": float64(3),
+						"image":    map[string]interface{}{"tag": "v1"},
+						"uid":      "b",
+					}),
+					helmReleaseSecret("api", "default", 1, "2.0.0", "deployed", nil),
+					helmReleaseSecret("worker", "default", 1, "0.1.0", "deployed", nil),
+				}},
+				CompareNamespaces: true,
+			}
+		})
+
+		It("should compare the latest revision of each release", func() {
+			releases := compareTestHelmReleases(config)
+
+			Expect(releases).To(HaveLen(4))
+			Expect(releases[0].Name).To(Equal("api"))
+			Expect(releases[0].Status).To(Equal(statusIdentical))
+			Expect(releases[0].RevisionChange).To(Equal(&FieldChange{Path: "revision", Type: changeModified, ValueA: 5, ValueB: 1}))
+			Expect(releases[0].ChangeLines()).To(Equal([]string{"revision: 5 → 1 (not counted as a difference)"}))
+			Expect(releases[1].Name).To(Equal("cache"))
+			Expect(releases[1].Status).To(Equal(statusOnlyInA))
+			Expect(releases[3].Name).To(Equal("worker"))
+			Expect(releases[3].Status).To(Equal(statusOnlyInB))
+
+			web := releases[2]
+			Expect(web.Status).To(Equal(statusDifferent))
+			Expect(web.ClusterA.Revision).To(Equal(2))
+			Expect(web.ClusterB.Revision).To(Equal(7))
+			Expect(web.Changes).To(Equal([]FieldChange{
+				{Path: "chartVersion", Type: changeModified, ValueA: "1.2.0", ValueB: "1.1.0"},
+				{Path: "status", Type: changeModified, ValueA: "deployed", ValueB: "failed"},
+			}))
+			Expect(web.ValueChanges).To(Equal([]FieldChange{
+				{Path: "values.image.tag", Type: changeModified, ValueA: "v2", ValueB: "v1"},
+				{Path: "values.uid", Type: changeModified, ValueA: "a", ValueB: "b"},
+			}))
+		})
+
+		It("should be part of the comparison and its reports", func() {
+			comparison := compareClusters(config)
+			Expect(comparison.HelmReleases).To(HaveLen(4))
+
+			report := buildDiffReport(config, comparison, time.Now())
+			Expect(report.HelmReleases).To(Equal(comparison.HelmReleases))
+
+			var text strings.Builder
+			Expect(renderTextReport(&text, config, comparison, false)).To(Succeed())
+			Expect(text.String()).To(ContainSubstring("⎈ Helm Releases (4)"))
+			Expect(text.String()).To(ContainSubstring("nginx 1.2.0 (app 1.25), revision 2, deployed"))
+			Expect(text.String()).To(ContainSubstring(`   values.image.tag: "v2" → "v1"`))
+
+			markdown, err := renderMarkdownReport(config, comparison)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(markdown).To(ContainSubstring("| default/web | different |"))
+			Expect(markdown).NotTo(ContainSubstring("| default/api |"))
+			Expect(markdown).To(ContainSubstring("Releases identical in both clusters: 1"))
+
+			html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(html).To(ContainSubstring(`onclick="showTab('helm')"`))
+			Expect(html).To(ContainSubstring(`<span class="status-badge status-only-b">Only in B</span>`))
+			Expect(html).To(ContainSubstring(`<li><code>values.image.tag: &#34;v2&#34; → &#34;v1&#34;</code></li>`))
+		})
+
+		It("should leave decoded release Secrets out of the resource comparison", func() {
+			broken := testResource("Secret", "default", helmReleasePrefix+"broken.v1", nil)
+			broken["type"] = helmReleaseSecretType
+			broken["data"] = map[string]interface{}{"release": "not base64"}
+			config.ClusterA.Data = append(config.ClusterA.Data, broken, testResource("Secret", "default", "token", nil))
+
+			comparison := compareClusters(config)
+
+			Expect(comparison.Kinds).To(HaveLen(1))
+			Expect(comparison.Kinds[0].CountA).To(Equal(6))
+			Expect(comparison.Kinds[0].CountB).To(Equal(3))
+			var names []string
+			for _, resource := range comparison.Kinds[0].Resources {
+				names = append(names, resource.Name)
+			}
+			Expect(names).To(ConsistOf(helmReleasePrefix+"broken.v1", "token"))
+
+			html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			for _, result := range decodeEmbeddedResults(html) {
+				Expect(result["name"]).To(BeElementOf(helmReleasePrefix+"broken.v1", "token"))
+			}
+		})
+
+		It("should match releases by name alone when namespaces are not compared", func() {
+			config.CompareNamespaces = false
+			config.ClusterA.Data = []map[string]interface{}{helmReleaseSecret("web", "staging", 1, "1.0.0", "deployed", nil)}
+			config.ClusterB.Data = []map[string]interface{}{helmReleaseSecret("web", "production", 1, "1.0.0", "deployed", nil)}
+
+			releases := compareTestHelmReleases(config)
+			Expect(releases).To(HaveLen(1))
+			Expect(releases[0].Status).To(Equal(statusIdentical))
+		})
+
+		It("should leave the Helm tab out when there are no releases", func() {
+			html, err := generateHTMLTemplate(&ComparisonConfig{}, compareClusters(&ComparisonConfig{}), "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(html).NotTo(ContainSubstring("showTab('helm')"))
+		})
+	})
+
+	Describe("HelmRelease String method", func() {
+		It("should describe a missing release as a dash", func() {
+			var release *HelmRelease
+			Expect(release.String()).To(Equal("-"))
+		})
+	})
+})
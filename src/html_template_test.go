@@ -1,12 +1,39 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
+// renderHTMLReport renders the HTML report for tests
+func renderHTMLReport(config *ComparisonConfig, timestamp string) string {
+	template, err := generateHTMLTemplate(config, compareClusters(config), timestamp)
+	Expect(err).NotTo(HaveOccurred())
+	return template
+}
+
+// decodeEmbeddedResults decodes the compressed comparison results a report embeds
+func decodeEmbeddedResults(template string) []map[string]interface{} {
+	match := regexp.MustCompile(`const encodedResults = "([A-Za-z0-9+/=]*)";`).FindStringSubmatch(template)
+	Expect(match).To(HaveLen(2))
+
+	compressed, err := base64.StdEncoding.DecodeString(match[1])
+	Expect(err).NotTo(HaveOccurred())
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	Expect(err).NotTo(HaveOccurred())
+
+	var results []map[string]interface{}
+	Expect(json.NewDecoder(reader).Decode(&results)).To(Succeed())
+	return results
+}
+
 var _ = Describe("HTML Template", func() {
 	Describe("generateHTMLTemplate function", func() {
 		Context("when generating HTML templates", func() {
@@ -16,7 +43,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Check basic HTML structure
 				Expect(template).To(ContainSubstring("<!DOCTYPE html>"))
@@ -34,7 +61,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Check for essential meta tags
 				Expect(template).To(ContainSubstring(`<meta charset="UTF-8"`))
@@ -48,7 +75,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Check for CSS
 				Expect(template).To(ContainSubstring("<style>"))
@@ -64,7 +91,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Check for JavaScript
 				Expect(template).To(ContainSubstring("<script>"))
@@ -72,26 +99,61 @@ var _ = Describe("HTML Template", func() {
 				Expect(template).To(ContainSubstring("function"))
 			})
 
-			It("should embed cluster data correctly", func() {
+			It("should embed the comparison results correctly", func() {
 				config := &ComparisonConfig{
-					ClusterA: ClusterConfig{Context: "production-cluster"},
-					ClusterB: ClusterConfig{Context: "staging-cluster"},
+					ClusterA: ClusterConfig{
+						Context: "production-cluster",
+						Data:    []map[string]interface{}{testResource("Pod", "default", "pod-1", nil)},
+					},
+					ClusterB: ClusterConfig{
+						Context: "staging-cluster",
+						Data:    []map[string]interface{}{testResource("Pod", "default", "pod-2", nil)},
+					},
 				}
 
-				clusterAData := `[{"name":"pod-1","status":"Running"}]`
-				clusterBData := `[{"name":"pod-2","status":"Pending"}]`
-
-				template := generateHTMLTemplate(config, clusterAData, clusterBData, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Check that cluster contexts are included
 				Expect(template).To(ContainSubstring("production-cluster"))
 				Expect(template).To(ContainSubstring("staging-cluster"))
 
-				// Check that data is embedded
-				Expect(template).To(ContainSubstring("pod-1"))
-				Expect(template).To(ContainSubstring("pod-2"))
-				Expect(template).To(ContainSubstring("Running"))
-				Expect(template).To(ContainSubstring("Pending"))
+				// Check that results are embedded compressed rather than as plain JSON
+				Expect(template).NotTo(ContainSubstring("pod-1"))
+				results := decodeEmbeddedResults(template)
+				Expect(results).To(HaveLen(2))
+				Expect(results[0]).To(HaveKeyWithValue("status", statusOnlyInA))
+				Expect(results[0]).To(HaveKeyWithValue("objectA", HaveKeyWithValue("metadata", HaveKeyWithValue("name", "pod-1"))))
+				Expect(results[0]).NotTo(HaveKey("objectB"))
+				Expect(results[1]).To(HaveKeyWithValue("status", statusOnlyInB))
+				Expect(results[1]).To(HaveKeyWithValue("objectB", HaveKeyWithValue("metadata", HaveKeyWithValue("name", "pod-2"))))
+			})
+
+			It("should embed the changes and normalized objects of differing resources only", func() {
+				changed := testResource("ConfigMap", "default", "changed", map[string]interface{}{"a": "1"})
+				changed["metadata"].(map[string]interface{})["resourceVersion"] = "7"
+				config := &ComparisonConfig{
+					ClusterA: ClusterConfig{Data: []map[string]interface{}{
+						changed,
+						testResource("ConfigMap", "default", "same", nil),
+					}},
+					ClusterB: ClusterConfig{Data: []map[string]interface{}{
+						testResource("ConfigMap", "default", "changed", map[string]interface{}{"a": "2"}),
+						testResource("ConfigMap", "default", "same", nil),
+					}},
+					CompareNamespaces: true,
+				}
+
+				results := decodeEmbeddedResults(renderHTMLReport(config, "2023-01-01_12-00-00"))
+
+				Expect(results).To(HaveLen(2))
+				Expect(results[0]["changes"]).To(ConsistOf(map[string]interface{}{
+					"path": "spec.a", "type": changeModified, "valueA": "1", "valueB": "2",
+				}))
+				Expect(results[0]).NotTo(HaveKey("ignoredChanges"))
+				Expect(results[0]["objectA"]).To(HaveKeyWithValue("metadata", map[string]interface{}{"name": "changed", "namespace": "default"}))
+				Expect(results[1]).To(HaveKeyWithValue("status", statusIdentical))
+				Expect(results[1]).NotTo(HaveKey("objectA"))
+				Expect(results[1]).NotTo(HaveKey("objectB"))
 			})
 
 			It("should include timestamp in the template", func() {
@@ -101,7 +163,7 @@ var _ = Describe("HTML Template", func() {
 				}
 
 				timestamp := "2023-12-25_14-30-45"
-				template := generateHTMLTemplate(config, `[]`, `[]`, timestamp)
+				template := renderHTMLReport(config, timestamp)
 
 				// Check that timestamp is included
 				Expect(template).To(ContainSubstring(timestamp))
@@ -113,7 +175,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "empty-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Should still generate valid HTML
 				Expect(template).To(ContainSubstring("<!DOCTYPE html>"))
@@ -127,7 +189,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "cluster.with.dots"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Should handle special characters properly
 				Expect(template).To(ContainSubstring("cluster-with-dashes_and_underscores"))
@@ -140,7 +202,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Check for tab navigation
 				Expect(template).To(ContainSubstring("Overview"))
@@ -148,13 +210,30 @@ var _ = Describe("HTML Template", func() {
 				Expect(template).To(ContainSubstring("Detailed Comparison"))
 			})
 
+			It("should include filter controls", func() {
+				config := &ComparisonConfig{
+					ClusterA: ClusterConfig{Context: "test-a"},
+					ClusterB: ClusterConfig{Context: "test-b"},
+				}
+
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
+
+				for _, id := range []string{"filter-kind", "filter-namespace", "filter-name", "filter-regex", "filter-field", "filter-summary"} {
+					Expect(template).To(ContainSubstring(`id="` + id + `"`))
+				}
+				for _, status := range []string{"only-a", "only-b", "different"} {
+					Expect(template).To(ContainSubstring(`class="filter-status" value="` + status + `"`))
+				}
+				Expect(template).To(ContainSubstring("readFiltersFromHash"))
+			})
+
 			It("should include interactive features", func() {
 				config := &ComparisonConfig{
 					ClusterA: ClusterConfig{Context: "test-a"},
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Check for interactive elements
 				Expect(template).To(ContainSubstring("onclick"))
@@ -162,65 +241,178 @@ var _ = Describe("HTML Template", func() {
 			})
 
 			It("should handle complex JSON data", func() {
+				complexData := []map[string]interface{}{{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": map[string]interface{}{
+						"name":      "test-pod",
+						"namespace": "default",
+						"labels":    map[string]interface{}{"app": "test"},
+					},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{"name": "test", "image": "nginx"}},
+					},
+				}}
 				config := &ComparisonConfig{
-					ClusterA: ClusterConfig{Context: "complex-a"},
+					ClusterA: ClusterConfig{Context: "complex-a", Data: complexData},
 					ClusterB: ClusterConfig{Context: "complex-b"},
 				}
 
-				complexData := `[{
-					"apiVersion": "v1",
-					"kind": "Pod",
-					"metadata": {
-						"name": "test-pod",
-						"namespace": "default",
-						"labels": {"app": "test"}
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
+
+				// Should handle complex nested JSON
+				results := decodeEmbeddedResults(template)
+				Expect(results).To(HaveLen(1))
+				resource := results[0]["objectA"].(map[string]interface{})
+				Expect(resource["apiVersion"]).To(Equal("v1"))
+				Expect(resource["metadata"]).To(HaveKeyWithValue("name", "test-pod"))
+				Expect(resource["spec"]).To(HaveKeyWithValue("containers", ConsistOf(HaveKeyWithValue("image", "nginx"))))
+			})
+		})
+
+		Context("when some scopes could not be fetched", func() {
+			It("should embed the resources of skipped scopes as not compared", func() {
+				config := &ComparisonConfig{
+					ClusterA: ClusterConfig{
+						Context: "test-a",
+						Data:    []map[string]interface{}{testResource("Secret", "kube-system", "token", nil)},
+					},
+					ClusterB: ClusterConfig{
+						Context: "test-b",
+						Skipped: []SkippedScope{
+							{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "kube-system"}, Reason: skipReasonForbidden},
+						},
 					},
-					"spec": {
-						"containers": [{"name": "test", "image": "nginx"}]
-					}
-				}]`
+				}
 
-				template := generateHTMLTemplate(config, complexData, complexData, "2023-01-01_12-00-00")
+				config.CompareNamespaces = true
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
-				// Should handle complex nested JSON
-				Expect(template).To(ContainSubstring("test-pod"))
-				Expect(template).To(ContainSubstring("nginx"))
-				Expect(template).To(ContainSubstring("apiVersion"))
+				results := decodeEmbeddedResults(template)
+				Expect(results).To(HaveLen(1))
+				Expect(results[0]).To(HaveKeyWithValue("status", statusNotCompared))
+				Expect(results[0]["skipped"]).To(HaveKeyWithValue("resource", "secrets"))
+				Expect(template).To(ContainSubstring("secrets in kube-system (forbidden)"))
+			})
+
+			It("should treat scopes that failed to fetch as unknown", func() {
+				config := &ComparisonConfig{
+					ClusterA: ClusterConfig{
+						Context: "test-a",
+						Skipped: []SkippedScope{
+							{ResourceScope: ResourceScope{Resource: "pods", Kind: "Pod", Namespace: "default"}, Reason: skipReasonError, Error: "connection reset by peer"},
+						},
+					},
+					ClusterB: ClusterConfig{
+						Context: "test-b",
+						Data:    []map[string]interface{}{testResource("Pod", "default", "web", nil)},
+					},
+					CompareNamespaces: true,
+				}
+
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
+
+				results := decodeEmbeddedResults(template)
+				Expect(results).To(HaveLen(1))
+				Expect(results[0]).To(HaveKeyWithValue("status", statusUnknown))
+				Expect(results[0]["skipped"]).To(HaveKeyWithValue("error", "connection reset by peer"))
+				Expect(template).To(ContainSubstring("pods in default (fetch failed: connection reset by peer)"))
+			})
+
+			It("should embed the unfiltered summary shown before the data is decoded", func() {
+				config := &ComparisonConfig{
+					ClusterA: ClusterConfig{Data: []map[string]interface{}{
+						testResource("ConfigMap", "default", "app", map[string]interface{}{"a": "1"}),
+						testResource("Namespace", "", "default", nil),
+					}},
+					ClusterB: ClusterConfig{Data: []map[string]interface{}{
+						testResource("ConfigMap", "default", "app", map[string]interface{}{"a": "2"}),
+					}},
+					CompareNamespaces: true,
+				}
+
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
+
+				match := regexp.MustCompile(`const reportSummary = (.*);`).FindStringSubmatch(template)
+				Expect(match).To(HaveLen(2))
+				var summary htmlReportSummary
+				Expect(json.Unmarshal([]byte(match[1]), &summary)).To(Succeed())
+				Expect(summary).To(Equal(htmlReportSummary{
+					TotalA: 2,
+					TotalB: 1,
+					Kinds: []htmlReportKind{
+						{Kind: "ConfigMap", CountA: 1, CountB: 1},
+						{Kind: "Namespace", CountA: 1, CountB: 0},
+					},
+					Counts:     map[string]int{statusDifferent: 1, statusOnlyInA: 1},
+					Namespaces: []string{"", "default"},
+				}))
 			})
 		})
 
 		Context("when handling edge cases", func() {
-			It("should handle nil config gracefully", func() {
-				// This might panic or handle gracefully depending on implementation
-				// We test that it doesn't crash the test suite
-				defer func() {
-					if r := recover(); r != nil {
-						// If it panics, that's also a valid behavior to test
-						Expect(r).NotTo(BeNil())
-					}
-				}()
-
-				template := generateHTMLTemplate(nil, `[]`, `[]`, "2023-01-01_12-00-00")
-
-				// If it doesn't panic, it should still generate some HTML
-				if template != "" {
-					Expect(template).To(ContainSubstring("html"))
+			It("should embed an empty list when the clusters have no data", func() {
+				template := renderHTMLReport(&ComparisonConfig{}, "2023-01-01_12-00-00")
+
+				Expect(decodeEmbeddedResults(template)).To(BeEmpty())
+			})
+
+			It("should expose cluster contexts to the script as escaped strings", func() {
+				config := &ComparisonConfig{
+					ClusterA: ClusterConfig{Context: "</script>ctx"},
+					ClusterB: ClusterConfig{Context: "test-b"},
 				}
+
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
+
+				Expect(template).To(ContainSubstring(`const contextA = "\u003c/script\u003ectx";`))
+				Expect(template).To(ContainSubstring(`const contextB = "test-b";`))
 			})
 
-			It("should handle malformed JSON data", func() {
+			It("should reject a nil config", func() {
+				_, err := generateHTMLTemplate(nil, nil, "2023-01-01_12-00-00")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should not let resource data close the script tag", func() {
 				config := &ComparisonConfig{
-					ClusterA: ClusterConfig{Context: "test-a"},
+					ClusterA: ClusterConfig{
+						Context: "test-a",
+						Data: []map[string]interface{}{
+							testResource("ConfigMap", "default", "evil", map[string]interface{}{"script": "</script><script>alert(1)</script>"}),
+						},
+					},
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				malformedJSON := `[{"name": "test", "invalid": json}]`
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
-				template := generateHTMLTemplate(config, malformedJSON, `[]`, "2023-01-01_12-00-00")
+				Expect(template).NotTo(ContainSubstring("alert(1)"))
+				Expect(strings.Count(template, "<script")).To(Equal(strings.Count(template, "</script>")))
+				Expect(decodeEmbeddedResults(template)[0]["objectA"]).To(HaveKeyWithValue("spec", HaveKeyWithValue("script", "</script><script>alert(1)</script>")))
+			})
 
-				// Should still generate HTML even with malformed JSON
-				Expect(template).To(ContainSubstring("<!DOCTYPE html>"))
-				Expect(template).To(ContainSubstring("test-a"))
+			It("should escape contexts, namespaces and resource names", func() {
+				config := &ComparisonConfig{
+					ClusterA: ClusterConfig{
+						Context:    "<b>ctx-a</b>",
+						Namespaces: []string{"<i>ns</i>"},
+						Resources:  []string{"<img src=x>"},
+						Skipped: []SkippedScope{
+							{ResourceScope: ResourceScope{Resource: "<pods>", Kind: "Pod", Namespace: "default"}, Reason: skipReasonForbidden},
+						},
+					},
+					ClusterB: ClusterConfig{Context: "test-b"},
+				}
+
+				template := renderHTMLReport(config, "<2023>")
+
+				Expect(template).NotTo(ContainSubstring("<b>ctx-a</b>"))
+				Expect(template).NotTo(ContainSubstring("<i>ns</i>"))
+				Expect(template).NotTo(ContainSubstring("<img src=x>"))
+				Expect(template).NotTo(ContainSubstring("<2023>"))
+				Expect(template).To(ContainSubstring("&lt;b&gt;ctx-a&lt;/b&gt;"))
+				Expect(template).To(ContainSubstring("&lt;pods&gt; in default (forbidden)"))
 			})
 
 			It("should handle very long cluster names", func() {
@@ -230,7 +422,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: longName + "b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Should handle long names without breaking
 				Expect(template).To(ContainSubstring("<!DOCTYPE html>"))
@@ -243,7 +435,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "")
+				template := renderHTMLReport(config, "")
 
 				// Should still generate valid HTML
 				Expect(template).To(ContainSubstring("<!DOCTYPE html>"))
@@ -258,7 +450,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				// Basic HTML validation checks
 				htmlOpenCount := strings.Count(template, "<html")
@@ -280,7 +472,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				scriptOpenCount := strings.Count(template, "<script")
 				scriptCloseCount := strings.Count(template, "</script>")
@@ -293,7 +485,7 @@ var _ = Describe("HTML Template", func() {
 					ClusterB: ClusterConfig{Context: "test-b"},
 				}
 
-				template := generateHTMLTemplate(config, `[]`, `[]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(config, "2023-01-01_12-00-00")
 
 				styleOpenCount := strings.Count(template, "<style")
 				styleCloseCount := strings.Count(template, "</style>")
@@ -301,4 +493,15 @@ var _ = Describe("HTML Template", func() {
 			})
 		})
 	})
+
+	Describe("statusBadge function", func() {
+		It("should give every comparison status its own class and label", func() {
+			Expect(statusBadge(statusOnlyInA)).To(Equal([]string{"status-only-a", "Only in A"}))
+			Expect(statusBadge(statusOnlyInB)).To(Equal([]string{"status-only-b", "Only in B"}))
+			Expect(statusBadge(statusDifferent)).To(Equal([]string{"status-different", "Different"}))
+			Expect(statusBadge(statusIdentical)).To(Equal([]string{"status-identical", "Identical"}))
+			Expect(statusBadge(statusNotCompared)).To(Equal([]string{"status-not-compared", "Not compared"}))
+			Expect(statusBadge(statusUnknown)).To(Equal([]string{"status-unknown", "Unknown"}))
+		})
+	})
 })
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// defaultImageRegistry is the registry of image references that do not name one
+const defaultImageRegistry = "docker.io"
+
+// Places where the image digests run by a workload container can drift apart
+const (
+	digestDriftClusters = "between-clusters"
+	digestDriftWithinA  = "within-A"
+	digestDriftWithinB  = "within-B"
+)
+
+// workloadPodSpecPaths is where each workload kind keeps its pod spec
+var workloadPodSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ImageReference is a parsed container image reference
+type ImageReference struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// ContainerImageComparison compares the image of one workload container in both clusters
+type ContainerImageComparison struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Workload  string `json:"workload"`
+	Container string `json:"container"`
+	Status    string `json:"status"`
+	ImageA    string `json:"imageA,omitempty"`
+	ImageB    string `json:"imageB,omitempty"`
+	// Differences names the parts of the references that differ: registry, repository, tag or digest
+	Differences []string `json:"differences,omitempty"`
+	// DigestsA and DigestsB list the image digests the pods of the workload are running
+	DigestsA []string `json:"digestsA,omitempty"`
+	DigestsB []string `json:"digestsB,omitempty"`
+	// DigestDrift names where the running digests differ although the specs do not:
+	// digestDriftClusters, digestDriftWithinA or digestDriftWithinB
+	DigestDrift []string `json:"digestDrift,omitempty"`
+}
+
+// imagePart is one part of an image reference shown in the HTML report
+type imagePart struct {
+	Text    string
+	Changed bool
+}
+
+// workloadContainer is the image of one container of a workload
+type workloadContainer struct {
+	Kind      string
+	Namespace string
+	Workload  string
+	Container string
+	Image     string
+}
+
+// parseImageReference splits an image reference into its parts, filling in the defaults
+// container runtimes apply: docker.io, library/ for official images and the latest tag
+// when neither a tag nor a digest is given
+func parseImageReference(image string) ImageReference {
+	var ref ImageReference
+
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		name, ref.Digest = name[:at], name[at+1:]
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:colon], name[colon+1:]
+	}
+
+	ref.Registry = defaultImageRegistry
+	if first, rest, found := strings.Cut(name, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, name = first, rest
+	}
+	if ref.Registry == defaultImageRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref
+}
+
+// imageParts splits an image reference into the parts shown in the HTML report, marking
+// the parts named in differences. Defaulted parts are shown so they can be compared.
+func imageParts(image string, differences []string) []imagePart {
+	if image == "" {
+		return nil
+	}
+
+	changed := make(map[string]bool)
+	for _, difference := range differences {
+		changed[difference] = true
+	}
+
+	ref := parseImageReference(image)
+	parts := []imagePart{
+		{Text: ref.Registry + "/", Changed: changed["registry"]},
+		{Text: ref.Repository, Changed: changed["repository"]},
+	}
+	if ref.Tag != "" {
+		parts = append(parts, imagePart{Text: ":" + ref.Tag, Changed: changed["tag"]})
+	}
+	if ref.Digest != "" {
+		parts = append(parts, imagePart{Text: "@" + ref.Digest, Changed: changed["digest"]})
+	}
+	return parts
+}
+
+// imageDifferences names the parts in which two image references differ
+func imageDifferences(imageA, imageB string) []string {
+	refA, refB := parseImageReference(imageA), parseImageReference(imageB)
+
+	var differences []string
+	for _, part := range []struct {
+		name   string
+		valueA string
+		valueB string
+	}{
+		{"registry", refA.Registry, refB.Registry},
+		{"repository", refA.Repository, refB.Repository},
+		{"tag", refA.Tag, refB.Tag},
+		{"digest", refA.Digest, refB.Digest},
+	} {
+		if part.valueA != part.valueB {
+			differences = append(differences, part.name)
+		}
+	}
+	return differences
+}
+
+// fetchedWorkloads returns the ownerIndexKey of the workloads among the resources
+func fetchedWorkloads(resources []map[string]interface{}) map[string]bool {
+	workloads := make(map[string]bool)
+	for _, resource := range resources {
+		id := resourceIdentity(resource)
+		if _, isWorkload := workloadPodSpecPaths[id.Kind]; isWorkload {
+			workloads[ownerIndexKey(id.Kind, id.Namespace, id.Name)] = true
+		}
+	}
+	return workloads
+}
+
+// listedWorkload returns the workload a workload resource is listed under: its highest
+// fetched workload controller or, when none was fetched, its highest workload controller
+// known from the owner references, e.g. the Deployment of a Pod when only Pods were
+// fetched. Controllers that are not workloads, such as operator resources, end the search.
+func listedWorkload(owners ownerIndex, workloads map[string]bool, kind, namespace, name string) ownerReference {
+	if owner, found := owners.topFetchedOwner(kind, namespace, name, workloads); found {
+		return owner
+	}
+
+	listed := ownerReference{Kind: kind, Name: name}
+	for _, owner := range owners.ownerChain(kind, namespace, name) {
+		if _, isWorkload := workloadPodSpecPaths[owner.Kind]; isWorkload {
+			listed = owner
+		} else if owner.Kind != "ReplicaSet" {
+			break
+		}
+	}
+	return listed
+}
+
+// workloadContainers lists the init and regular containers of the workloads among the
+// resources under their listedWorkload. Workloads created by another fetched workload,
+// such as the Pods of a Deployment or the Jobs of a CronJob, are left out, since their
+// owner is listed with the same images. Workloads whose controller was not fetched are
+// listed under the controller, once per container; those whose controller is not a
+// workload, such as a StatefulSet created by an operator, are listed themselves.
+func workloadContainers(resources []map[string]interface{}) []workloadContainer {
+	owners := newOwnerIndex(resources)
+	workloads := fetchedWorkloads(resources)
+
+	var containers []workloadContainer
+	listed := make(map[string]bool)
+	for _, resource := range resources {
+		id := resourceIdentity(resource)
+		path, isWorkload := workloadPodSpecPaths[id.Kind]
+		if !isWorkload {
+			continue
+		}
+		if _, generated := owners.topFetchedOwner(id.Kind, id.Namespace, id.Name, workloads); generated {
+			continue
+		}
+		workload := listedWorkload(owners, workloads, id.Kind, id.Namespace, id.Name)
+
+		podSpec, _ := nestedMap(resource, path)
+		for _, field := range []string{"initContainers", "containers"} {
+			list, _ := podSpec[field].([]interface{})
+			for _, item := range list {
+				spec, _ := item.(map[string]interface{})
+				name, _ := spec["name"].(string)
+				image, _ := spec["image"].(string)
+				if image == "" {
+					continue
+				}
+				container := workloadContainer{
+					Kind:      workload.Kind,
+					Namespace: id.Namespace,
+					Workload:  workload.Name,
+					Container: name,
+					Image:     image,
+				}
+				if key := container.key(true); !listed[key] {
+					listed[key] = true
+					containers = append(containers, container)
+				}
+			}
+		}
+	}
+	return containers
+}
+
+// nestedMap returns the map at the given path of a resource
+func nestedMap(object map[string]interface{}, path []string) (map[string]interface{}, bool) {
+	current := object
+	for _, key := range path {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// runningDigests collects the image digests reported in the container statuses of the
+// Pods among the resources, keyed like workloadContainer.key with the listedWorkload of
+// each Pod as workload, so they match the containers listed by workloadContainers
+func runningDigests(resources []map[string]interface{}, useNamespace bool) map[string][]string {
+	owners := newOwnerIndex(resources)
+	workloads := fetchedWorkloads(resources)
+	found := make(map[string]map[string]bool)
+	for _, resource := range resources {
+		id := resourceIdentity(resource)
+		if id.Kind != "Pod" {
+			continue
+		}
+		owner := listedWorkload(owners, workloads, id.Kind, id.Namespace, id.Name)
+
+		status, _ := resource["status"].(map[string]interface{})
+		for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+			list, _ := status[field].([]interface{})
+			for _, item := range list {
+				containerStatus, _ := item.(map[string]interface{})
+				name, _ := containerStatus["name"].(string)
+				imageID, _ := containerStatus["imageID"].(string)
+				digest := imageDigest(imageID)
+				if digest == "" {
+					continue
+				}
+				key := workloadContainer{Kind: owner.Kind, Namespace: id.Namespace, Workload: owner.Name, Container: name}.key(useNamespace)
+				if found[key] == nil {
+					found[key] = make(map[string]bool)
+				}
+				found[key][digest] = true
+			}
+		}
+	}
+
+	digests := make(map[string][]string, len(found))
+	for key, set := range found {
+		digests[key] = sortedKeys(set)
+	}
+	return digests
+}
+
+// imageDigest extracts the digest from the imageID of a container status, which depending on
+// the runtime looks like "docker-pullable://nginx@sha256:...", "docker.io/library/nginx@sha256:..."
+// or "sha256:..."; it is empty while the container is waiting for its image
+func imageDigest(imageID string) string {
+	if at := strings.LastIndex(imageID, "@"); at >= 0 {
+		return imageID[at+1:]
+	}
+	if _, id, found := strings.Cut(imageID, "://"); found {
+		imageID = id
+	}
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+	return ""
+}
+
+// digestDrift names where the running digests of a container drift: within a cluster when its
+// pods run more than one digest, and between the clusters when the spec images are identical
+// but the clusters run different digests
+func digestDrift(comparison ContainerImageComparison) []string {
+	var drift []string
+	if comparison.Status == statusIdentical && len(comparison.DigestsA) > 0 && len(comparison.DigestsB) > 0 &&
+		!reflect.DeepEqual(comparison.DigestsA, comparison.DigestsB) {
+		drift = append(drift, digestDriftClusters)
+	}
+	if len(comparison.DigestsA) > 1 {
+		drift = append(drift, digestDriftWithinA)
+	}
+	if len(comparison.DigestsB) > 1 {
+		drift = append(drift, digestDriftWithinB)
+	}
+	return drift
+}
+
+// key identifies a workload container across clusters
+func (c workloadContainer) key(useNamespace bool) string {
+	if useNamespace {
+		return c.Kind + "/" + c.Namespace + "/" + c.Workload + "/" + c.Container
+	}
+	return c.Kind + "/" + c.Workload + "/" + c.Container
+}
+
+// compareImages compares the image of every workload container in both clusters, matched
+// by kind, workload name, container name and, when CompareNamespaces is set, namespace, along
+// with the image digests their pods are running
+func compareImages(config *ComparisonConfig) []ContainerImageComparison {
+	key := func(container workloadContainer) string {
+		return container.key(config.CompareNamespaces)
+	}
+	digestsA := runningDigests(config.ClusterA.Data, config.CompareNamespaces)
+	digestsB := runningDigests(config.ClusterB.Data, config.CompareNamespaces)
+
+	containersB := make(map[string]workloadContainer)
+	for _, container := range workloadContainers(config.ClusterB.Data) {
+		containersB[key(container)] = container
+	}
+
+	var comparisons []ContainerImageComparison
+	matched := make(map[string]bool)
+	for _, containerA := range workloadContainers(config.ClusterA.Data) {
+		comparison := ContainerImageComparison{
+			Kind:      containerA.Kind,
+			Namespace: containerA.Namespace,
+			Workload:  containerA.Workload,
+			Container: containerA.Container,
+			Status:    statusOnlyInA,
+			ImageA:    containerA.Image,
+			DigestsA:  digestsA[key(containerA)],
+		}
+		if containerB, found := containersB[key(containerA)]; found {
+			matched[key(containerA)] = true
+			comparison.ImageB = containerB.Image
+			comparison.DigestsB = digestsB[key(containerB)]
+			comparison.Differences = imageDifferences(containerA.Image, containerB.Image)
+			comparison.Status = statusIdentical
+			if len(comparison.Differences) > 0 {
+				comparison.Status = statusDifferent
+			}
+		}
+		comparison.DigestDrift = digestDrift(comparison)
+		comparisons = append(comparisons, comparison)
+	}
+
+	for _, containerB := range workloadContainers(config.ClusterB.Data) {
+		if matched[key(containerB)] {
+			continue
+		}
+		comparison := ContainerImageComparison{
+			Kind:      containerB.Kind,
+			Namespace: containerB.Namespace,
+			Workload:  containerB.Workload,
+			Container: containerB.Container,
+			Status:    statusOnlyInB,
+			ImageB:    containerB.Image,
+			DigestsB:  digestsB[key(containerB)],
+		}
+		comparison.DigestDrift = digestDrift(comparison)
+		comparisons = append(comparisons, comparison)
+	}
+
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		a, b := comparisons[i], comparisons[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+		return a.Container < b.Container
+	})
+	return comparisons
+}
+
+// differentImages returns the containers whose image differs or that exist in one cluster only
+func differentImages(images []ContainerImageComparison) []ContainerImageComparison {
+	var different []ContainerImageComparison
+	for _, image := range images {
+		if image.Status != statusIdentical {
+			different = append(different, image)
+		}
+	}
+	return different
+}
+
+// driftingImages returns the containers whose running image digests drift
+func driftingImages(images []ContainerImageComparison) []ContainerImageComparison {
+	var drifting []ContainerImageComparison
+	for _, image := range images {
+		if len(image.DigestDrift) > 0 {
+			drifting = append(drifting, image)
+		}
+	}
+	return drifting
+}
+
+// shortDigest abbreviates a digest to its algorithm and first 12 hex characters
+func shortDigest(digest string) string {
+	algorithm, hex, found := strings.Cut(digest, ":")
+	if !found || len(hex) <= 12 {
+		return digest
+	}
+	return algorithm + ":" + hex[:12]
+}
+
+// shortDigests abbreviates a list of digests and joins them with commas
+func shortDigests(digests []string) string {
+	short := make([]string, len(digests))
+	for i, digest := range digests {
+		short[i] = shortDigest(digest)
+	}
+	return strings.Join(short, ", ")
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testWorkload returns a workload of the given kind with one container per name/image pair
+func testWorkload(kind, namespace, name string, images ...string) map[string]interface{} {
+	var containers []interface{}
+	for i := 0; i+1 < len(images); i += 2 {
+		containers = append(containers, map[string]interface{}{"name": images[i], "image": images[i+1]})
+	}
+	podSpec := map[string]interface{}{"containers": containers}
+
+	switch kind {
+	case "Pod":
+		return testResource(kind, namespace, name, podSpec)
+	case "CronJob":
+		return testResource(kind, namespace, name, map[string]interface{}{
+			"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": podSpec}}},
+		})
+	}
+	return testResource(kind, namespace, name, map[string]interface{}{"template": map[string]interface{}{"spec": podSpec}})
+}
+
+// testRunningPod returns a Pod of a Deployment whose container reports running the given digest
+func testRunningPod(namespace, deployment, name, container, digest string) map[string]interface{} {
+	pod := withController(testResource("Pod", namespace, name, nil), "ReplicaSet", deployment+"-6d4f")
+	pod["metadata"].(map[string]interface{})["labels"] = map[string]interface{}{podTemplateHashLabel: "6d4f"}
+	pod["status"] = map[string]interface{}{
+		"containerStatuses": []interface{}{
+			map[string]interface{}{"name": container, "imageID": "docker.io/library/app@" + digest},
+		},
+	}
+	return pod
+}
+
+var _ = Describe("Images", func() {
+	Describe("parseImageReference function", func() {
+		It("should apply the defaults of container runtimes", func() {
+			Expect(parseImageReference("nginx")).To(Equal(ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}))
+			Expect(parseImageReference("bitnami/redis:7.2")).To(Equal(ImageReference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"}))
+		})
+
+		It("should recognise registries with a domain, a port or localhost", func() {
+			Expect(parseImageReference("gcr.io/project/app:v1")).To(Equal(ImageReference{Registry: "gcr.io", Repository: "project/app", Tag: "v1"}))
+			Expect(parseImageReference("registry:5000/app")).To(Equal(ImageReference{Registry: "registry:5000", Repository: "app", Tag: "latest"}))
+			Expect(parseImageReference("localhost/app:dev")).To(Equal(ImageReference{Registry: "localhost", Repository: "app", Tag: "dev"}))
+		})
+
+		It("should split off digests", func() {
+			Expect(parseImageReference("quay.io/org/app:v1@sha256:abc")).To(Equal(ImageReference{Registry: "quay.io", Repository: "org/app", Tag: "v1", Digest: "sha256:abc"}))
+			Expect(parseImageReference("quay.io/org/app@sha256:abc")).To(Equal(ImageReference{Registry: "quay.io", Repository: "org/app", Digest: "sha256:abc"}))
+		})
+	})
+
+	Describe("imageDifferences function", func() {
+		It("should name the differing parts", func() {
+			Expect(imageDifferences("nginx:1.25", "docker.io/library/nginx:1.25")).To(BeEmpty())
+			Expect(imageDifferences("nginx:1.25", "nginx:1.26")).To(Equal([]string{"tag"}))
+			Expect(imageDifferences("gcr.io/team/app:v1", "eu.gcr.io/team/app:v2")).To(Equal([]string{"registry", "tag"}))
+		})
+	})
+
+	Describe("imageDigest function", func() {
+		It("should extract the digest of every imageID format", func() {
+			Expect(imageDigest("docker-pullable://nginx@sha256:abc")).To(Equal("sha256:abc"))
+			Expect(imageDigest("docker.io/library/nginx@sha256:abc")).To(Equal("sha256:abc"))
+			Expect(imageDigest("docker://sha256:abc")).To(Equal("sha256:abc"))
+			Expect(imageDigest("sha256:abc")).To(Equal("sha256:abc"))
+			Expect(imageDigest("")).To(BeEmpty())
+		})
+	})
+
+	Describe("workloadContainers function", func() {
+		It("should list the containers of every workload kind", func() {
+			deployment := testWorkload("Deployment", "default", "web", "nginx", "nginx:1.25")
+			template := deployment["spec"].(map[string]interface{})["template"].(map[string]interface{})
+			template["spec"].(map[string]interface{})["initContainers"] = []interface{}{
+				map[string]interface{}{"name": "migrate", "image": "app:v1"},
+			}
+
+			containers := workloadContainers([]map[string]interface{}{
+				deployment,
+				testWorkload("CronJob", "default", "backup", "backup", "backup:v3"),
+				testWorkload("Pod", "default", "debug", "shell", "busybox"),
+				testResource("ConfigMap", "default", "settings", nil),
+			})
+
+			Expect(containers).To(Equal([]workloadContainer{
+				{Kind: "Deployment", Namespace: "default", Workload: "web", Container: "migrate", Image: "app:v1"},
+				{Kind: "Deployment", Namespace: "default", Workload: "web", Container: "nginx", Image: "nginx:1.25"},
+				{Kind: "CronJob", Namespace: "default", Workload: "backup", Container: "backup", Image: "backup:v3"},
+				{Kind: "Pod", Namespace: "default", Workload: "debug", Container: "shell", Image: "busybox"},
+			}))
+		})
+
+		It("should leave out workloads created by a fetched workload", func() {
+			pod := withController(testWorkload("Pod", "default", "web-6d4f-x2k", "nginx", "nginx:1.25"), "ReplicaSet", "web-6d4f")
+			pod["metadata"].(map[string]interface{})["labels"] = map[string]interface{}{podTemplateHashLabel: "6d4f"}
+			job := withController(testWorkload("Job", "default", "backup-28391", "backup", "backup:v3"), "CronJob", "backup")
+
+			Expect(workloadContainers([]map[string]interface{}{
+				testWorkload("Deployment", "default", "web", "nginx", "nginx:1.25"),
+				testWorkload("CronJob", "default", "backup", "backup", "backup:v3"),
+				pod,
+				job,
+			})).To(Equal([]workloadContainer{
+				{Kind: "Deployment", Namespace: "default", Workload: "web", Container: "nginx", Image: "nginx:1.25"},
+				{Kind: "CronJob", Namespace: "default", Workload: "backup", Container: "backup", Image: "backup:v3"},
+			}))
+		})
+
+		It("should list the Pods when their controllers were not fetched", func() {
+			pod := withController(testWorkload("Pod", "default", "web-6d4f-x2k", "nginx", "nginx:1.25"), "ReplicaSet", "web-6d4f")
+
+			Expect(workloadContainers([]map[string]interface{}{pod})).To(HaveLen(1))
+		})
+
+		It("should list workloads created by operators", func() {
+			statefulSet := withController(testWorkload("StatefulSet", "monitoring", "prometheus-main", "prometheus", "prom/prometheus:v2.51"), "Prometheus", "main")
+			prometheus := testResource("Prometheus", "monitoring", "main", nil)
+
+			Expect(workloadContainers([]map[string]interface{}{prometheus, statefulSet})).To(Equal([]workloadContainer{
+				{Kind: "StatefulSet", Namespace: "monitoring", Workload: "prometheus-main", Container: "prometheus", Image: "prom/prometheus:v2.51"},
+			}))
+		})
+	})
+
+	Describe("compareImages function", func() {
+		var config *ComparisonConfig
+
+		BeforeEach(func() {
+			config = &ComparisonConfig{
+				ClusterA: ClusterConfig{Data: []map[string]interface{}{
+					testWorkload("Deployment", "default", "web", "nginx", "nginx:1.25", "sidecar", "gcr.io/team/proxy:v1"),
+					testWorkload("StatefulSet", "default", "db", "postgres", "postgres:16"),
+					testWorkload("DaemonSet", "default", "agent", "agent", "agent:v1"),
+				}},
+				ClusterB: ClusterConfig{Data: []map[string]interface{}{
+					testWorkload("Deployment", "default", "web", "nginx", "nginx:1.26", "sidecar", "eu.gcr.io/team/proxy:v1"),
+					testWorkload("StatefulSet", "default", "db", "postgres", "docker.io/library/postgres:16"),
+					testWorkload("Job", "default", "seed", "seed", "seed:v1"),
+				}},
+				CompareNamespaces: true,
+			}
+		})
+
+		It("should compare the image of every container", func() {
+			images := compareImages(config)
+
+			Expect(images).To(Equal([]ContainerImageComparison{
+				{Kind: "DaemonSet", Namespace: "default", Workload: "agent", Container: "agent", Status: statusOnlyInA, ImageA: "agent:v1"},
+				{Kind: "Deployment", Namespace: "default", Workload: "web", Container: "nginx", Status: statusDifferent,
+					ImageA: "nginx:1.25", ImageB: "nginx:1.26", Differences: []string{"tag"}},
+				{Kind: "Deployment", Namespace: "default", Workload: "web", Container: "sidecar", Status: statusDifferent,
+					ImageA: "gcr.io/team/proxy:v1", ImageB: "eu.gcr.io/team/proxy:v1", Differences: []string{"registry"}},
+				{Kind: "Job", Namespace: "default", Workload: "seed", Container: "seed", Status: statusOnlyInB, ImageB: "seed:v1"},
+				{Kind: "StatefulSet", Namespace: "default", Workload: "db", Container: "postgres", Status: statusIdentical,
+					ImageA: "postgres:16", ImageB: "docker.io/library/postgres:16"},
+			}))
+		})
+
+		It("should match workloads by name alone when namespaces are not compared", func() {
+			config.CompareNamespaces = false
+			config.ClusterA.Data = []map[string]interface{}{testWorkload("Deployment", "staging", "web", "nginx", "nginx:1.25")}
+			config.ClusterB.Data = []map[string]interface{}{testWorkload("Deployment", "production", "web", "nginx", "nginx:1.26")}
+
+			images := compareImages(config)
+			Expect(images).To(HaveLen(1))
+			Expect(images[0].Status).To(Equal(statusDifferent))
+		})
+
+		It("should be part of the comparison and its reports", func() {
+			comparison := compareClusters(config)
+			Expect(comparison.Images).To(HaveLen(5))
+
+			report := buildDiffReport(config, comparison, time.Now())
+			Expect(report.Images).To(Equal(comparison.Images))
+
+			var text strings.Builder
+			Expect(renderTextReport(&text, config, comparison, false)).To(Succeed())
+			Expect(text.String()).To(ContainSubstring("🐳 Container Images (5 containers, 4 differ)"))
+			Expect(text.String()).To(MatchRegexp(`Deployment/web\s+nginx\s+different\s+nginx:1.25\s+nginx:1.26\s+tag`))
+			Expect(text.String()).NotTo(ContainSubstring("Deployment/db"))
+
+			markdown, err := renderMarkdownReport(config, comparison)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(markdown).To(ContainSubstring("| Deployment default/web | sidecar | different | `gcr.io/team/proxy:v1` | `eu.gcr.io/team/proxy:v1` | registry |"))
+			Expect(markdown).To(ContainSubstring("| DaemonSet default/agent | agent | only-in-A | `agent:v1` | - |  |"))
+			Expect(markdown).To(ContainSubstring("Containers with identical images: 1"))
+
+			html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(html).To(ContainSubstring(`onclick="showTab('images')"`))
+			Expect(html).To(ContainSubstring(`<code>docker.io/library/nginx<mark>:1.26</mark></code>`))
+			Expect(html).To(ContainSubstring(`<code><mark>eu.gcr.io/</mark>team/proxy:v1</code>`))
+		})
+
+		It("should leave the Images tab out when there are no workloads", func() {
+			html, err := generateHTMLTemplate(&ComparisonConfig{}, compareClusters(&ComparisonConfig{}), "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(html).NotTo(ContainSubstring("showTab('images')"))
+		})
+
+		Describe("running digests", func() {
+			const digest1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+			const digest2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+
+			BeforeEach(func() {
+				config.ClusterA.Data = []map[string]interface{}{
+					testWorkload("Deployment", "default", "web", "app", "app:1.4"),
+					testWorkload("Deployment", "default", "api", "app", "app:2.0"),
+					testRunningPod("default", "web", "web-6d4f-a", "app", digest1),
+					testRunningPod("default", "api", "api-6d4f-a", "app", digest1),
+					testRunningPod("default", "api", "api-6d4f-b", "app", digest2),
+				}
+				config.ClusterB.Data = []map[string]interface{}{
+					testWorkload("Deployment", "default", "web", "app", "app:1.4"),
+					testWorkload("Deployment", "default", "api", "app", "app:2.1"),
+					testRunningPod("default", "web", "web-6d4f-a", "app", digest2),
+					testRunningPod("default", "api", "api-6d4f-a", "app", digest1),
+				}
+			})
+
+			It("should report digests that drift between clusters or between pods", func() {
+				images := compareImages(config)
+
+				Expect(images).To(HaveLen(2))
+				api, web := images[0], images[1]
+				Expect(web.Status).To(Equal(statusIdentical))
+				Expect(web.DigestsA).To(Equal([]string{digest1}))
+				Expect(web.DigestsB).To(Equal([]string{digest2}))
+				Expect(web.DigestDrift).To(Equal([]string{digestDriftClusters}))
+
+				Expect(api.Status).To(Equal(statusDifferent))
+				Expect(api.DigestsA).To(Equal([]string{digest1, digest2}))
+				Expect(api.DigestDrift).To(Equal([]string{digestDriftWithinA}))
+			})
+
+			It("should not report drift when one cluster has no running pods", func() {
+				config.ClusterB.Data = config.ClusterB.Data[:2]
+
+				images := compareImages(config)
+				Expect(images[1].DigestDrift).To(BeEmpty())
+			})
+
+			It("should attribute Pods to their Deployment when only Pods are fetched", func() {
+				podsOnly := func(data []map[string]interface{}) []map[string]interface{} {
+					var pods []map[string]interface{}
+					for _, resource := range data {
+						if resourceIdentity(resource).Kind != "Pod" {
+							continue
+						}
+						resource["spec"] = map[string]interface{}{"containers": []interface{}{
+							map[string]interface{}{"name": "app", "image": "app:2.0"},
+						}}
+						pods = append(pods, resource)
+					}
+					return pods
+				}
+				config.ClusterA.Data = podsOnly(config.ClusterA.Data)
+				config.ClusterB.Data = podsOnly(config.ClusterB.Data)
+
+				images := compareImages(config)
+
+				Expect(images).To(HaveLen(2))
+				api, web := images[0], images[1]
+				Expect(api.Kind + "/" + api.Workload).To(Equal("Deployment/api"))
+				Expect(api.Status).To(Equal(statusIdentical))
+				Expect(api.DigestDrift).To(Equal([]string{digestDriftClusters, digestDriftWithinA}))
+				Expect(web.Kind + "/" + web.Workload).To(Equal("Deployment/web"))
+				Expect(web.DigestDrift).To(Equal([]string{digestDriftClusters}))
+			})
+
+			It("should attribute Pods to their Job when the Jobs of a CronJob are not fetched", func() {
+				pod := withController(testWorkload("Pod", "default", "backup-28391-abcde", "backup", "backup:v3"), "Job", "backup-28391")
+				pod["status"] = map[string]interface{}{
+					"containerStatuses": []interface{}{
+						map[string]interface{}{"name": "backup", "imageID": "docker.io/library/backup@" + digest1},
+					},
+				}
+				config.ClusterA.Data = []map[string]interface{}{testWorkload("CronJob", "default", "backup", "backup", "backup:v3"), pod}
+				config.ClusterB.Data = nil
+
+				Expect(compareImages(config)).To(ContainElement(ContainerImageComparison{
+					Kind: "Job", Namespace: "default", Workload: "backup-28391", Container: "backup",
+					Status: statusOnlyInA, ImageA: "backup:v3", DigestsA: []string{digest1},
+				}))
+			})
+
+			It("should list drifting containers in the reports", func() {
+				comparison := compareClusters(config)
+
+				var text strings.Builder
+				Expect(renderTextReport(&text, config, comparison, false)).To(Succeed())
+				Expect(text.String()).To(ContainSubstring("🧬 Running Digest Drift (2)"))
+				Expect(text.String()).To(MatchRegexp(`Deployment/web\s+app\s+between-clusters\s+sha256:111111111111\s+sha256:222222222222`))
+
+				markdown, err := renderMarkdownReport(config, comparison)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(markdown).To(ContainSubstring("| Deployment default/api | app | within-A | `sha256:111111111111, sha256:222222222222` | `sha256:111111111111` |"))
+
+				html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(html).To(ContainSubstring(`<div class="image-drift">🧬 Digest drift: between-clusters</div>`))
+				Expect(html).To(ContainSubstring(`<div class="image-digest" title="` + digest2 + `">sha256:222222222222</div>`))
+			})
+		})
+	})
+})
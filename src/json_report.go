@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// diffReportSchemaVersion is the version of the diff-<ts>.json schema. It is bumped
+// whenever a field is removed or changes meaning; new fields may be added within a version.
+const diffReportSchemaVersion = "k8s-compare.diff/v1"
+
+// DiffReport is the machine-readable comparison written to diff-<ts>.json
+type DiffReport struct {
+	SchemaVersion string             `json:"schemaVersion"`
+	Metadata      DiffReportMetadata `json:"metadata"`
+	Summary       DiffReportSummary  `json:"summary"`
+	Kinds         []DiffReportKind   `json:"kinds"`
+	// HelmReleases compares the latest revision of each Helm release in both clusters
+	HelmReleases []HelmReleaseComparison `json:"helmReleases"`
+	// Images compares the image of every workload container in both clusters
+	Images []ContainerImageComparison `json:"images"`
+	// RBAC compares the effective permissions of every subject; it is only present when
+	// the RBAC analysis was requested
+	RBAC []RBACSubjectComparison `json:"rbac,omitempty"`
+}
+
+// DiffReportMetadata describes the comparison run
+type DiffReportMetadata struct {
+	GeneratedAt       string            `json:"generatedAt"`
+	ReportTimestamp   string            `json:"reportTimestamp"`
+	CompareNamespaces bool              `json:"compareNamespaces"`
+	IgnoredFields     []string          `json:"ignoredFields"`
+	ClusterA          DiffReportCluster `json:"clusterA"`
+	ClusterB          DiffReportCluster `json:"clusterB"`
+}
+
+// DiffReportCluster describes what was fetched from one cluster
+type DiffReportCluster struct {
+	Context    string         `json:"context"`
+	Namespaces []string       `json:"namespaces"`
+	Resources  []string       `json:"resources"`
+	Total      int            `json:"total"`
+	Skipped    []SkippedScope `json:"skipped"`
+}
+
+// DiffReportSummary counts the compared resources per status
+type DiffReportSummary struct {
+	Counts map[string]int `json:"counts"`
+}
+
+// DiffReportKind holds the compared resources of one kind
+type DiffReportKind struct {
+	Kind      string               `json:"kind"`
+	CountA    int                  `json:"countA"`
+	CountB    int                  `json:"countB"`
+	Counts    map[string]int       `json:"counts"`
+	Resources []ResourceComparison `json:"resources"`
+}
+
+// buildDiffReport converts a comparison into the versioned diff report
+func buildDiffReport(config *ComparisonConfig, comparison *Comparison, generatedAt time.Time) DiffReport {
+	report := DiffReport{
+		SchemaVersion: diffReportSchemaVersion,
+		Metadata: DiffReportMetadata{
+			GeneratedAt:       generatedAt.UTC().Format(time.RFC3339),
+			ReportTimestamp:   config.ReportTimestamp,
+			CompareNamespaces: config.CompareNamespaces,
+			IgnoredFields:     ignoredFields,
+			ClusterA:          diffReportCluster(config.ClusterA),
+			ClusterB:          diffReportCluster(config.ClusterB),
+		},
+		Summary:      DiffReportSummary{Counts: statusCountsWithZeros(comparison.StatusCounts())},
+		Kinds:        []DiffReportKind{},
+		HelmReleases: comparison.HelmReleases,
+		Images:       comparison.Images,
+		RBAC:         comparison.RBAC,
+	}
+	if report.HelmReleases == nil {
+		report.HelmReleases = []HelmReleaseComparison{}
+	}
+	if report.Images == nil {
+		report.Images = []ContainerImageComparison{}
+	}
+
+	for _, kind := range comparison.Kinds {
+		resources := kind.Resources
+		if resources == nil {
+			resources = []ResourceComparison{}
+		}
+		report.Kinds = append(report.Kinds, DiffReportKind{
+			Kind:      kind.Kind,
+			CountA:    kind.CountA,
+			CountB:    kind.CountB,
+			Counts:    statusCountsWithZeros(kind.StatusCounts()),
+			Resources: resources,
+		})
+	}
+
+	return report
+}
+
+// diffReportCluster describes a cluster, using empty lists rather than null
+func diffReportCluster(cluster ClusterConfig) DiffReportCluster {
+	described := DiffReportCluster{
+		Context:    cluster.Context,
+		Namespaces: cluster.Namespaces,
+		Resources:  cluster.Resources,
+		Total:      len(cluster.Data),
+		Skipped:    cluster.Skipped,
+	}
+	if described.Namespaces == nil {
+		described.Namespaces = []string{}
+	}
+	if described.Resources == nil {
+		described.Resources = []string{}
+	}
+	if described.Skipped == nil {
+		described.Skipped = []SkippedScope{}
+	}
+	return described
+}
+
+// statusCountsWithZeros returns the counts with an entry for every status, so consumers
+// do not have to treat missing keys as zero
+func statusCountsWithZeros(counts map[string]int) map[string]int {
+	complete := make(map[string]int)
+	for _, status := range []string{statusOnlyInA, statusOnlyInB, statusDifferent, statusIdentical, statusNotCompared, statusUnknown} {
+		complete[status] = counts[status]
+	}
+	return complete
+}
+
+// generateDiffReport writes the machine-readable diff report to diff-<ts>.json
+func generateDiffReport(config *ComparisonConfig, comparison *Comparison) error {
+	filename := fmt.Sprintf("%s/diff-%s.json", config.OutputDir, config.ReportTimestamp)
+
+	jsonData, err := json.MarshalIndent(buildDiffReport(config, comparison, time.Now()), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal diff report: %w", err)
+	}
+
+	if err := os.WriteFile(filename, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write diff report: %w", err)
+	}
+
+	fmt.Fprintf(config.progress(), "📄 Generated JSON diff report: %s\n", filename)
+	return nil
+}
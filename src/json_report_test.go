@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON diff report", func() {
+	var config *ComparisonConfig
+
+	BeforeEach(func() {
+		config = &ComparisonConfig{
+			ClusterA: ClusterConfig{
+				Context:    "staging",
+				Namespaces: []string{"default"},
+				Resources:  []string{"deployments"},
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"paused": false}),
+				},
+			},
+			ClusterB: ClusterConfig{
+				Context:    "production",
+				Namespaces: []string{"default"},
+				Resources:  []string{"deployments"},
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"paused": true}),
+				},
+			},
+			ReportTimestamp:   "2024-01-02_03:04:05",
+			CompareNamespaces: true,
+		}
+	})
+
+	Describe("buildDiffReport function", func() {
+		It("should describe the run and count every status", func() {
+			report := buildDiffReport(config, compareClusters(config), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+			Expect(report.SchemaVersion).To(Equal(diffReportSchemaVersion))
+			Expect(report.Metadata.GeneratedAt).To(Equal("2024-01-02T03:04:05Z"))
+			Expect(report.Metadata.ClusterA.Context).To(Equal("staging"))
+			Expect(report.Metadata.ClusterB.Total).To(Equal(1))
+			Expect(report.Summary.Counts).To(HaveLen(6))
+			Expect(report.Summary.Counts[statusDifferent]).To(Equal(1))
+			Expect(report.Summary.Counts[statusOnlyInA]).To(Equal(0))
+			Expect(report.Kinds).To(HaveLen(1))
+			Expect(report.Kinds[0].Kind).To(Equal("Deployment"))
+		})
+	})
+
+	Describe("JSON encoding", func() {
+		It("should encode resources with their identity and field changes", func() {
+			jsonData, err := json.Marshal(buildDiffReport(config, compareClusters(config), time.Now()))
+			Expect(err).NotTo(HaveOccurred())
+
+			var decoded map[string]interface{}
+			Expect(json.Unmarshal(jsonData, &decoded)).To(Succeed())
+
+			resource := decoded["kinds"].([]interface{})[0].(map[string]interface{})["resources"].([]interface{})[0].(map[string]interface{})
+			Expect(resource).To(HaveKeyWithValue("kind", "Deployment"))
+			Expect(resource).To(HaveKeyWithValue("namespace", "default"))
+			Expect(resource).To(HaveKeyWithValue("name", "web"))
+			Expect(resource).To(HaveKeyWithValue("status", statusDifferent))
+			Expect(resource).NotTo(HaveKey("ObjectA"))
+			Expect(resource["changes"]).To(ConsistOf(map[string]interface{}{
+				"path": "spec.paused", "type": changeModified, "valueA": false, "valueB": true,
+			}))
+		})
+
+		It("should omit the value of the side a field is missing from", func() {
+			added, err := json.Marshal(FieldChange{Path: "spec.replicas", Type: changeAdded, ValueB: float64(0)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(added)).To(MatchJSON(`{"path": "spec.replicas", "type": "added", "valueB": 0}`))
+
+			removed, err := json.Marshal(FieldChange{Path: "spec.replicas", Type: changeRemoved, ValueA: float64(2)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(removed)).To(MatchJSON(`{"path": "spec.replicas", "type": "removed", "valueA": 2}`))
+		})
+
+		It("should use empty lists rather than null", func() {
+			config.ClusterA = ClusterConfig{}
+			config.ClusterB = ClusterConfig{}
+
+			jsonData, err := json.Marshal(buildDiffReport(config, compareClusters(config), time.Now()))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(jsonData)).To(ContainSubstring(`"kinds":[]`))
+			Expect(string(jsonData)).To(ContainSubstring(`"skipped":[]`))
+			Expect(string(jsonData)).NotTo(ContainSubstring("null"))
+		})
+	})
+
+	Describe("generateDiffReport function", func() {
+		It("should write diff-<ts>.json to the output directory", func() {
+			tempDir, err := os.MkdirTemp("", "k8s-compare-test")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(tempDir)
+			config.OutputDir = tempDir
+
+			Expect(generateDiffReport(config, compareClusters(config))).To(Succeed())
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "diff-2024-01-02_03:04:05.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"schemaVersion": "k8s-compare.diff/v1"`))
+		})
+	})
+})
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups the test cases of one resource kind
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is one compared resource
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+// junitMessage is the outcome of a test case that did not pass
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// buildJUnitReport converts a comparison into JUnit test suites, one per kind:
+// identical resources pass, differing and missing resources fail with the field diff,
+// resources whose only differences are in ignored fields or that could not be compared
+// are skipped, and resources whose scope failed to list are errors
+func buildJUnitReport(config *ComparisonConfig, comparison *Comparison) junitTestSuites {
+	report := junitTestSuites{
+		Name:   fmt.Sprintf("k8s-compare %s vs %s", config.ClusterA.Context, config.ClusterB.Context),
+		Suites: []junitTestSuite{},
+	}
+
+	for _, kind := range comparison.Kinds {
+		suite := junitTestSuite{Name: kind.Kind, Timestamp: config.ReportTimestamp}
+
+		for _, resource := range kind.Resources {
+			testCase := junitResourceTestCase(config, resource)
+			suite.Tests++
+			switch {
+			case testCase.Failure != nil:
+				suite.Failures++
+			case testCase.Error != nil:
+				suite.Errors++
+			case testCase.Skipped != nil:
+				suite.Skipped++
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	return report
+}
+
+// junitResourceTestCase builds the test case of a compared resource
+func junitResourceTestCase(config *ComparisonConfig, resource ResourceComparison) junitTestCase {
+	name := resource.Name
+	if resource.Namespace != "" {
+		name = resource.Namespace + "/" + name
+	}
+	testCase := junitTestCase{Name: name, ClassName: resource.Kind}
+
+	switch resource.Status {
+	case statusDifferent:
+		testCase.Failure = &junitMessage{
+			Message: fmt.Sprintf("%d fields differ between %s and %s", len(resource.Changes), config.ClusterA.Context, config.ClusterB.Context),
+			Type:    statusDifferent,
+			Text:    formatFieldChanges(resource.Changes),
+		}
+	case statusOnlyInA:
+		testCase.Failure = &junitMessage{
+			Message: fmt.Sprintf("only present in Cluster A (%s)", config.ClusterA.Context),
+			Type:    statusOnlyInA,
+		}
+	case statusOnlyInB:
+		testCase.Failure = &junitMessage{
+			Message: fmt.Sprintf("only present in Cluster B (%s)", config.ClusterB.Context),
+			Type:    statusOnlyInB,
+		}
+	case statusNotCompared:
+		testCase.Skipped = &junitMessage{Message: "not compared: " + resource.Skipped.String() + " is not permitted in the other cluster"}
+	case statusUnknown:
+		testCase.Error = &junitMessage{
+			Message: "unknown: listing " + resource.Skipped.String() + " failed in the other cluster",
+			Type:    statusUnknown,
+			Text:    resource.Skipped.Error,
+		}
+	case statusIdentical:
+		if len(resource.IgnoredChanges) > 0 {
+			testCase.Skipped = &junitMessage{Message: "only ignored fields differ:\n" + formatFieldChanges(resource.IgnoredChanges)}
+		}
+	}
+
+	return testCase
+}
+
+// formatFieldChanges formats field changes one per line, e.g. "spec.replicas: 2 → 3"
+func formatFieldChanges(changes []FieldChange) string {
+	var lines []string
+	for _, change := range changes {
+		switch change.Type {
+		case changeAdded:
+			lines = append(lines, fmt.Sprintf("%s: added %s", change.Path, formatFieldValue(change.ValueB)))
+		case changeRemoved:
+			lines = append(lines, fmt.Sprintf("%s: removed %s", change.Path, formatFieldValue(change.ValueA)))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s → %s", change.Path, formatFieldValue(change.ValueA), formatFieldValue(change.ValueB)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatFieldValue formats a field value as compact JSON
+func formatFieldValue(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+// generateJUnitReport writes the comparison as JUnit XML to the given path
+func generateJUnitReport(path string, config *ComparisonConfig, comparison *Comparison) error {
+	xmlData, err := xml.MarshalIndent(buildJUnitReport(config, comparison), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create JUnit report directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, append([]byte(xml.Header), xmlData...), 0644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+
+	fmt.Fprintf(config.progress(), "📄 Generated JUnit report: %s\n", path)
+	return nil
+}
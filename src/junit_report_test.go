@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JUnit report", func() {
+	var config *ComparisonConfig
+
+	BeforeEach(func() {
+		identicalA := testResource("ConfigMap", "default", "settings", map[string]interface{}{"a": "1"})
+		identicalA["metadata"].(map[string]interface{})["resourceVersion"] = "1"
+		identicalB := testResource("ConfigMap", "default", "settings", map[string]interface{}{"a": "1"})
+		identicalB["metadata"].(map[string]interface{})["resourceVersion"] = "2"
+
+		config = &ComparisonConfig{
+			ClusterA: ClusterConfig{
+				Context: "staging",
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(2)}),
+					testResource("Deployment", "default", "api", nil),
+					testResource("Secret", "kube-system", "token", nil),
+					identicalA,
+				},
+				Skipped: []SkippedScope{
+					{ResourceScope: ResourceScope{Resource: "services", Kind: "Service", Namespace: "default"}, Reason: skipReasonError, Error: "connection reset"},
+				},
+			},
+			ClusterB: ClusterConfig{
+				Context: "production",
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(3)}),
+					testResource("Service", "default", "web", nil),
+					identicalB,
+				},
+				Skipped: []SkippedScope{
+					{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "kube-system"}, Reason: skipReasonForbidden},
+				},
+			},
+			ReportTimestamp:   "2024-01-02_03:04:05",
+			CompareNamespaces: true,
+		}
+	})
+
+	Describe("buildJUnitReport function", func() {
+		It("should group test cases into suites by kind and count outcomes", func() {
+			report := buildJUnitReport(config, compareClusters(config))
+
+			Expect(report.Tests).To(Equal(5))
+			Expect(report.Failures).To(Equal(2))
+			Expect(report.Errors).To(Equal(1))
+			Expect(report.Skipped).To(Equal(2))
+
+			Expect(report.Suites).To(HaveLen(4))
+			Expect(report.Suites[1].Name).To(Equal("Deployment"))
+			Expect(report.Suites[1].Tests).To(Equal(2))
+			Expect(report.Suites[1].Failures).To(Equal(2))
+		})
+
+		It("should fail differing resources with the field diff", func() {
+			deployments := buildJUnitReport(config, compareClusters(config)).Suites[1].TestCases
+
+			Expect(deployments[0].Name).To(Equal("default/api"))
+			Expect(deployments[0].Failure.Type).To(Equal(statusOnlyInA))
+			Expect(deployments[1].Name).To(Equal("default/web"))
+			Expect(deployments[1].ClassName).To(Equal("Deployment"))
+			Expect(deployments[1].Failure.Message).To(Equal("1 fields differ between staging and production"))
+			Expect(deployments[1].Failure.Text).To(Equal("spec.replicas: 2 → 3"))
+		})
+
+		It("should skip resources that differ only in ignored fields", func() {
+			configMaps := buildJUnitReport(config, compareClusters(config)).Suites[0].TestCases
+
+			Expect(configMaps[0].Failure).To(BeNil())
+			Expect(configMaps[0].Skipped.Message).To(ContainSubstring(`metadata.resourceVersion: "1" → "2"`))
+		})
+
+		It("should skip forbidden resources and report failed scopes as errors", func() {
+			suites := buildJUnitReport(config, compareClusters(config)).Suites
+
+			Expect(suites[2].Name).To(Equal("Secret"))
+			Expect(suites[2].TestCases[0].Skipped.Message).To(ContainSubstring("not permitted"))
+			Expect(suites[3].Name).To(Equal("Service"))
+			Expect(suites[3].TestCases[0].Error.Text).To(Equal("connection reset"))
+		})
+	})
+
+	Describe("formatFieldChanges function", func() {
+		It("should describe added, removed and modified fields", func() {
+			Expect(formatFieldChanges([]FieldChange{
+				{Path: "spec.paused", Type: changeAdded, ValueB: true},
+				{Path: "spec.replicas", Type: changeRemoved, ValueA: int64(2)},
+				{Path: "spec.image", Type: changeModified, ValueA: "nginx:1.25", ValueB: "nginx:1.26"},
+			})).To(Equal("spec.paused: added true\nspec.replicas: removed 2\nspec.image: \"nginx:1.25\" → \"nginx:1.26\""))
+		})
+	})
+
+	Describe("generateJUnitReport function", func() {
+		It("should write valid JUnit XML", func() {
+			tempDir, err := os.MkdirTemp("", "k8s-compare-test")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(tempDir)
+			path := filepath.Join(tempDir, "results", "junit.xml")
+
+			Expect(generateJUnitReport(path, config, compareClusters(config))).To(Succeed())
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(HavePrefix(xml.Header))
+
+			var decoded junitTestSuites
+			Expect(xml.Unmarshal(content, &decoded)).To(Succeed())
+			Expect(decoded.Tests).To(Equal(5))
+			Expect(decoded.Suites[1].TestCases[1].Failure.Text).To(Equal("spec.replicas: 2 → 3"))
+		})
+	})
+})
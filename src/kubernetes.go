@@ -2,54 +2,79 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-// getKubernetesClient creates a Kubernetes client for the given context
-func getKubernetesClient(contextName string) (*kubernetes.Clientset, error) {
-	kubeConfig := clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
-	config, err := clientcmd.LoadFromFile(kubeConfig)
-	if err != nil {
-		return nil, err
+// newClientConfig builds a client config for the given context using the standard
+// kubeconfig loading rules. An empty kubeconfig honours $KUBECONFIG (including
+// colon-separated lists) and falls back to ~/.kube/config, exactly like kubectl.
+// A non-empty kubeconfig may itself be a single file or a list of files.
+func newClientConfig(kubeconfig, contextName string) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		paths := filepath.SplitList(kubeconfig)
+		if len(paths) == 1 {
+			loadingRules.ExplicitPath = paths[0]
+		} else {
+			loadingRules.Precedence = paths
+		}
 	}
 
-	clientConfig := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{
 		CurrentContext: contextName,
 	})
+}
 
-	restConfig, err := clientConfig.ClientConfig()
+// loadKubeconfig returns the merged raw kubeconfig for the given kubeconfig selection
+func loadKubeconfig(kubeconfig string) (*clientcmdapi.Config, error) {
+	rawConfig, err := newClientConfig(kubeconfig, "").RawConfig()
 	if err != nil {
 		return nil, err
 	}
+	return &rawConfig, nil
+}
 
-	return kubernetes.NewForConfig(restConfig)
+// getRESTConfig returns the REST config for the given kubeconfig and context
+func getRESTConfig(kubeconfig, contextName string) (*rest.Config, error) {
+	restConfig, err := newClientConfig(kubeconfig, contextName).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	restConfig.ExecProvider = withGCloudAuthMode(restConfig.ExecProvider)
+	return restConfig, nil
 }
 
-// getDynamicClient creates a dynamic client and discovery client for the given context
-func getDynamicClient(contextName string) (dynamic.Interface, *discovery.DiscoveryClient, error) {
-	kubeConfig := clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
-	config, err := clientcmd.LoadFromFile(kubeConfig)
+// getKubernetesClient creates a Kubernetes client for the given context
+func getKubernetesClient(kubeconfig, contextName string) (*kubernetes.Clientset, error) {
+	restConfig, err := getRESTConfig(kubeconfig, contextName)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	clientConfig := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{
-		CurrentContext: contextName,
-	})
+	return kubernetes.NewForConfig(restConfig)
+}
 
-	restConfig, err := clientConfig.ClientConfig()
+// getDynamicClient creates a dynamic client and discovery client for the given context
+func getDynamicClient(kubeconfig, contextName string) (dynamic.Interface, *discovery.DiscoveryClient, error) {
+	restConfig, err := getRESTConfig(kubeconfig, contextName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -68,9 +93,8 @@ func getDynamicClient(contextName string) (dynamic.Interface, *discovery.Discove
 }
 
 // getAvailableContexts returns all available kubectl contexts
-func getAvailableContexts() ([]string, error) {
-	kubeConfig := clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
-	config, err := clientcmd.LoadFromFile(kubeConfig)
+func getAvailableContexts(kubeconfig string) ([]string, error) {
+	config, err := loadKubeconfig(kubeconfig)
 	if err != nil {
 		return nil, err
 	}
@@ -84,8 +108,8 @@ func getAvailableContexts() ([]string, error) {
 }
 
 // getAvailableResourceTypes returns all available resource types for the given context
-func getAvailableResourceTypes(contextName string) ([]string, error) {
-	client, err := getKubernetesClient(contextName)
+func getAvailableResourceTypes(kubeconfig, contextName string) ([]string, error) {
+	client, err := getKubernetesClient(kubeconfig, contextName)
 	if err != nil {
 		return nil, err
 	}
@@ -112,24 +136,17 @@ func getAvailableResourceTypes(contextName string) ([]string, error) {
 	return resources, nil
 }
 
-// fetchClusterResourcesWithContext fetches resources from a cluster with the given context
-func fetchClusterResourcesWithContext(contextName string, namespaces []string, resources []string) ([]map[string]interface{}, error) {
-	// Add timeout context for operations
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
-
-	dynamicClient, discoveryClient, err := getDynamicClient(contextName)
-	if err != nil {
-		return nil, err
-	}
-
-	// Get all API resources
-	apiResourceLists, err := discoveryClient.ServerPreferredResources()
-	if err != nil {
-		return nil, err
-	}
+// selectedAPIResource is a resource type chosen for comparison, resolved through discovery
+type selectedAPIResource struct {
+	GVR        schema.GroupVersionResource
+	Kind       string
+	Namespaced bool
+}
 
-	var allItems []runtime.Object
+// resolveAPIResources maps the selected resource names to their preferred API versions.
+// A name served by several API groups (e.g. events) resolves to every group.
+func resolveAPIResources(apiResourceLists []*metav1.APIResourceList, resources []string) []selectedAPIResource {
+	var selected []selectedAPIResource
 
 	for _, apiResourceList := range apiResourceLists {
 		for _, apiResource := range apiResourceList.APIResources {
@@ -149,41 +166,222 @@ func fetchClusterResourcesWithContext(contextName string, namespaces []string, r
 				continue
 			}
 
-			gvr := schema.GroupVersionResource{
-				Group:    gv.Group,
-				Version:  gv.Version,
-				Resource: apiResource.Name,
+			selected = append(selected, selectedAPIResource{
+				GVR: schema.GroupVersionResource{
+					Group:    gv.Group,
+					Version:  gv.Version,
+					Resource: apiResource.Name,
+				},
+				Kind:       apiResource.Kind,
+				Namespaced: apiResource.Namespaced,
+			})
+		}
+	}
+
+	return selected
+}
+
+// scopesFor returns the resource/namespace combinations to fetch for a resource type.
+// Cluster-scoped resources are fetched once, with an empty namespace.
+func (r selectedAPIResource) scopesFor(namespaces []string) []ResourceScope {
+	scope := ResourceScope{Group: r.GVR.Group, Resource: r.GVR.Resource, Kind: r.Kind}
+	if !r.Namespaced {
+		return []ResourceScope{scope}
+	}
+
+	scopes := make([]ResourceScope, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		scope.Namespace = namespace
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// discoverAPIResources resolves the selected resource names for the given context
+func discoverAPIResources(kubeconfig, contextName string, resources []string) ([]selectedAPIResource, error) {
+	client, err := getKubernetesClient(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	apiResourceLists, err := client.Discovery().ServerPreferredResources()
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveAPIResources(apiResourceLists, resources), nil
+}
+
+// listPageSize is the number of resources requested per list call. Paginating keeps
+// individual responses small on large clusters.
+const listPageSize = 500
+
+// listPageTimeout bounds the listing of one page, including its retries and their backoff
+const listPageTimeout = 2 * time.Minute
+
+// fetchClusterResourcesWithContext fetches resources from a cluster with the given context.
+// Scopes listed in skipped (e.g. denied by the permission preflight) are not fetched; the
+// returned skipped list additionally contains any scope the API server refused to list or
+// that still failed after retrying transient errors.
+func fetchClusterResourcesWithContext(out io.Writer, kubeconfig, contextName string, namespaces []string, resources []string, skipped []SkippedScope) ([]map[string]interface{}, []SkippedScope, error) {
+	sink := &sliceSink{}
+
+	skipped, err := streamClusterResources(out, kubeconfig, contextName, namespaces, resources, skipped, sink)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sink.items, skipped, nil
+}
+
+// resourceSink receives the resources of a cluster scope by scope. The resources of a
+// scope are kept once it is committed; discard drops the ones received since the last
+// commit, so a scope that fails part-way leaves no half-listed resources behind.
+type resourceSink interface {
+	emit(object map[string]interface{}) error
+	discard() error
+	commit() error
+}
+
+// sliceSink keeps the resources in memory
+type sliceSink struct {
+	items     []map[string]interface{}
+	committed int
+}
+
+func (s *sliceSink) emit(object map[string]interface{}) error {
+	s.items = append(s.items, object)
+	return nil
+}
+
+func (s *sliceSink) discard() error {
+	clear(s.items[s.committed:])
+	s.items = s.items[:s.committed]
+	return nil
+}
+
+func (s *sliceSink) commit() error {
+	s.committed = len(s.items)
+	return nil
+}
+
+// streamClusterResources lists resources from a cluster page by page and passes each
+// resource to the sink as soon as its page arrives, so callers decide whether to keep
+// them. Each scope is committed once all its pages were listed, and discarded when one
+// fails. Skipped scopes are handled as by fetchClusterResourcesWithContext. An error
+// returned by the sink aborts the fetch.
+func streamClusterResources(out io.Writer, kubeconfig, contextName string, namespaces []string, resources []string, skipped []SkippedScope, sink resourceSink) ([]SkippedScope, error) {
+	ctx := context.Background()
+
+	dynamicClient, discoveryClient, err := getDynamicClient(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all API resources
+	apiResourceLists, err := discoveryClient.ServerPreferredResources()
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[ResourceScope]bool)
+	for _, scope := range skipped {
+		skip[scope.ResourceScope] = true
+	}
+
+	fetched := 0
+
+	for _, apiResource := range resolveAPIResources(apiResourceLists, resources) {
+		// Fetch resources from selected namespaces
+		for _, scope := range apiResource.scopesFor(namespaces) {
+			if skip[scope] {
+				continue
 			}
 
-			// Fetch resources from selected namespaces
-			for _, namespace := range namespaces {
-				var resourceInterface dynamic.ResourceInterface
-				if apiResource.Namespaced {
-					resourceInterface = dynamicClient.Resource(gvr).Namespace(namespace)
-				} else {
-					resourceInterface = dynamicClient.Resource(gvr)
-				}
+			var resourceInterface dynamic.ResourceInterface
+			if apiResource.Namespaced {
+				resourceInterface = dynamicClient.Resource(apiResource.GVR).Namespace(scope.Namespace)
+			} else {
+				resourceInterface = dynamicClient.Resource(apiResource.GVR)
+			}
 
-				resources, err := resourceInterface.List(ctx, metav1.ListOptions{})
-				if err != nil {
-					fmt.Printf("⚠️  Warning: Failed to fetch %s from namespace %s: %v\n", apiResource.Name, namespace, err)
-					continue
+			count, err := listAllPages(ctx, out, resourceInterface, scope, sink)
+			if err != nil {
+				var emitErr *emitError
+				if errors.As(err, &emitErr) {
+					return nil, emitErr.err
 				}
-
-				for _, item := range resources.Items {
-					allItems = append(allItems, &item)
+				if discardErr := sink.discard(); discardErr != nil {
+					return nil, discardErr
+				}
+				if apierrors.IsForbidden(err) {
+					fmt.Fprintf(out, "🚫 Not permitted to list %s; it will be marked as not compared\n", scope)
+					skipped = append(skipped, SkippedScope{ResourceScope: scope, Reason: skipReasonForbidden})
+					continue
 				}
+				fmt.Fprintf(out, "⚠️  Warning: Failed to fetch %s: %v; its resources will be marked as unknown\n", scope, err)
+				skipped = append(skipped, SkippedScope{ResourceScope: scope, Reason: skipReasonError, Error: err.Error()})
+				continue
+			}
+			if err := sink.commit(); err != nil {
+				return nil, err
 			}
+			fetched += count
 		}
 	}
 
-	fmt.Printf("✅ Fetched %d resources from %s\n", len(allItems), contextName)
+	fmt.Fprintf(out, "✅ Fetched %d resources from %s\n", fetched, contextName)
 
-	var result []map[string]interface{}
-	for _, item := range allItems {
-		unstructured := item.(*unstructured.Unstructured)
-		result = append(result, unstructured.Object)
-	}
+	return skipped, nil
+}
+
+// emitError wraps an error returned by the sink, to tell it apart from list errors
+type emitError struct {
+	err error
+}
 
-	return result, nil
+func (e *emitError) Error() string { return e.err.Error() }
+
+// listAllPages lists one scope page by page, retrying transient failures of each page,
+// and returns how many resources were passed to the sink. When the continue token of a
+// later page has expired (410 Gone), the resources passed so far are discarded and the
+// list restarts from the beginning, once.
+func listAllPages(ctx context.Context, out io.Writer, resourceInterface dynamic.ResourceInterface, scope ResourceScope, sink resourceSink) (int, error) {
+	count := 0
+	options := metav1.ListOptions{Limit: listPageSize}
+	restarted := false
+
+	for {
+		var page *unstructured.UnstructuredList
+		pageCtx, cancel := context.WithTimeout(ctx, listPageTimeout)
+		err := withRetry(pageCtx, out, defaultRetryPolicy, "Listing "+scope.String(), func() error {
+			var listErr error
+			page, listErr = resourceInterface.List(pageCtx, options)
+			return listErr
+		})
+		cancel()
+		if err != nil && options.Continue != "" && !restarted && (apierrors.IsResourceExpired(err) || apierrors.IsGone(err)) {
+			fmt.Fprintf(out, "🔁 The list of %s expired between pages; listing it again from the start\n", scope)
+			if err := sink.discard(); err != nil {
+				return 0, &emitError{err: err}
+			}
+			count, options.Continue, restarted = 0, "", true
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+
+		for i := range page.Items {
+			if err := sink.emit(page.Items[i].Object); err != nil {
+				return count, &emitError{err: err}
+			}
+			count++
+		}
+
+		if page.GetContinue() == "" {
+			return count, nil
+		}
+		options.Continue = page.GetContinue()
+	}
 }
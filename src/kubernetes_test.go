@@ -1,11 +1,191 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 )
 
+// pagedResourceInterface serves List calls from fixed pages, using the page index as
+// continue token, and records the options of every call
+type pagedResourceInterface struct {
+	dynamic.ResourceInterface
+	pages   [][]map[string]interface{}
+	failAt  map[int]error
+	options []metav1.ListOptions
+}
+
+func (p *pagedResourceInterface) List(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	p.options = append(p.options, options)
+
+	index := 0
+	if options.Continue != "" {
+		index, _ = strconv.Atoi(options.Continue)
+	}
+	if err, ok := p.failAt[index]; ok {
+		delete(p.failAt, index)
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	for _, object := range p.pages[index] {
+		list.Items = append(list.Items, unstructured.Unstructured{Object: object})
+	}
+	if index+1 < len(p.pages) {
+		list.SetContinue(strconv.Itoa(index + 1))
+	}
+	return list, nil
+}
+
+// failingSink is a resourceSink whose emit always fails
+type failingSink struct {
+	sliceSink
+	err error
+}
+
+func (s *failingSink) emit(map[string]interface{}) error { return s.err }
+
+// writeTestKubeconfig writes a minimal kubeconfig with one cluster/context per name
+func writeTestKubeconfig(dir, fileName string, contexts ...string) string {
+	content := "apiVersion: v1\nkind: Config\nclusters:\n"
+	for _, name := range contexts {
+		content += fmt.Sprintf("- name: %s\n  cluster:\n    server: https://%s.example.com\n", name, name)
+	}
+	content += "users:\n- name: test-user\n  user:\n    token: test-token\ncontexts:\n"
+	for _, name := range contexts {
+		content += fmt.Sprintf("- name: %s\n  context:\n    cluster: %s\n    user: test-user\n", name, name)
+	}
+
+	path := filepath.Join(dir, fileName)
+	Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+	return path
+}
+
 var _ = Describe("Kubernetes", func() {
+	Describe("kubeconfig loading", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "k8s-compare-kubeconfig")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		Context("when an explicit kubeconfig is given", func() {
+			It("should list only the contexts from that file", func() {
+				path := writeTestKubeconfig(tempDir, "customer.yaml", "customer-prod", "customer-staging")
+
+				contexts, err := getAvailableContexts(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contexts).To(Equal([]string{"customer-prod", "customer-staging"}))
+			})
+
+			It("should resolve the server for the selected context", func() {
+				path := writeTestKubeconfig(tempDir, "ours.yaml", "ours-a", "ours-b")
+
+				restConfig, err := getRESTConfig(path, "ours-b")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(restConfig.Host).To(Equal("https://ours-b.example.com"))
+			})
+
+			It("should return an error for a missing file", func() {
+				_, err := getAvailableContexts(filepath.Join(tempDir, "missing.yaml"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when a list of kubeconfig files is given", func() {
+			It("should merge contexts from every file", func() {
+				first := writeTestKubeconfig(tempDir, "first.yaml", "alpha")
+				second := writeTestKubeconfig(tempDir, "second.yaml", "beta")
+
+				contexts, err := getAvailableContexts(first + string(filepath.ListSeparator) + second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contexts).To(Equal([]string{"alpha", "beta"}))
+			})
+		})
+
+		Context("when no kubeconfig is given", func() {
+			It("should honour a multi-file KUBECONFIG environment variable", func() {
+				first := writeTestKubeconfig(tempDir, "first.yaml", "alpha")
+				second := writeTestKubeconfig(tempDir, "second.yaml", "beta")
+
+				original, wasSet := os.LookupEnv("KUBECONFIG")
+				defer func() {
+					if wasSet {
+						os.Setenv("KUBECONFIG", original)
+					} else {
+						os.Unsetenv("KUBECONFIG")
+					}
+				}()
+				os.Setenv("KUBECONFIG", first+string(filepath.ListSeparator)+second)
+
+				contexts, err := getAvailableContexts("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contexts).To(Equal([]string{"alpha", "beta"}))
+			})
+		})
+	})
+
+	Describe("resolveAPIResources function", func() {
+		apiResourceLists := []*metav1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "pods", Kind: "Pod", Namespaced: true},
+					{Name: "pods/log", Kind: "Pod", Namespaced: true},
+					{Name: "nodes", Kind: "Node", Namespaced: false},
+					{Name: "events", Kind: "Event", Namespaced: true},
+				},
+			},
+			{
+				GroupVersion: "events.k8s.io/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "events", Kind: "Event", Namespaced: true},
+				},
+			},
+		}
+
+		It("should resolve selected resources to their group versions", func() {
+			selected := resolveAPIResources(apiResourceLists, []string{"pods", "nodes"})
+			Expect(selected).To(HaveLen(2))
+			Expect(selected[0].GVR).To(Equal(schema.GroupVersionResource{Version: "v1", Resource: "pods"}))
+			Expect(selected[0].Kind).To(Equal("Pod"))
+			Expect(selected[0].Namespaced).To(BeTrue())
+			Expect(selected[1].Namespaced).To(BeFalse())
+		})
+
+		It("should resolve a name served by several groups to each group", func() {
+			selected := resolveAPIResources(apiResourceLists, []string{"events"})
+			Expect(selected).To(HaveLen(2))
+			Expect(selected[1].GVR.Group).To(Equal("events.k8s.io"))
+		})
+
+		It("should fetch cluster-scoped resources once", func() {
+			selected := resolveAPIResources(apiResourceLists, []string{"pods", "nodes"})
+			Expect(selected[0].scopesFor([]string{"default", "kube-system"})).To(HaveLen(2))
+
+			nodeScopes := selected[1].scopesFor([]string{"default", "kube-system"})
+			Expect(nodeScopes).To(Equal([]ResourceScope{{Resource: "nodes", Kind: "Node"}}))
+		})
+	})
+
 	Describe("createKubernetesClient function", func() {
 		Context("when creating Kubernetes client", func() {
 			// Note: These functions interact with kubeconfig and would typically
@@ -357,4 +537,101 @@ var _ = Describe("Kubernetes", func() {
 			})
 		})
 	})
+
+	Describe("listAllPages function", func() {
+		var scope ResourceScope
+		var originalSleep func(context.Context, time.Duration) error
+
+		BeforeEach(func() {
+			scope = ResourceScope{Resource: "configmaps", Kind: "ConfigMap", Namespace: "default"}
+			originalSleep = sleepContext
+			sleepContext = func(ctx context.Context, delay time.Duration) error { return nil }
+		})
+
+		AfterEach(func() {
+			sleepContext = originalSleep
+		})
+
+		It("should follow continue tokens and emit every resource", func() {
+			resources := &pagedResourceInterface{pages: [][]map[string]interface{}{
+				{testResource("ConfigMap", "default", "a", nil), testResource("ConfigMap", "default", "b", nil)},
+				{testResource("ConfigMap", "default", "c", nil)},
+			}}
+
+			sink := &sliceSink{}
+			count, err := listAllPages(context.Background(), GinkgoWriter, resources, scope, sink)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(3))
+			var names []string
+			for _, object := range sink.items {
+				names = append(names, resourceIdentity(object).Name)
+			}
+			Expect(names).To(Equal([]string{"a", "b", "c"}))
+			Expect(resources.options).To(HaveLen(2))
+			Expect(resources.options[0].Limit).To(Equal(int64(listPageSize)))
+			Expect(resources.options[1].Continue).To(Equal("1"))
+		})
+
+		It("should retry a page that fails transiently", func() {
+			resources := &pagedResourceInterface{
+				pages: [][]map[string]interface{}{
+					{testResource("ConfigMap", "default", "a", nil)},
+					{testResource("ConfigMap", "default", "b", nil)},
+				},
+				failAt: map[int]error{1: apierrors.NewServiceUnavailable("restarting")},
+			}
+
+			count, err := listAllPages(context.Background(), GinkgoWriter, resources, scope, &sliceSink{})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(2))
+			Expect(resources.options).To(HaveLen(3))
+		})
+
+		It("should list again from the start when the continue token expired", func() {
+			resources := &pagedResourceInterface{
+				pages: [][]map[string]interface{}{
+					{testResource("ConfigMap", "default", "a", nil)},
+					{testResource("ConfigMap", "default", "b", nil)},
+				},
+				failAt: map[int]error{1: apierrors.NewResourceExpired("the provided continue parameter is too old")},
+			}
+
+			sink := &sliceSink{}
+			count, err := listAllPages(context.Background(), GinkgoWriter, resources, scope, sink)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(2))
+			Expect(sink.items).To(HaveLen(2))
+			Expect(resources.options).To(HaveLen(4))
+			Expect(resources.options[2].Continue).To(BeEmpty())
+		})
+
+		It("should stop when emit fails", func() {
+			resources := &pagedResourceInterface{pages: [][]map[string]interface{}{
+				{testResource("ConfigMap", "default", "a", nil), testResource("ConfigMap", "default", "b", nil)},
+			}}
+			diskFull := errors.New("no space left on device")
+
+			count, err := listAllPages(context.Background(), GinkgoWriter, resources, scope, &failingSink{err: diskFull})
+
+			var emitErr *emitError
+			Expect(errors.As(err, &emitErr)).To(BeTrue())
+			Expect(emitErr.err).To(Equal(diskFull))
+			Expect(count).To(Equal(0))
+		})
+	})
+
+	Describe("sliceSink type", func() {
+		It("should drop the resources received since the last commit", func() {
+			sink := &sliceSink{}
+			Expect(sink.emit(testResource("ConfigMap", "default", "a", nil))).To(Succeed())
+			Expect(sink.commit()).To(Succeed())
+			Expect(sink.emit(testResource("ConfigMap", "other", "b", nil))).To(Succeed())
+			Expect(sink.discard()).To(Succeed())
+
+			Expect(sink.items).To(Equal([]map[string]interface{}{testResource("ConfigMap", "default", "a", nil)}))
+		})
+	})
 })
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+// Kinds of lines in a line diff
+const (
+	lineEqual   = ' '
+	lineRemoved = '-'
+	lineAdded   = '+'
+)
+
+// diffLine is a single line of a line diff
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+// diffHunk is a group of changed lines with their surrounding context, as in `diff -u`
+type diffHunk struct {
+	StartA, CountA int
+	StartB, CountB int
+	Lines          []diffLine
+}
+
+// Header returns the @@ header line of the hunk
+func (h diffHunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.StartA, h.CountA), hunkRange(h.StartB, h.CountB))
+}
+
+// hunkRange formats a hunk range the way diff -u does: the count is omitted when it is 1,
+// and an empty range points at the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
+
+// splitLines splits text into lines, ignoring a trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes a minimal line diff between a and b with Myers' algorithm, in its
+// linear-space variant: memory grows with the number of lines rather than their product,
+// so large resources can be diffed too
+func diffLines(a, b []string) []diffLine {
+	return appendLineDiff(nil, a, b)
+}
+
+// appendLineDiff appends the line diff of a and b to lines, splitting the problem at the
+// middle snake of the shortest edit path
+func appendLineDiff(lines []diffLine, a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{Op: lineEqual, Text: line})
+	}
+
+	middleA := a[prefix : len(a)-suffix]
+	middleB := b[prefix : len(b)-suffix]
+	switch {
+	case len(middleA) == 0:
+		for _, line := range middleB {
+			lines = append(lines, diffLine{Op: lineAdded, Text: line})
+		}
+	case len(middleB) == 0:
+		for _, line := range middleA {
+			lines = append(lines, diffLine{Op: lineRemoved, Text: line})
+		}
+	default:
+		x, y, u, v := middleSnake(middleA, middleB)
+		lines = appendLineDiff(lines, middleA[:x], middleB[:y])
+		for _, line := range middleA[x:u] {
+			lines = append(lines, diffLine{Op: lineEqual, Text: line})
+		}
+		lines = appendLineDiff(lines, middleA[u:], middleB[v:])
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{Op: lineEqual, Text: line})
+	}
+	return lines
+}
+
+// middleSnake finds the snake in the middle of a shortest edit path from a to b, running
+// the search from both ends until the paths overlap. The snake takes a[x:u] to b[y:v]; the
+// edits before and after it can be found independently. a and b must not be empty.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+
+	// forward[offset+k] is the furthest x reached on diagonal k = x-y from the start;
+	// backward[offset+k] the furthest distance from the end on diagonal k of the reversed input
+	offset := maxD + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var endX int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				endX = forward[offset+k+1]
+			} else {
+				endX = forward[offset+k-1] + 1
+			}
+			startX, startY := endX, endX-k
+			endY := startY
+			for endX < n && endY < m && a[endX] == b[endY] {
+				endX++
+				endY++
+			}
+			forward[offset+k] = endX
+
+			if reverse := delta - k; odd && reverse >= -(d-1) && reverse <= d-1 && endX+backward[offset+reverse] >= n {
+				return startX, startY, endX, endY
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var endX int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				endX = backward[offset+k+1]
+			} else {
+				endX = backward[offset+k-1] + 1
+			}
+			startX, startY := endX, endX-k
+			endY := startY
+			for endX < n && endY < m && a[n-1-endX] == b[m-1-endY] {
+				endX++
+				endY++
+			}
+			backward[offset+k] = endX
+
+			if straight := delta - k; !odd && straight >= -d && straight <= d && endX+forward[offset+straight] >= n {
+				return n - endX, m - endY, n - startX, m - startY
+			}
+		}
+	}
+
+	// Unreachable: the paths always overlap by the time d reaches maxD
+	return 0, 0, 0, 0
+}
+
+// unifiedHunks groups a line diff into hunks with the given number of context lines
+func unifiedHunks(lines []diffLine, context int) []diffHunk {
+	var hunks []diffHunk
+	var current *diffHunk
+
+	lineA, lineB := 1, 1
+	for index, line := range lines {
+		if line.Op == lineEqual {
+			// Keep an equal line only when it is within the context of a change
+			nearChange := false
+			for k := max(0, index-context); k <= min(len(lines)-1, index+context); k++ {
+				if lines[k].Op != lineEqual {
+					nearChange = true
+					break
+				}
+			}
+			if !nearChange {
+				if current != nil {
+					hunks = append(hunks, *current)
+					current = nil
+				}
+				lineA++
+				lineB++
+				continue
+			}
+		}
+
+		if current == nil {
+			current = &diffHunk{StartA: lineA, StartB: lineB}
+		}
+		current.Lines = append(current.Lines, line)
+
+		switch line.Op {
+		case lineEqual:
+			current.CountA++
+			current.CountB++
+			lineA++
+			lineB++
+		case lineRemoved:
+			current.CountA++
+			lineA++
+		case lineAdded:
+			current.CountB++
+			lineB++
+		}
+	}
+
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+
+	return hunks
+}
+
+// resourceDiffHunks renders both sides of a compared resource as YAML, without the
+// ignored fields, and returns the unified diff hunks between them
+func resourceDiffHunks(resource ResourceComparison) ([]diffHunk, error) {
+	yamlA, err := yaml.Marshal(normalizeObject(resource.ObjectA))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s from Cluster A as YAML: %w", resource, err)
+	}
+	yamlB, err := yaml.Marshal(normalizeObject(resource.ObjectB))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s from Cluster B as YAML: %w", resource, err)
+	}
+
+	return yamlDiffHunks(string(yamlA), string(yamlB)), nil
+}
+
+// yamlDiffHunks returns the unified diff hunks between two YAML documents
+func yamlDiffHunks(before, after string) []diffHunk {
+	return unifiedHunks(diffLines(splitLines(before), splitLines(after)), diffContextLines)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Line diff", func() {
+	Describe("diffLines function", func() {
+		It("should mark equal, removed and added lines", func() {
+			lines := diffLines([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+
+			Expect(lines).To(Equal([]diffLine{
+				{Op: lineEqual, Text: "a"},
+				{Op: lineRemoved, Text: "b"},
+				{Op: lineAdded, Text: "x"},
+				{Op: lineEqual, Text: "c"},
+				{Op: lineAdded, Text: "d"},
+			}))
+		})
+
+		It("should return minimal diffs that rebuild both inputs", func() {
+			random := rand.New(rand.NewSource(1))
+			randomLines := func() []string {
+				lines := make([]string, random.Intn(12))
+				for i := range lines {
+					lines[i] = string(rune('a' + random.Intn(4)))
+				}
+				return lines
+			}
+
+			for i := 0; i < 500; i++ {
+				a, b := randomLines(), randomLines()
+
+				rebuiltA, rebuiltB := []string{}, []string{}
+				equal := 0
+				for _, line := range diffLines(a, b) {
+					if line.Op != lineAdded {
+						rebuiltA = append(rebuiltA, line.Text)
+					}
+					if line.Op != lineRemoved {
+						rebuiltB = append(rebuiltB, line.Text)
+					}
+					if line.Op == lineEqual {
+						equal++
+					}
+				}
+				Expect(rebuiltA).To(Equal(a))
+				Expect(rebuiltB).To(Equal(b))
+				Expect(equal).To(Equal(longestCommonSubsequence(a, b)), "diff of %v and %v", a, b)
+			}
+		})
+
+		It("should diff large inputs", func() {
+			// A table of every pair of lines would take gigabytes here
+			a := make([]string, 20000)
+			for i := range a {
+				a[i] = fmt.Sprintf("line %d", i)
+			}
+			b := append([]string{}, a...)
+			for i := 0; i < len(b); i += 1000 {
+				b[i] = "changed"
+			}
+
+			counts := make(map[byte]int)
+			for _, line := range diffLines(a, b) {
+				counts[line.Op]++
+			}
+
+			Expect(counts).To(Equal(map[byte]int{lineEqual: 19980, lineRemoved: 20, lineAdded: 20}))
+		})
+
+		It("should handle empty inputs", func() {
+			Expect(diffLines(nil, nil)).To(BeEmpty())
+			Expect(diffLines(nil, []string{"a"})).To(Equal([]diffLine{{Op: lineAdded, Text: "a"}}))
+			Expect(diffLines([]string{"a"}, nil)).To(Equal([]diffLine{{Op: lineRemoved, Text: "a"}}))
+		})
+	})
+
+	Describe("splitLines function", func() {
+		It("should ignore the trailing newline", func() {
+			Expect(splitLines("a\nb\n")).To(Equal([]string{"a", "b"}))
+			Expect(splitLines("")).To(BeEmpty())
+		})
+	})
+
+	Describe("unifiedHunks function", func() {
+		var lines []string
+
+		BeforeEach(func() {
+			lines = nil
+			for i := 1; i <= 20; i++ {
+				lines = append(lines, fmt.Sprintf("line %d", i))
+			}
+		})
+
+		It("should keep the context around a change", func() {
+			changed := append([]string{}, lines...)
+			changed[9] = "changed"
+
+			hunks := unifiedHunks(diffLines(lines, changed), 3)
+
+			Expect(hunks).To(HaveLen(1))
+			Expect(hunks[0].Header()).To(Equal("@@ -7,7 +7,7 @@"))
+			Expect(hunks[0].Lines).To(HaveLen(8))
+			Expect(hunks[0].Lines[0].Text).To(Equal("line 7"))
+			Expect(hunks[0].Lines[7].Text).To(Equal("line 13"))
+		})
+
+		It("should split distant changes into separate hunks", func() {
+			changed := append([]string{}, lines...)
+			changed[1] = "first"
+			changed[18] = "second"
+
+			hunks := unifiedHunks(diffLines(lines, changed), 3)
+
+			Expect(hunks).To(HaveLen(2))
+			Expect(hunks[0].Header()).To(Equal("@@ -1,5 +1,5 @@"))
+			Expect(hunks[1].Header()).To(Equal("@@ -16,5 +16,5 @@"))
+		})
+
+		It("should merge changes whose context overlaps", func() {
+			changed := append([]string{}, lines...)
+			changed[5] = "first"
+			changed[10] = "second"
+
+			Expect(unifiedHunks(diffLines(lines, changed), 3)).To(HaveLen(1))
+		})
+
+		It("should return no hunks for identical input", func() {
+			Expect(unifiedHunks(diffLines(lines, lines), 3)).To(BeEmpty())
+		})
+
+		It("should format empty ranges like diff -u", func() {
+			hunks := unifiedHunks(diffLines(nil, []string{"a"}), 3)
+
+			Expect(hunks).To(HaveLen(1))
+			Expect(hunks[0].Header()).To(Equal("@@ -0,0 +1 @@"))
+		})
+	})
+})
+
+// longestCommonSubsequence returns the length of the longest common subsequence of a and b
+func longestCommonSubsequence(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	return lengths[0][0]
+}
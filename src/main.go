@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
@@ -22,6 +23,25 @@ func main() {
 	rootCmd.Flags().StringP("output-dir", "o", "reports", "Output directory for generated JSON files")
 	rootCmd.Flags().BoolP("interactive", "i", true, "Run in interactive mode")
 	rootCmd.Flags().BoolP("compare-namespaces", "c", true, "Compare namespaces")
+	rootCmd.Flags().String("kubeconfig", "", "Path to the kubeconfig file(s) for both clusters (defaults to $KUBECONFIG or ~/.kube/config)")
+	rootCmd.Flags().String("kubeconfig-a", "", "Path to the kubeconfig file(s) for Cluster A (overrides --kubeconfig)")
+	rootCmd.Flags().String("kubeconfig-b", "", "Path to the kubeconfig file(s) for Cluster B (overrides --kubeconfig)")
+	rootCmd.Flags().String("gcloud-auth", string(gcloudAuthBrowser), "How to refresh expired Google Cloud credentials: browser, no-browser, adc, service-account or none")
+	rootCmd.Flags().String("gcloud-key-file", "", "Service account key file used to authenticate gcloud (implies --gcloud-auth=service-account)")
+	rootCmd.Flags().String("format", formatHTML, "Report format: html (browser report) or text (summary and colored diff in the terminal)")
+	rootCmd.Flags().Bool("no-color", false, "Disable colors in the text report (also disabled by NO_COLOR or when stdout is not a terminal)")
+	rootCmd.Flags().Bool("no-pager", false, "Print the text report directly instead of through $PAGER")
+	rootCmd.Flags().String("export-yaml", "", "Also export each normalized resource as YAML to <dir>/cluster-a|cluster-b/<namespace>/<group.kind>/<name>.yaml")
+	rootCmd.Flags().Bool("stream", false, "Stream resources to NDJSON snapshots and compare them on disk with bounded memory (for very large clusters)")
+	rootCmd.Flags().Bool("gzip", false, "Compress the NDJSON snapshots and diff written by --stream")
+	rootCmd.Flags().String("junit", "", "Also write the comparison as JUnit XML to this path, one test case per resource")
+	rootCmd.Flags().String("remediation-dir", "", "Also write the manifests or patches that would make Cluster B match Cluster A to this directory, with an apply.sh to review and run")
+	rootCmd.Flags().String("remediation-format", remediationApply, "Format of the remediation of differing resources: apply (full manifests for server-side apply) or merge-patch (JSON merge patches)")
+	rootCmd.Flags().Bool("expand-owned", false, "Compare controller-generated resources (ReplicaSets, Pods, ...) one by one instead of counting them under their owner")
+	rootCmd.Flags().Bool("rbac", false, "Also compare the effective RBAC permissions of every user, group and service account (adds roles, rolebindings, clusterroles and clusterrolebindings to the selected resource types)")
+
+	rootCmd.AddCommand(newServeCommand())
+	rootCmd.AddCommand(newSyncCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -44,32 +64,139 @@ func runComparison(cmd *cobra.Command, args []string) {
 		log.Fatalf("Failed to get compare namespaces flag: %v", err)
 	}
 
-	// Setup and run the comparison
-	config, err := setupComparison(outputDir, compareNamespaces)
+	kubeconfigA, kubeconfigB, err := getKubeconfigFlags(cmd)
 	if err != nil {
+		log.Fatalf("Failed to get kubeconfig flags: %v", err)
+	}
+
+	gcloudAuthMode, _ := cmd.Flags().GetString("gcloud-auth")
+	gcloudKeyFile, _ := cmd.Flags().GetString("gcloud-key-file")
+	gcloudAuth, err = parseGCloudAuthOptions(gcloudAuthMode, gcloudKeyFile)
+	if err != nil {
+		log.Fatalf("Invalid Google Cloud auth options: %v", err)
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	if format != formatHTML && format != formatText {
+		log.Fatalf("Invalid format %q: must be %s or %s", format, formatHTML, formatText)
+	}
+
+	exportDir, _ := cmd.Flags().GetString("export-yaml")
+	junitPath, _ := cmd.Flags().GetString("junit")
+	remediationDir, _ := cmd.Flags().GetString("remediation-dir")
+	remediationFormat, _ := cmd.Flags().GetString("remediation-format")
+	if remediationFormat != remediationApply && remediationFormat != remediationMergePatch {
+		log.Fatalf("Invalid remediation format %q: must be %s or %s", remediationFormat, remediationApply, remediationMergePatch)
+	}
+	analyzeRBAC, _ := cmd.Flags().GetBool("rbac")
+	expandOwned, _ := cmd.Flags().GetBool("expand-owned")
+	stream, _ := cmd.Flags().GetBool("stream")
+	compress, _ := cmd.Flags().GetBool("gzip")
+	if stream && (format != formatHTML || exportDir != "" || junitPath != "" || remediationDir != "" || analyzeRBAC) {
+		log.Fatalf("--stream only writes NDJSON snapshots and diffs; it cannot be combined with --format text, --export-yaml, --junit, --remediation-dir or --rbac")
+	}
+	if compress && !stream {
+		log.Fatalf("--gzip requires --stream")
+	}
+
+	config := &ComparisonConfig{
+		ClusterA:          ClusterConfig{Kubeconfig: kubeconfigA},
+		ClusterB:          ClusterConfig{Kubeconfig: kubeconfigB},
+		OutputDir:         outputDir,
+		CompareNamespaces: compareNamespaces,
+		AnalyzeRBAC:       analyzeRBAC,
+		ExpandOwned:       expandOwned,
+	}
+
+	// Setup and run the comparison
+	if err := setupComparison(config); err != nil {
 		log.Fatalf("Setup failed: %v", err)
 	}
+	if analyzeRBAC {
+		config.ClusterA.Resources = withRBACResources(config.ClusterA.Resources)
+		config.ClusterB.Resources = withRBACResources(config.ClusterB.Resources)
+	}
+
+	if stream {
+		if err := runStreamingComparison(config, compress); err != nil {
+			log.Fatalf("Streaming comparison failed: %v", err)
+		}
+
+		extension := snapshotExtension(compress)
+		fmt.Println("\n🎉 Comparison completed successfully!")
+		fmt.Println("📄 Generated files:")
+		fmt.Printf("   - %s/cluster-a-%s%s\n", config.OutputDir, config.ReportTimestamp, extension)
+		fmt.Printf("   - %s/cluster-b-%s%s\n", config.OutputDir, config.ReportTimestamp, extension)
+		fmt.Printf("   - %s/diff-%s%s\n", config.OutputDir, config.ReportTimestamp, extension)
+		return
+	}
 
 	// Fetch resources from both clusters
 	if err := fetchResources(config); err != nil {
 		log.Fatalf("Failed to fetch resources: %v", err)
 	}
 
+	// Compare once and hand the result to every report
+	comparison := compareClusters(config)
+
 	// Generate output files
-	if err := generateOutputFiles(config); err != nil {
+	if err := generateOutputFiles(config, comparison); err != nil {
 		log.Fatalf("Failed to generate output files: %v", err)
 	}
 
+	if exportDir != "" {
+		if err := exportYAMLTree(exportDir, config); err != nil {
+			log.Fatalf("Failed to export YAML resources: %v", err)
+		}
+	}
+
+	if junitPath != "" {
+		if err := generateJUnitReport(junitPath, config, comparison); err != nil {
+			log.Fatalf("Failed to generate JUnit report: %v", err)
+		}
+	}
+
+	if remediationDir != "" {
+		if _, err := writeRemediation(remediationDir, remediationFormat, config, comparison); err != nil {
+			log.Fatalf("Failed to write remediation: %v", err)
+		}
+	}
+
+	if format == formatText {
+		noColor, _ := cmd.Flags().GetBool("no-color")
+		noPager, _ := cmd.Flags().GetBool("no-pager")
+
+		var report strings.Builder
+		if err := renderTextReport(&report, config, comparison, shouldUseColor(noColor)); err != nil {
+			log.Fatalf("Failed to render text report: %v", err)
+		}
+		fmt.Println()
+		if err := pageOutput(report.String(), !noPager); err != nil {
+			log.Fatalf("Failed to display text report: %v", err)
+		}
+	}
+
 	fmt.Println("\n🎉 Comparison completed successfully!")
 	fmt.Println("📄 Generated files:")
 	fmt.Printf("   - %s/cluster-a-%s.json\n", config.OutputDir, config.ReportTimestamp)
 	fmt.Printf("   - %s/cluster-b-%s.json\n", config.OutputDir, config.ReportTimestamp)
+	fmt.Printf("   - %s/diff-%s.json\n", config.OutputDir, config.ReportTimestamp)
 	fmt.Printf("   - %s/k8s-comparison-report_%s.html\n", config.OutputDir, config.ReportTimestamp)
+	fmt.Printf("   - %s/k8s-comparison-report_%s.md\n", config.OutputDir, config.ReportTimestamp)
+	if junitPath != "" {
+		fmt.Printf("   - %s\n", junitPath)
+	}
+	if exportDir != "" {
+		fmt.Printf("   - %s/cluster-a/ and %s/cluster-b/\n", exportDir, exportDir)
+	}
+	if remediationDir != "" {
+		fmt.Printf("   - %s/%s\n", remediationDir, remediationScript)
+	}
 	fmt.Println("💡 Open the HTML report in your browser to view the comparison")
 	fmt.Printf("   👉 Example: open %s/k8s-comparison-report_%s.html\n", config.OutputDir, config.ReportTimestamp)
 
 	interactive, _ := cmd.Flags().GetBool("interactive")
-	if interactive {
+	if interactive && format == formatHTML {
 		reportFile := fmt.Sprintf("%s/k8s-comparison-report_%s.html", config.OutputDir, config.ReportTimestamp)
 		var openNow bool
 		huh.NewForm(
@@ -98,6 +225,33 @@ func runComparison(cmd *cobra.Command, args []string) {
 	}
 }
 
+// getKubeconfigFlags resolves the kubeconfig selection for each cluster, letting
+// --kubeconfig-a/--kubeconfig-b override the shared --kubeconfig flag
+func getKubeconfigFlags(cmd *cobra.Command) (string, string, error) {
+	kubeconfig, err := cmd.Flags().GetString("kubeconfig")
+	if err != nil {
+		return "", "", err
+	}
+
+	kubeconfigA, err := cmd.Flags().GetString("kubeconfig-a")
+	if err != nil {
+		return "", "", err
+	}
+	if kubeconfigA == "" {
+		kubeconfigA = kubeconfig
+	}
+
+	kubeconfigB, err := cmd.Flags().GetString("kubeconfig-b")
+	if err != nil {
+		return "", "", err
+	}
+	if kubeconfigB == "" {
+		kubeconfigB = kubeconfig
+	}
+
+	return kubeconfigA, kubeconfigB, nil
+}
+
 func checkedAttr(val bool) string {
 	if val {
 		return " checked"
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// Limits that keep the Markdown report within typical pull request comment sizes
+// (GitHub rejects comments longer than 65536 characters)
+const (
+	// markdownMaxLength is the size budget of the whole report
+	markdownMaxLength = 60000
+	// markdownMaxDiffLines is the number of diff lines shown per resource
+	markdownMaxDiffLines = 60
+	// markdownMaxListedResources is the number of resources listed per missing/skipped section
+	markdownMaxListedResources = 50
+	// markdownMaxHelmChanges is the number of differences shown per Helm release
+	markdownMaxHelmChanges = 10
+	// markdownMaxRBACChanges is the number of permission changes shown per RBAC subject
+	markdownMaxRBACChanges = 10
+	// markdownMaxTableRows is the number of rows shown per table
+	markdownMaxTableRows = 50
+	// markdownMaxSectionLength is the share of the budget each table section may use, so the
+	// summary, generated resource, Helm, image, digest drift and RBAC tables together leave
+	// room for the diffs
+	markdownMaxSectionLength = markdownMaxLength / 10
+)
+
+// renderMarkdownReport renders the comparison as Markdown for pull request comments: a
+// summary table per kind, a collapsible diff per differing resource and lists of
+// resources missing from one cluster. Each table shows at most markdownMaxTableRows rows
+// within its markdownMaxSectionLength share of the size budget, and the diffs and lists
+// that would exceed the rest of the budget are left out; whatever is cut is counted in a
+// note.
+func renderMarkdownReport(config *ComparisonConfig, comparison *Comparison) (string, error) {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "## Cluster comparison: `%s` vs `%s`\n\n", config.ClusterA.Context, config.ClusterB.Context)
+	writeMarkdownSummary(&builder, comparison)
+	writeMarkdownOwnedDifferences(&builder, comparison.OwnedDifferences())
+	writeMarkdownHelmReleases(&builder, comparison.HelmReleases)
+	writeMarkdownImages(&builder, comparison.Images)
+	writeMarkdownRBAC(&builder, comparison.RBAC)
+
+	counts := comparison.StatusCounts()
+	if counts[statusDifferent]+counts[statusOnlyInA]+counts[statusOnlyInB] == 0 {
+		builder.WriteString("\n✅ No differences found\n")
+	}
+
+	// Closing notes are reserved up front so they always fit in the budget
+	const truncationNoteLength = 200
+	omitted := 0
+
+	if counts[statusDifferent] > 0 {
+		builder.WriteString("\n### Differences\n\n")
+		for _, kind := range comparison.Kinds {
+			for _, resource := range kind.Resources {
+				if resource.Status != statusDifferent {
+					continue
+				}
+				// Once a diff is left out, later ones are too, so the report keeps its order
+				if omitted > 0 {
+					omitted++
+					continue
+				}
+
+				section, err := markdownResourceDiff(resource)
+				if err != nil {
+					return "", err
+				}
+				if builder.Len()+len(section) > markdownMaxLength-truncationNoteLength {
+					omitted++
+					continue
+				}
+				builder.WriteString(section)
+			}
+		}
+	}
+
+	for _, section := range []struct {
+		title  string
+		status string
+	}{
+		{"Only in Cluster A", statusOnlyInA},
+		{"Only in Cluster B", statusOnlyInB},
+		{"Not Compared", statusNotCompared},
+		{"Unknown (Fetch Failed)", statusUnknown},
+	} {
+		list := markdownResourceList(section.title, section.status, comparison)
+		if list == "" {
+			continue
+		}
+		if builder.Len()+len(list) > markdownMaxLength-truncationNoteLength {
+			omitted += counts[section.status]
+			continue
+		}
+		builder.WriteString(list)
+	}
+
+	writeMarkdownTruncationNote(&builder, omitted, "resources")
+
+	return builder.String(), nil
+}
+
+// fitMarkdownRows returns the leading table rows that fit in markdownMaxTableRows and in
+// the section share of the size budget, after reserving the given length for the rest of
+// the section, along with the number of rows left out
+func fitMarkdownRows(rows []string, reserved int) ([]string, int) {
+	length := reserved
+	for i, row := range rows {
+		length += len(row)
+		if i == markdownMaxTableRows || length > markdownMaxSectionLength {
+			return rows[:i], len(rows) - i
+		}
+	}
+	return rows, 0
+}
+
+// writeMarkdownTruncationNote notes how many entries were left out to fit the size budget
+func writeMarkdownTruncationNote(builder *strings.Builder, omitted int, entries string) {
+	if omitted > 0 {
+		fmt.Fprintf(builder, "\n> ⚠️ %d more %s were left out to fit the comment size limit; see the HTML report for the full comparison.\n", omitted, entries)
+	}
+}
+
+// writeMarkdownSummary writes the per-kind status counts as a Markdown table
+func writeMarkdownSummary(builder *strings.Builder, comparison *Comparison) {
+	const header = "| Kind | In A | In B | Only in A | Only in B | Different | Identical | Not Compared | Unknown |\n" +
+		"|------|-----:|-----:|----------:|----------:|----------:|----------:|-------------:|--------:|\n"
+
+	row := func(kind string, countA, countB int, counts map[string]int) string {
+		return fmt.Sprintf("| %s | %d | %d | %d | %d | %d | %d | %d | %d |\n", kind, countA, countB,
+			counts[statusOnlyInA], counts[statusOnlyInB], counts[statusDifferent],
+			counts[statusIdentical], counts[statusNotCompared], counts[statusUnknown])
+	}
+
+	var rows []string
+	for _, kind := range comparison.Kinds {
+		rows = append(rows, row(escapeMarkdownTableCell(kind.Kind), kind.CountA, kind.CountB, kind.StatusCounts()))
+	}
+	total := row("**Total**", comparison.TotalA, comparison.TotalB, comparison.StatusCounts())
+
+	rows, omitted := fitMarkdownRows(rows, len(header)+len(total))
+	builder.WriteString(header)
+	builder.WriteString(strings.Join(rows, ""))
+	builder.WriteString(total)
+	writeMarkdownTruncationNote(builder, omitted, "kinds")
+}
+
+// writeMarkdownOwnedDifferences writes the resources whose controller-generated resources
+// differ in number or readiness as a table
+func writeMarkdownOwnedDifferences(builder *strings.Builder, owners []ResourceComparison) {
+	if len(owners) == 0 {
+		return
+	}
+
+	const header = "\n### Generated resources\n\n" +
+		"| Owner | Generated resources |\n" +
+		"|-------|---------------------|\n"
+
+	rows := make([]string, len(owners))
+	for i, owner := range owners {
+		summaries := make([]string, len(owner.Owned))
+		for j, summary := range owner.Owned {
+			summaries[j] = summary.String()
+		}
+		rows[i] = fmt.Sprintf("| %s | %s |\n", escapeMarkdownTableCell(owner.String()), escapeMarkdownTableCell(strings.Join(summaries, "<br>")))
+	}
+
+	rows, omitted := fitMarkdownRows(rows, len(header))
+	builder.WriteString(header)
+	builder.WriteString(strings.Join(rows, ""))
+	writeMarkdownTruncationNote(builder, omitted, "owners")
+}
+
+// writeMarkdownHelmReleases writes the Helm releases that are missing from a cluster or
+// differ as a table, with up to markdownMaxHelmChanges differences per release
+func writeMarkdownHelmReleases(builder *strings.Builder, releases []HelmReleaseComparison) {
+	if len(releases) == 0 {
+		return
+	}
+
+	builder.WriteString("\n### Helm Releases\n\n")
+
+	var changed []HelmReleaseComparison
+	for _, release := range releases {
+		if release.Status != statusIdentical {
+			changed = append(changed, release)
+		}
+	}
+	if len(changed) == 0 {
+		fmt.Fprintf(builder, "✅ All %d releases match\n", len(releases))
+		return
+	}
+
+	const header = "| Release | Status | Cluster A | Cluster B | Differences |\n" +
+		"|---------|--------|-----------|-----------|-------------|\n"
+
+	var rows []string
+	for _, release := range changed {
+		lines := release.ChangeLines()
+		var differences []string
+		for i, line := range lines {
+			if i == markdownMaxHelmChanges {
+				differences = append(differences, fmt.Sprintf("… %d more", len(lines)-i))
+				break
+			}
+			differences = append(differences, "`"+strings.ReplaceAll(line, "`", "'")+"`")
+		}
+		rows = append(rows, fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			escapeMarkdownTableCell(release.Namespace+"/"+release.Name), release.Status,
+			escapeMarkdownTableCell(release.ClusterA.String()), escapeMarkdownTableCell(release.ClusterB.String()),
+			escapeMarkdownTableCell(strings.Join(differences, "<br>"))))
+	}
+
+	rows, omitted := fitMarkdownRows(rows, len(header))
+	builder.WriteString(header)
+	builder.WriteString(strings.Join(rows, ""))
+	writeMarkdownTruncationNote(builder, omitted, "releases")
+	if identical := len(releases) - len(changed); identical > 0 {
+		fmt.Fprintf(builder, "\nReleases identical in both clusters: %d\n", identical)
+	}
+}
+
+// writeMarkdownImages writes the workload containers whose image differs between the
+// clusters or that exist in one cluster only as a table
+func writeMarkdownImages(builder *strings.Builder, images []ContainerImageComparison) {
+	if len(images) == 0 {
+		return
+	}
+
+	builder.WriteString("\n### Container Images\n\n")
+
+	different := differentImages(images)
+	if len(different) == 0 {
+		fmt.Fprintf(builder, "✅ All %d container images match\n", len(images))
+		writeMarkdownDigestDrift(builder, images)
+		return
+	}
+
+	const header = "| Workload | Container | Status | Cluster A | Cluster B | Differs in |\n" +
+		"|----------|-----------|--------|-----------|-----------|------------|\n"
+
+	rows := make([]string, len(different))
+	for i, image := range different {
+		rows[i] = fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+			escapeMarkdownTableCell(markdownWorkload(image)), escapeMarkdownTableCell(image.Container), image.Status,
+			markdownImage(image.ImageA), markdownImage(image.ImageB), strings.Join(image.Differences, ", "))
+	}
+
+	rows, omitted := fitMarkdownRows(rows, len(header))
+	builder.WriteString(header)
+	builder.WriteString(strings.Join(rows, ""))
+	writeMarkdownTruncationNote(builder, omitted, "containers")
+	if identical := len(images) - len(different); identical > 0 {
+		fmt.Fprintf(builder, "\nContainers with identical images: %d\n", identical)
+	}
+	writeMarkdownDigestDrift(builder, images)
+}
+
+// writeMarkdownDigestDrift writes the containers whose running image digests drift as a table
+func writeMarkdownDigestDrift(builder *strings.Builder, images []ContainerImageComparison) {
+	drifting := driftingImages(images)
+	if len(drifting) == 0 {
+		return
+	}
+
+	const header = "\n#### Running digest drift\n\n" +
+		"| Workload | Container | Drift | Digests A | Digests B |\n" +
+		"|----------|-----------|-------|-----------|-----------|\n"
+
+	rows := make([]string, len(drifting))
+	for i, image := range drifting {
+		rows[i] = fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			escapeMarkdownTableCell(markdownWorkload(image)), escapeMarkdownTableCell(image.Container), strings.Join(image.DigestDrift, ", "),
+			markdownImage(shortDigests(image.DigestsA)), markdownImage(shortDigests(image.DigestsB)))
+	}
+
+	rows, omitted := fitMarkdownRows(rows, len(header))
+	builder.WriteString(header)
+	builder.WriteString(strings.Join(rows, ""))
+	writeMarkdownTruncationNote(builder, omitted, "containers")
+}
+
+// writeMarkdownRBAC writes the subjects whose effective permissions differ as a table, with
+// up to markdownMaxRBACChanges permission changes per subject
+func writeMarkdownRBAC(builder *strings.Builder, subjects []RBACSubjectComparison) {
+	if len(subjects) == 0 {
+		return
+	}
+
+	builder.WriteString("\n### RBAC\n\n")
+
+	different := differentRBACSubjects(subjects)
+	if len(different) == 0 {
+		fmt.Fprintf(builder, "✅ All %d subjects have identical permissions\n", len(subjects))
+		return
+	}
+
+	const header = "| Subject | Status | Differences (A → B) |\n" +
+		"|---------|--------|---------------------|\n"
+
+	var rows []string
+	for _, subject := range different {
+		var differences []string
+		for i, change := range subject.Changes {
+			if i == markdownMaxRBACChanges {
+				differences = append(differences, fmt.Sprintf("… %d more", len(subject.Changes)-i))
+				break
+			}
+			differences = append(differences, "`"+strings.ReplaceAll(change.String(), "`", "'")+"`")
+		}
+		rows = append(rows, fmt.Sprintf("| %s | %s | %s |\n",
+			escapeMarkdownTableCell(subject.Subject), subject.Status, escapeMarkdownTableCell(strings.Join(differences, "<br>"))))
+	}
+
+	rows, omitted := fitMarkdownRows(rows, len(header))
+	builder.WriteString(header)
+	builder.WriteString(strings.Join(rows, ""))
+	writeMarkdownTruncationNote(builder, omitted, "subjects")
+	if identical := len(subjects) - len(different); identical > 0 {
+		fmt.Fprintf(builder, "\nSubjects with identical permissions: %d\n", identical)
+	}
+}
+
+// markdownWorkload names the workload of a container, with its namespace when it has one
+func markdownWorkload(image ContainerImageComparison) string {
+	if image.Namespace != "" {
+		return image.Kind + " " + image.Namespace + "/" + image.Workload
+	}
+	return image.Kind + " " + image.Workload
+}
+
+// markdownImage formats an image reference or digests as a table cell, or "-" when empty
+func markdownImage(image string) string {
+	if image == "" {
+		return "-"
+	}
+	return "`" + escapeMarkdownTableCell(strings.ReplaceAll(image, "`", "'")) + "`"
+}
+
+// markdownResourceDiff renders a collapsible section with the unified diff of a resource,
+// truncated to markdownMaxDiffLines lines
+func markdownResourceDiff(resource ResourceComparison) (string, error) {
+	hunks, err := resourceDiffHunks(resource)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, hunk := range hunks {
+		lines = append(lines, hunk.Header())
+		for _, line := range hunk.Lines {
+			lines = append(lines, string(line.Op)+line.Text)
+		}
+	}
+
+	truncated := 0
+	if len(lines) > markdownMaxDiffLines {
+		truncated = len(lines) - markdownMaxDiffLines
+		lines = lines[:markdownMaxDiffLines]
+	}
+
+	fence := markdownFence(lines)
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "<details>\n<summary><code>%s</code> (%d changed fields)</summary>\n\n", html.EscapeString(resource.String()), len(resource.Changes))
+	builder.WriteString(fence + "diff\n")
+	for _, line := range lines {
+		builder.WriteString(line + "\n")
+	}
+	builder.WriteString(fence + "\n")
+	if truncated > 0 {
+		fmt.Fprintf(&builder, "\n_%d more diff lines not shown_\n", truncated)
+	}
+	builder.WriteString("\n</details>\n\n")
+
+	return builder.String(), nil
+}
+
+// markdownResourceList renders a collapsible list of the resources with the given status,
+// truncated to markdownMaxListedResources entries
+func markdownResourceList(title, status string, comparison *Comparison) string {
+	var entries []string
+	for _, kind := range comparison.Kinds {
+		for _, resource := range kind.Resources {
+			if resource.Status != status {
+				continue
+			}
+			entry := "- `" + resource.String() + "`"
+			if resource.Skipped != nil && resource.Skipped.Error != "" {
+				entry += " (fetch failed: " + html.EscapeString(resource.Skipped.Error) + ")"
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "\n<details>\n<summary>%s (%d)</summary>\n\n", title, len(entries))
+	for i, entry := range entries {
+		if i == markdownMaxListedResources {
+			fmt.Fprintf(&builder, "- _and %d more_\n", len(entries)-markdownMaxListedResources)
+			break
+		}
+		builder.WriteString(entry + "\n")
+	}
+	builder.WriteString("\n</details>\n")
+
+	return builder.String()
+}
+
+// markdownFence returns a code fence longer than any backtick run in the lines, so
+// resource content cannot close the fence early
+func markdownFence(lines []string) string {
+	longest := 0
+	for _, line := range lines {
+		run := 0
+		for _, r := range line {
+			if r == '`' {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// escapeMarkdownTableCell escapes the pipes that would split a table cell
+func escapeMarkdownTableCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// generateMarkdownReport writes the Markdown report next to the HTML report
+func generateMarkdownReport(config *ComparisonConfig, comparison *Comparison) error {
+	filename := fmt.Sprintf("%s/k8s-comparison-report_%s.md", config.OutputDir, config.ReportTimestamp)
+
+	content, err := renderMarkdownReport(config, comparison)
+	if err != nil {
+		return fmt.Errorf("failed to render Markdown report: %w", err)
+	}
+
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write Markdown report: %w", err)
+	}
+
+	fmt.Fprintf(config.progress(), "📄 Generated Markdown report: %s\n", filename)
+	return nil
+}
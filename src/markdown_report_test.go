@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Markdown report", func() {
+	var config *ComparisonConfig
+
+	BeforeEach(func() {
+		config = &ComparisonConfig{
+			ClusterA: ClusterConfig{
+				Context: "staging",
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(2)}),
+					testResource("ConfigMap", "default", "legacy", nil),
+				},
+			},
+			ClusterB: ClusterConfig{
+				Context: "production",
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(3)}),
+				},
+			},
+			ReportTimestamp:   "2024-01-02_03:04:05",
+			CompareNamespaces: true,
+		}
+	})
+
+	Describe("renderMarkdownReport function", func() {
+		It("should render a summary table and a collapsible diff per differing resource", func() {
+			report, err := renderMarkdownReport(config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(ContainSubstring("## Cluster comparison: `staging` vs `production`"))
+			Expect(report).To(ContainSubstring("| ConfigMap | 1 | 0 | 1 | 0 | 0 | 0 | 0 | 0 |"))
+			Expect(report).To(ContainSubstring("| Deployment | 1 | 1 | 0 | 0 | 1 | 0 | 0 | 0 |"))
+			Expect(report).To(ContainSubstring("| **Total** | 2 | 1 | 1 | 0 | 1 | 0 | 0 | 0 |"))
+			Expect(report).To(ContainSubstring("<summary><code>Deployment default/web</code> (1 changed fields)</summary>"))
+			Expect(report).To(ContainSubstring("```diff\n"))
+			Expect(report).To(ContainSubstring("-  replicas: 2\n+  replicas: 3\n"))
+			Expect(report).To(ContainSubstring("<summary>Only in Cluster A (1)</summary>\n\n- `ConfigMap default/legacy`"))
+		})
+
+		It("should report when the clusters match", func() {
+			config.ClusterA.Data = config.ClusterB.Data
+
+			report, err := renderMarkdownReport(config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(ContainSubstring("No differences found"))
+			Expect(report).NotTo(ContainSubstring("<details>"))
+		})
+
+		It("should truncate long diffs", func() {
+			specA := map[string]interface{}{}
+			specB := map[string]interface{}{}
+			for i := 0; i < markdownMaxDiffLines; i++ {
+				key := fmt.Sprintf("key%03d", i)
+				specA[key] = "a"
+				specB[key] = "b"
+			}
+			config.ClusterA.Data = []map[string]interface{}{testResource("ConfigMap", "default", "big", specA)}
+			config.ClusterB.Data = []map[string]interface{}{testResource("ConfigMap", "default", "big", specB)}
+
+			report, err := renderMarkdownReport(config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(MatchRegexp(`_\d+ more diff lines not shown_`))
+		})
+
+		It("should stay within the size budget", func() {
+			config.ClusterA.Data = nil
+			config.ClusterB.Data = nil
+			for i := 0; i < 2000; i++ {
+				name := fmt.Sprintf("app-%04d", i)
+				config.ClusterA.Data = append(config.ClusterA.Data, testResource("ConfigMap", "default", name, map[string]interface{}{"value": "a"}))
+				config.ClusterB.Data = append(config.ClusterB.Data, testResource("ConfigMap", "default", name, map[string]interface{}{"value": "b"}))
+			}
+
+			report, err := renderMarkdownReport(config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(len(report)).To(BeNumerically("<=", markdownMaxLength))
+			Expect(report).To(MatchRegexp(`\d+ more resources were left out`))
+		})
+
+		It("should keep large image, drift, RBAC and Helm sections within the comment size limit", func() {
+			for i := 0; i < 2000; i++ {
+				name := fmt.Sprintf("app-%04d", i)
+				config.ClusterA.Data = append(config.ClusterA.Data, testResource("ConfigMap", "default", name, map[string]interface{}{"value": "a"}))
+				config.ClusterB.Data = append(config.ClusterB.Data, testResource("ConfigMap", "default", name, map[string]interface{}{"value": "b"}))
+			}
+			comparison := compareClusters(config)
+
+			for i := 0; i < 500; i++ {
+				name := fmt.Sprintf("app-%04d", i)
+				comparison.Images = append(comparison.Images, ContainerImageComparison{
+					Kind: "Deployment", Namespace: "default", Workload: name, Container: "main", Status: statusDifferent,
+					ImageA: "registry.example.com/team/" + name + ":1.0.0", ImageB: "registry.example.com/team/" + name + ":1.1.0",
+					Differences: []string{"tag"}, DigestDrift: []string{digestDriftWithinB},
+					DigestsA: []string{"sha256:" + strings.Repeat("a", 64)}, DigestsB: []string{"sha256:" + strings.Repeat("b", 64), "sha256:" + strings.Repeat("c", 64)},
+				})
+
+				release := HelmReleaseComparison{Name: name, Namespace: "default", Status: statusDifferent,
+					ClusterA: &HelmRelease{Chart: name, ChartVersion: "1.0.0", Revision: 3, Status: "deployed"},
+					ClusterB: &HelmRelease{Chart: name, ChartVersion: "1.1.0", Revision: 7, Status: "deployed"},
+				}
+				subject := RBACSubjectComparison{Subject: "ServiceAccount default/" + name, Status: statusDifferent}
+				for j := 0; j < 20; j++ {
+					release.ValueChanges = append(release.ValueChanges, FieldChange{Path: fmt.Sprintf("values.settings.key%02d", j), Type: "changed", ValueA: "a", ValueB: "b"})
+					subject.Changes = append(subject.Changes, RBACPermissionChange{Namespace: "default", Resource: fmt.Sprintf("widgets%02d.example.com", j), VerbsA: []string{"get", "list"}, VerbsB: []string{"*"}})
+				}
+				comparison.HelmReleases = append(comparison.HelmReleases, release)
+				comparison.RBAC = append(comparison.RBAC, subject)
+			}
+
+			report, err := renderMarkdownReport(config, comparison)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(len(report)).To(BeNumerically("<=", markdownMaxLength))
+			for _, entries := range []string{"containers", "releases", "subjects", "resources"} {
+				Expect(report).To(MatchRegexp(`\d+ more ` + entries + ` were left out to fit the comment size limit`))
+			}
+			Expect(report).To(ContainSubstring("### Differences"))
+		})
+
+		It("should limit the rows per table", func() {
+			comparison := compareClusters(config)
+			for i := 0; i < markdownMaxTableRows+5; i++ {
+				comparison.RBAC = append(comparison.RBAC, RBACSubjectComparison{Subject: fmt.Sprintf("User user-%02d", i), Status: statusOnlyInA})
+			}
+
+			report, err := renderMarkdownReport(config, comparison)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(ContainSubstring("| User user-49 | only-in-A |"))
+			Expect(report).NotTo(ContainSubstring("| User user-50 |"))
+			Expect(report).To(ContainSubstring("> ⚠️ 5 more subjects were left out"))
+		})
+
+		It("should limit the listed missing resources", func() {
+			config.ClusterA.Data = nil
+			config.ClusterB.Data = nil
+			for i := 0; i < markdownMaxListedResources+5; i++ {
+				config.ClusterA.Data = append(config.ClusterA.Data, testResource("Secret", "default", fmt.Sprintf("secret-%02d", i), nil))
+			}
+
+			report, err := renderMarkdownReport(config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(ContainSubstring("- _and 5 more_"))
+		})
+	})
+
+	Describe("markdownFence function", func() {
+		It("should use a fence longer than any backtick run in the content", func() {
+			Expect(markdownFence([]string{"plain"})).To(Equal("```"))
+			Expect(markdownFence([]string{"+  script: echo ````"})).To(Equal("`````"))
+		})
+	})
+
+	Describe("generateMarkdownReport function", func() {
+		It("should write the report next to the HTML report", func() {
+			tempDir, err := os.MkdirTemp("", "k8s-compare-test")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(tempDir)
+			config.OutputDir = tempDir
+
+			Expect(generateMarkdownReport(config, compareClusters(config))).To(Succeed())
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "k8s-comparison-report_2024-01-02_03:04:05.md"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.HasPrefix(string(content), "## Cluster comparison")).To(BeTrue())
+		})
+	})
+})
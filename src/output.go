@@ -3,13 +3,12 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"html"
 	"os"
-	"strings"
 )
 
-// generateOutputFiles generates JSON and HTML output files
-func generateOutputFiles(config *ComparisonConfig) error {
+// generateOutputFiles generates the JSON snapshots, the JSON diff report, the Markdown report, the HTML report
+// and the index of all runs in the output directory from a comparison computed once by the caller
+func generateOutputFiles(config *ComparisonConfig, comparison *Comparison) error {
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(config.OutputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
@@ -25,11 +24,26 @@ func generateOutputFiles(config *ComparisonConfig) error {
 		return fmt.Errorf("failed to write cluster-b.json: %w", err)
 	}
 
+	// Write the machine-readable diff report
+	if err := generateDiffReport(config, comparison); err != nil {
+		return fmt.Errorf("failed to generate diff report: %w", err)
+	}
+
+	// Write the Markdown report for pull request comments
+	if err := generateMarkdownReport(config, comparison); err != nil {
+		return fmt.Errorf("failed to generate Markdown report: %w", err)
+	}
+
 	// Generate HTML report
-	if err := generateHTMLReport(config); err != nil {
+	if err := generateHTMLReport(config, comparison); err != nil {
 		return fmt.Errorf("failed to generate HTML report: %w", err)
 	}
 
+	// List the new run alongside the earlier ones
+	if err := generateReportIndex(config.progress(), config.OutputDir); err != nil {
+		return fmt.Errorf("failed to generate report index: %w", err)
+	}
+
 	return nil
 }
 
@@ -44,39 +58,22 @@ func writeJSONFile(filename string, data []map[string]interface{}) error {
 }
 
 // generateHTMLReport creates an HTML report with embedded data
-func generateHTMLReport(config *ComparisonConfig) error {
+func generateHTMLReport(config *ComparisonConfig, comparison *Comparison) error {
 	// Create timestamp for filename
 	filename := fmt.Sprintf("%s/k8s-comparison-report_%s.html", config.OutputDir, config.ReportTimestamp)
 
-	// Convert data to JSON strings for embedding
-	clusterAJSON, err := json.Marshal(config.ClusterA.Data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal cluster A data: %w", err)
-	}
-
-	clusterBJSON, err := json.Marshal(config.ClusterB.Data)
+	// Generate the HTML content
+	htmlContent, err := generateHTMLTemplate(config, comparison, config.ReportTimestamp)
 	if err != nil {
-		return fmt.Errorf("failed to marshal cluster B data: %w", err)
+		return err
 	}
 
-	// Generate the HTML content
-	htmlContent := generateHTMLTemplate(config, string(clusterAJSON), string(clusterBJSON), config.ReportTimestamp)
-
 	// Write to file
 	err = os.WriteFile(filename, []byte(htmlContent), 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write HTML report: %w", err)
 	}
 
-	fmt.Printf("📄 Generated HTML report: %s\n", filename)
+	fmt.Fprintf(config.progress(), "📄 Generated HTML report: %s\n", filename)
 	return nil
 }
-
-// generateResourceTags creates HTML tags for resources
-func generateResourceTags(resources []string) string {
-	var tags []string
-	for _, resource := range resources {
-		tags = append(tags, fmt.Sprintf(`<span class="resource-tag">%s</span>`, html.EscapeString(resource)))
-	}
-	return strings.Join(tags, "\n                        ")
-}
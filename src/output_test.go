@@ -22,40 +22,46 @@ var _ = Describe("Output", func() {
 		os.RemoveAll(tempDir)
 	})
 
-	Describe("generateResourceTags function", func() {
-		Context("when generating tags for resources", func() {
-			It("should generate correct tags for pods", func() {
-				resources := []string{"pods", "services", "deployments"}
-				tags := generateResourceTags(resources)
-
-				Expect(tags).To(ContainSubstring("pods"))
-				Expect(tags).To(ContainSubstring("services"))
-				Expect(tags).To(ContainSubstring("deployments"))
-				Expect(tags).To(ContainSubstring("resource-tag"))
+	Describe("resource tags", func() {
+		Context("when rendering tags for resources", func() {
+			render := func(resources []string) string {
+				return renderHTMLReport(&ComparisonConfig{ClusterA: ClusterConfig{Resources: resources}}, "2023-01-01_12-00-00")
+			}
+
+			It("should render a tag per resource", func() {
+				report := render([]string{"pods", "services", "deployments"})
+
+				Expect(report).To(ContainSubstring(`<span class="resource-tag">pods</span>`))
+				Expect(report).To(ContainSubstring(`<span class="resource-tag">services</span>`))
+				Expect(report).To(ContainSubstring(`<span class="resource-tag">deployments</span>`))
 			})
 
 			It("should handle empty resource list", func() {
-				resources := []string{}
-				tags := generateResourceTags(resources)
-
-				Expect(tags).To(Equal(""))
+				Expect(render([]string{})).NotTo(ContainSubstring(`<span class="resource-tag">`))
 			})
 
-			It("should handle single resource", func() {
-				resources := []string{"pods"}
-				tags := generateResourceTags(resources)
+			It("should handle special characters in resource names", func() {
+				report := render([]string{"custom-resources.v1", "api-extensions"})
 
-				Expect(tags).To(ContainSubstring("pods"))
-				Expect(tags).To(ContainSubstring("resource-tag"))
+				Expect(report).To(ContainSubstring("custom-resources.v1"))
+				Expect(report).To(ContainSubstring("api-extensions"))
 			})
+		})
+	})
 
-			It("should handle special characters in resource names", func() {
-				resources := []string{"custom-resources.v1", "api-extensions"}
-				tags := generateResourceTags(resources)
+	Describe("skippedScopeLabel function", func() {
+		It("should describe a skipped scope with its reason", func() {
+			scope := SkippedScope{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "kube-system"}, Reason: skipReasonForbidden}
+			Expect(skippedScopeLabel(scope)).To(Equal("secrets in kube-system (forbidden)"))
+		})
 
-				Expect(tags).To(ContainSubstring("custom-resources.v1"))
-				Expect(tags).To(ContainSubstring("api-extensions"))
-			})
+		It("should include the error of a failed fetch", func() {
+			scope := SkippedScope{ResourceScope: ResourceScope{Resource: "pods", Kind: "Pod", Namespace: "default"}, Reason: skipReasonError, Error: "timeout"}
+			Expect(skippedScopeLabel(scope)).To(Equal("pods in default (fetch failed: timeout)"))
+		})
+
+		It("should render no Not Compared item when no scope was skipped", func() {
+			Expect(renderHTMLReport(&ComparisonConfig{}, "2023-01-01_12-00-00")).NotTo(ContainSubstring(`<div class="metadata-label">Not Compared</div>`))
 		})
 	})
 
@@ -82,7 +88,7 @@ var _ = Describe("Output", func() {
 				defer os.Chdir(originalDir)
 				os.Chdir(tempDir)
 
-				err := generateOutputFiles(&config)
+				err := generateOutputFiles(&config, compareClusters(&config))
 				Expect(err).NotTo(HaveOccurred())
 
 				// Check if JSON files were created
@@ -100,7 +106,7 @@ var _ = Describe("Output", func() {
 				defer os.Chdir(originalDir)
 				os.Chdir(tempDir)
 
-				err := generateOutputFiles(&config)
+				err := generateOutputFiles(&config, compareClusters(&config))
 				Expect(err).NotTo(HaveOccurred())
 
 				clusterAFile := filepath.Join(tempDir, "cluster-a.json")
@@ -136,7 +142,7 @@ var _ = Describe("Output", func() {
 				defer os.Chdir(originalDir)
 				os.Chdir(tempDir)
 
-				err := generateHTMLReport(&config)
+				err := generateHTMLReport(&config, compareClusters(&config))
 				Expect(err).NotTo(HaveOccurred())
 
 				// Check that an HTML file was created (filename includes timestamp)
@@ -173,7 +179,7 @@ var _ = Describe("Output", func() {
 				defer os.Chdir(originalDir)
 				os.Chdir(tempDir)
 
-				err := generateHTMLReport(&config)
+				err := generateHTMLReport(&config, compareClusters(&config))
 				Expect(err).NotTo(HaveOccurred())
 
 				// Check that an HTML file was created
@@ -206,7 +212,7 @@ var _ = Describe("Output", func() {
 					},
 				}
 
-				template := generateHTMLTemplate(&config, `[{"name":"test"}]`, `[{"name":"test2"}]`, "2023-01-01_12-00-00")
+				template := renderHTMLReport(&config, "2023-01-01_12-00-00")
 
 				Expect(template).To(ContainSubstring("<!DOCTYPE html>"))
 				Expect(template).To(ContainSubstring("<html"))
@@ -222,7 +228,7 @@ var _ = Describe("Output", func() {
 					ClusterB: ClusterConfig{Context: "cluster-b"},
 				}
 
-				template := generateHTMLTemplate(&config, "[]", "[]", "2023-01-01_12-00-00")
+				template := renderHTMLReport(&config, "2023-01-01_12-00-00")
 
 				Expect(template).To(ContainSubstring("<script>"))
 				Expect(template).To(ContainSubstring("</script>"))
@@ -235,7 +241,7 @@ var _ = Describe("Output", func() {
 					ClusterB: ClusterConfig{Context: "cluster-b"},
 				}
 
-				template := generateHTMLTemplate(&config, "[]", "[]", "2023-01-01_12-00-00")
+				template := renderHTMLReport(&config, "2023-01-01_12-00-00")
 
 				Expect(template).To(ContainSubstring("<style>"))
 				Expect(template).To(ContainSubstring("</style>"))
@@ -246,7 +252,7 @@ var _ = Describe("Output", func() {
 			It("should handle empty config gracefully", func() {
 				config := ComparisonConfig{}
 
-				template := generateHTMLTemplate(&config, "[]", "[]", "2023-01-01_12-00-00")
+				template := renderHTMLReport(&config, "2023-01-01_12-00-00")
 
 				Expect(template).To(ContainSubstring("<!DOCTYPE html>"))
 				Expect(template).To(ContainSubstring("Kubernetes Resource Comparison"))
@@ -266,7 +272,7 @@ var _ = Describe("Output", func() {
 				defer os.Chdir(originalDir)
 				os.Chdir(tempDir)
 
-				err := generateOutputFiles(&config)
+				err := generateOutputFiles(&config, compareClusters(&config))
 				Expect(err).NotTo(HaveOccurred())
 
 				files, err := os.ReadDir(tempDir)
@@ -294,7 +300,7 @@ var _ = Describe("Output", func() {
 				os.Chdir(tempDir)
 
 				beforeTime := time.Now()
-				err := generateOutputFiles(&config)
+				err := generateOutputFiles(&config, compareClusters(&config))
 				Expect(err).NotTo(HaveOccurred())
 
 				// Check that HTML file includes timestamp in filename
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxOwnerDepth bounds owner chains, so inconsistent owner references cannot loop forever
+const maxOwnerDepth = 10
+
+// podTemplateHashLabel is the label the Deployment controller adds to its ReplicaSets and their
+// Pods; ReplicaSet names end in it
+const podTemplateHashLabel = "pod-template-hash"
+
+// ownerReference identifies the controller of a resource
+type ownerReference struct {
+	Kind string
+	Name string
+}
+
+// ownerIndex maps the "kind/namespace/name" of fetched resources to their controller
+type ownerIndex map[string]ownerReference
+
+// controllerOwner returns the controller named in the owner references of a resource
+func controllerOwner(resource map[string]interface{}) (ownerReference, bool) {
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	owners, _ := metadata["ownerReferences"].([]interface{})
+	for _, owner := range owners {
+		reference, ok := owner.(map[string]interface{})
+		if !ok || reference["controller"] != true {
+			continue
+		}
+		kind, _ := reference["kind"].(string)
+		name, _ := reference["name"].(string)
+		return ownerReference{Kind: kind, Name: name}, true
+	}
+	return ownerReference{}, false
+}
+
+// newOwnerIndex indexes the controllers of the resources. When the ReplicaSet of a Pod was
+// not fetched, its Deployment is derived from the ReplicaSet name, which is the Deployment
+// name followed by the pod template hash.
+func newOwnerIndex(resources []map[string]interface{}) ownerIndex {
+	index := make(ownerIndex)
+	for _, resource := range resources {
+		if owner, owned := controllerOwner(resource); owned {
+			id := resourceIdentity(resource)
+			index[ownerIndexKey(id.Kind, id.Namespace, id.Name)] = owner
+		}
+	}
+
+	for _, resource := range resources {
+		if key, deployment, derived := derivedDeployment(resource); derived {
+			if _, found := index[key]; !found {
+				index[key] = deployment
+			}
+		}
+	}
+	return index
+}
+
+// derivedDeployment returns the ownerIndexKey of the ReplicaSet controlling a resource and
+// the Deployment derived from its name and the pod template hash label of the resource
+func derivedDeployment(resource map[string]interface{}) (string, ownerReference, bool) {
+	owner, owned := controllerOwner(resource)
+	if !owned || owner.Kind != "ReplicaSet" {
+		return "", ownerReference{}, false
+	}
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	hash, _ := labels[podTemplateHashLabel].(string)
+	deployment, trimmed := strings.CutSuffix(owner.Name, "-"+hash)
+	if hash == "" || !trimmed {
+		return "", ownerReference{}, false
+	}
+	return ownerIndexKey("ReplicaSet", resourceIdentity(resource).Namespace, owner.Name), ownerReference{Kind: "Deployment", Name: deployment}, true
+}
+
+// ownerChain follows the controllers of a resource, from its own controller up to the one
+// that has no controller
+func (index ownerIndex) ownerChain(kind, namespace, name string) []ownerReference {
+	var chain []ownerReference
+	current := ownerReference{Kind: kind, Name: name}
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		owner, found := index[ownerIndexKey(current.Kind, namespace, current.Name)]
+		if !found {
+			break
+		}
+		chain = append(chain, owner)
+		current = owner
+	}
+	return chain
+}
+
+// ownerIndexKey returns the key of a resource in an ownerIndex
+func ownerIndexKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// OwnedSummary counts the controller-generated resources of one kind that were collapsed
+// into their owner in each cluster
+type OwnedSummary struct {
+	Kind   string `json:"kind"`
+	CountA int    `json:"countA"`
+	CountB int    `json:"countB"`
+	// ReadyA and ReadyB count the ready Pods; they are only set for Pods
+	ReadyA *int `json:"readyA,omitempty"`
+	ReadyB *int `json:"readyB,omitempty"`
+}
+
+// topFetchedOwner returns the highest controller in the owner chain of a resource that is
+// among the fetched resources, given by their ownerIndexKey
+func (index ownerIndex) topFetchedOwner(kind, namespace, name string, fetched map[string]bool) (ownerReference, bool) {
+	var top ownerReference
+	found := false
+	for _, owner := range index.ownerChain(kind, namespace, name) {
+		if fetched[ownerIndexKey(owner.Kind, namespace, owner.Name)] {
+			top, found = owner, true
+		}
+	}
+	return top, found
+}
+
+// collapseOwnedResources separates the controller-generated resources whose owner was
+// fetched too, keyed by the ownerIndexKey of their highest fetched owner, from the
+// resources that are compared directly. Generated names never match between clusters,
+// so these resources are counted under their owner instead.
+func collapseOwnedResources(resources []map[string]interface{}) ([]map[string]interface{}, map[string][]map[string]interface{}) {
+	fetched := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		id := resourceIdentity(resource)
+		fetched[ownerIndexKey(id.Kind, id.Namespace, id.Name)] = true
+	}
+
+	owners := newOwnerIndex(resources)
+	var compared []map[string]interface{}
+	owned := make(map[string][]map[string]interface{})
+	for _, resource := range resources {
+		id := resourceIdentity(resource)
+		owner, found := owners.topFetchedOwner(id.Kind, id.Namespace, id.Name, fetched)
+		if !found {
+			compared = append(compared, resource)
+			continue
+		}
+		key := ownerIndexKey(owner.Kind, id.Namespace, owner.Name)
+		owned[key] = append(owned[key], resource)
+	}
+	return compared, owned
+}
+
+// ownedTally counts the resources collapsed into an owner in one cluster per kind
+type ownedTally map[string]*ownedCount
+
+// ownedCount is the number of collapsed resources of a kind, and of ready ones for Pods
+type ownedCount struct {
+	Total int
+	Ready int
+}
+
+// add counts a collapsed resource
+func (t ownedTally) add(resource map[string]interface{}) {
+	kind := resourceIdentity(resource).Kind
+	t.count(kind, kind == "Pod" && isPodReady(resource))
+}
+
+// count counts a collapsed resource of a kind, ready or not
+func (t ownedTally) count(kind string, ready bool) {
+	if t[kind] == nil {
+		t[kind] = &ownedCount{}
+	}
+	t[kind].Total++
+	if ready {
+		t[kind].Ready++
+	}
+}
+
+// summarizeOwned counts the resources collapsed into an owner in each cluster per kind
+func summarizeOwned(ownedA, ownedB []map[string]interface{}) []OwnedSummary {
+	tallyA, tallyB := make(ownedTally), make(ownedTally)
+	for _, resource := range ownedA {
+		tallyA.add(resource)
+	}
+	for _, resource := range ownedB {
+		tallyB.add(resource)
+	}
+	return summarizeTallies(tallyA, tallyB)
+}
+
+// summarizeTallies combines the counts of the resources collapsed into an owner in each
+// cluster into one summary per kind
+func summarizeTallies(tallyA, tallyB ownedTally) []OwnedSummary {
+	summaries := make(map[string]*OwnedSummary)
+	count := func(tally ownedTally, inA bool) {
+		for kind, count := range tally {
+			summary := summaries[kind]
+			if summary == nil {
+				summary = &OwnedSummary{Kind: kind}
+				if kind == "Pod" {
+					summary.ReadyA, summary.ReadyB = new(int), new(int)
+				}
+				summaries[kind] = summary
+			}
+
+			if inA {
+				summary.CountA = count.Total
+			} else {
+				summary.CountB = count.Total
+			}
+			if summary.ReadyA != nil {
+				if inA {
+					*summary.ReadyA = count.Ready
+				} else {
+					*summary.ReadyB = count.Ready
+				}
+			}
+		}
+	}
+	count(tallyA, true)
+	count(tallyB, false)
+
+	var result []OwnedSummary
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Kind < result[j].Kind })
+	return result
+}
+
+// isPodReady reports whether the Ready condition of a Pod is true
+func isPodReady(pod map[string]interface{}) bool {
+	status, _ := pod["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, item := range conditions {
+		condition, _ := item.(map[string]interface{})
+		if condition["type"] == "Ready" {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
+
+// differs reports whether the clusters have a different number of resources, or of ready Pods
+func (s OwnedSummary) differs() bool {
+	if s.CountA != s.CountB {
+		return true
+	}
+	return s.ReadyA != nil && s.ReadyB != nil && *s.ReadyA != *s.ReadyB
+}
+
+// String describes the counts, e.g. "Pods: 3/3 ready in A, 2/3 in B" or "ReplicaSets: 1 in A, 2 in B"
+func (s OwnedSummary) String() string {
+	if s.ReadyA != nil && s.ReadyB != nil {
+		return fmt.Sprintf("%ss: %d/%d ready in A, %d/%d in B", s.Kind, *s.ReadyA, s.CountA, *s.ReadyB, s.CountB)
+	}
+	return fmt.Sprintf("%ss: %d in A, %d in B", s.Kind, s.CountA, s.CountB)
+}
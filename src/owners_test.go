@@ -0,0 +1,187 @@
+package main
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// withController adds a controller owner reference to a resource and returns it
+func withController(resource map[string]interface{}, kind, name string) map[string]interface{} {
+	metadata := resource["metadata"].(map[string]interface{})
+	metadata["ownerReferences"] = []interface{}{
+		map[string]interface{}{"kind": "Node", "name": "node-1"},
+		map[string]interface{}{"kind": kind, "name": name, "controller": true},
+	}
+	return resource
+}
+
+// testReplicaPod returns a Pod created through the given ReplicaSet of a Deployment
+func testReplicaPod(namespace, replicaSet, name string, ready bool) map[string]interface{} {
+	pod := withController(testResource("Pod", namespace, name, nil), "ReplicaSet", replicaSet)
+	status := "False"
+	if ready {
+		status = "True"
+	}
+	pod["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": status}},
+	}
+	return pod
+}
+
+var _ = Describe("Owners", func() {
+	Describe("controllerOwner function", func() {
+		It("should return the owner reference marked as controller", func() {
+			pod := withController(testResource("Pod", "default", "web-6d4f-x2k", nil), "ReplicaSet", "web-6d4f")
+
+			owner, owned := controllerOwner(pod)
+			Expect(owned).To(BeTrue())
+			Expect(owner).To(Equal(ownerReference{Kind: "ReplicaSet", Name: "web-6d4f"}))
+
+			_, owned = controllerOwner(testResource("Pod", "default", "debug", nil))
+			Expect(owned).To(BeFalse())
+		})
+	})
+
+	Describe("ownerIndex ownerChain method", func() {
+		It("should follow owner chains up to the top-level owner", func() {
+			index := newOwnerIndex([]map[string]interface{}{
+				withController(testResource("Pod", "default", "backup-28391-abcde", nil), "Job", "backup-28391"),
+				withController(testResource("Job", "default", "backup-28391", nil), "CronJob", "backup"),
+			})
+
+			Expect(index.ownerChain("Pod", "default", "backup-28391-abcde")).To(Equal([]ownerReference{
+				{Kind: "Job", Name: "backup-28391"},
+				{Kind: "CronJob", Name: "backup"},
+			}))
+			Expect(index.ownerChain("Pod", "other", "backup-28391-abcde")).To(BeEmpty())
+		})
+
+		It("should derive the Deployment of a ReplicaSet that was not fetched from the pod template hash", func() {
+			pod := withController(testResource("Pod", "default", "web-6d4f-x2k", nil), "ReplicaSet", "web-6d4f")
+			pod["metadata"].(map[string]interface{})["labels"] = map[string]interface{}{podTemplateHashLabel: "6d4f"}
+
+			index := newOwnerIndex([]map[string]interface{}{pod})
+			Expect(index.ownerChain("Pod", "default", "web-6d4f-x2k")).To(Equal([]ownerReference{
+				{Kind: "ReplicaSet", Name: "web-6d4f"},
+				{Kind: "Deployment", Name: "web"},
+			}))
+		})
+
+		It("should stop at inconsistent owner loops", func() {
+			index := newOwnerIndex([]map[string]interface{}{
+				withController(testResource("ReplicaSet", "default", "a", nil), "ReplicaSet", "b"),
+				withController(testResource("ReplicaSet", "default", "b", nil), "ReplicaSet", "a"),
+			})
+
+			Expect(index.ownerChain("ReplicaSet", "default", "a")).To(HaveLen(maxOwnerDepth))
+		})
+	})
+
+	Describe("collapseOwnedResources function", func() {
+		It("should count generated resources under their highest fetched owner", func() {
+			deployment := testResource("Deployment", "default", "web", nil)
+			replicaSet := withController(testResource("ReplicaSet", "default", "web-6d4f", nil), "Deployment", "web")
+			pod := testReplicaPod("default", "web-6d4f", "web-6d4f-x2k", true)
+			orphan := testReplicaPod("default", "gone-1a2b", "gone-1a2b-abcde", true)
+
+			compared, owned := collapseOwnedResources([]map[string]interface{}{deployment, replicaSet, pod, orphan})
+
+			Expect(compared).To(Equal([]map[string]interface{}{deployment, orphan}))
+			Expect(owned).To(Equal(map[string][]map[string]interface{}{
+				"Deployment/default/web": {replicaSet, pod},
+			}))
+		})
+	})
+
+	Describe("summarizeOwned function", func() {
+		It("should count generated resources per kind and ready Pods", func() {
+			summaries := summarizeOwned(
+				[]map[string]interface{}{
+					testResource("ReplicaSet", "default", "web-6d4f", nil),
+					testReplicaPod("default", "web-6d4f", "web-6d4f-a", true),
+					testReplicaPod("default", "web-6d4f", "web-6d4f-b", true),
+				},
+				[]map[string]interface{}{
+					testResource("ReplicaSet", "default", "web-7c9b", nil),
+					testReplicaPod("default", "web-7c9b", "web-7c9b-a", true),
+					testReplicaPod("default", "web-7c9b", "web-7c9b-b", false),
+				},
+			)
+
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].String()).To(Equal("Pods: 2/2 ready in A, 1/2 in B"))
+			Expect(summaries[0].differs()).To(BeTrue())
+			Expect(summaries[1].String()).To(Equal("ReplicaSets: 1 in A, 1 in B"))
+			Expect(summaries[1].differs()).To(BeFalse())
+		})
+	})
+
+	Describe("owner-aware comparison", func() {
+		var config *ComparisonConfig
+
+		BeforeEach(func() {
+			config = &ComparisonConfig{
+				ClusterA: ClusterConfig{Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", nil),
+					withController(testResource("ReplicaSet", "default", "web-6d4f", nil), "Deployment", "web"),
+					testReplicaPod("default", "web-6d4f", "web-6d4f-a", true),
+					testReplicaPod("default", "web-6d4f", "web-6d4f-b", true),
+					testReplicaPod("default", "web-6d4f", "web-6d4f-c", true),
+				}},
+				ClusterB: ClusterConfig{Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", nil),
+					withController(testResource("ReplicaSet", "default", "web-7c9b", nil), "Deployment", "web"),
+					testReplicaPod("default", "web-7c9b", "web-7c9b-a", true),
+					testReplicaPod("default", "web-7c9b", "web-7c9b-b", true),
+				}},
+				CompareNamespaces: true,
+			}
+		})
+
+		It("should compare owners and summarize their generated resources", func() {
+			comparison := compareClusters(config)
+
+			Expect(comparison.StatusCounts()).To(Equal(map[string]int{statusIdentical: 1}))
+			Expect(comparison.Summaries()).To(ContainElement(kindSummary{Kind: "Pod", CountA: 3, CountB: 2, Counts: map[string]int{}}))
+
+			owners := comparison.OwnedDifferences()
+			Expect(owners).To(HaveLen(1))
+			Expect(owners[0].Name).To(Equal("web"))
+			Expect(owners[0].Owned).To(HaveLen(2))
+			Expect(owners[0].Owned[0].String()).To(Equal("Pods: 3/3 ready in A, 2/2 in B"))
+
+			var text strings.Builder
+			Expect(renderTextReport(&text, config, comparison, false)).To(Succeed())
+			Expect(text.String()).To(ContainSubstring("🧩 Generated Resources (1 owners differ)\nDeployment default/web\n   Pods: 3/3 ready in A, 2/2 in B\n"))
+
+			markdown, err := renderMarkdownReport(config, comparison)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(markdown).To(ContainSubstring("| Deployment default/web | Pods: 3/3 ready in A, 2/2 in B<br>ReplicaSets: 1 in A, 1 in B |"))
+			Expect(markdown).To(ContainSubstring("✅ No differences found"))
+
+			html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			results := decodeEmbeddedResults(html)
+			Expect(results).To(HaveLen(1))
+			Expect(results[0]["owned"]).To(ContainElement(HaveKeyWithValue("kind", "Pod")))
+		})
+
+		It("should compare generated resources one by one when expanded", func() {
+			config.ExpandOwned = true
+			comparison := compareClusters(config)
+
+			Expect(comparison.StatusCounts()).To(Equal(map[string]int{statusIdentical: 1, statusOnlyInA: 4, statusOnlyInB: 3}))
+			Expect(comparison.OwnedDifferences()).To(BeEmpty())
+
+			html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			results := decodeEmbeddedResults(html)
+			Expect(results).To(HaveLen(8))
+			for _, result := range results {
+				Expect(result).NotTo(HaveKey("owned"))
+			}
+		})
+	})
+})
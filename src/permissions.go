@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// permissionCheck is the outcome of a list permission check for one resource scope
+type permissionCheck struct {
+	ResourceScope
+	Allowed bool
+	Reason  string
+}
+
+// checkListPermissions asks the API server, via SelfSubjectAccessReview, whether the
+// current user may list every selected resource type in every selected namespace
+func checkListPermissions(ctx context.Context, client kubernetes.Interface, apiResources []selectedAPIResource, namespaces []string) ([]permissionCheck, error) {
+	var checks []permissionCheck
+
+	for _, apiResource := range apiResources {
+		for _, scope := range apiResource.scopesFor(namespaces) {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: scope.Namespace,
+						Verb:      "list",
+						Group:     scope.Group,
+						Resource:  scope.Resource,
+					},
+				},
+			}
+
+			result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to review list permission for %s: %w", scope, err)
+			}
+
+			checks = append(checks, permissionCheck{
+				ResourceScope: scope,
+				Allowed:       result.Status.Allowed,
+				Reason:        result.Status.Reason,
+			})
+		}
+	}
+
+	return checks, nil
+}
+
+// deniedScopes returns the scopes the user is not allowed to list
+func deniedScopes(checks []permissionCheck) []SkippedScope {
+	var denied []SkippedScope
+	for _, check := range checks {
+		if !check.Allowed {
+			denied = append(denied, SkippedScope{ResourceScope: check.ResourceScope, Reason: skipReasonForbidden})
+		}
+	}
+	return denied
+}
+
+// formatPermissionMatrix renders the permission checks as a resource × namespace table.
+// Cluster-scoped resources have a single entry in the "(cluster)" column.
+func formatPermissionMatrix(checks []permissionCheck, namespaces []string) string {
+	const clusterColumn = "(cluster)"
+
+	var rows []string
+	cells := make(map[string]map[string]string)
+	hasClusterScoped := false
+
+	for _, check := range checks {
+		row := check.Resource
+		if check.Group != "" {
+			row += "." + check.Group
+		}
+		if _, ok := cells[row]; !ok {
+			rows = append(rows, row)
+			cells[row] = make(map[string]string)
+		}
+
+		column := check.Namespace
+		if column == "" {
+			column = clusterColumn
+			hasClusterScoped = true
+		}
+
+		if check.Allowed {
+			cells[row][column] = "✓"
+		} else {
+			cells[row][column] = "✗"
+		}
+	}
+
+	columns := append([]string{}, namespaces...)
+	if hasClusterScoped {
+		columns = append(columns, clusterColumn)
+	}
+
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "   RESOURCE\t%s\t\n", strings.Join(columns, "\t"))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = cells[row][column]
+			if values[i] == "" {
+				values[i] = "-"
+			}
+		}
+		fmt.Fprintf(writer, "   %s\t%s\t\n", row, strings.Join(values, "\t"))
+	}
+	writer.Flush()
+
+	return builder.String()
+}
+
+// runPermissionPreflight checks list permissions for a cluster before fetching, prints
+// the permission matrix and records denied scopes so they are reported as not compared.
+// When the access reviews themselves cannot be performed the preflight is skipped and
+// any forbidden list calls are detected during the fetch instead.
+func runPermissionPreflight(out io.Writer, cluster *ClusterConfig, clusterName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	apiResources, err := discoverAPIResources(cluster.Kubeconfig, cluster.Context, cluster.Resources)
+	if err != nil {
+		fmt.Fprintf(out, "⚠️  Warning: Skipping permission preflight for %s: %v\n", clusterName, err)
+		return
+	}
+
+	client, err := getKubernetesClient(cluster.Kubeconfig, cluster.Context)
+	if err != nil {
+		fmt.Fprintf(out, "⚠️  Warning: Skipping permission preflight for %s: %v\n", clusterName, err)
+		return
+	}
+
+	checks, err := checkListPermissions(ctx, client, apiResources, cluster.Namespaces)
+	if err != nil {
+		fmt.Fprintf(out, "⚠️  Warning: Skipping permission preflight for %s: %v\n", clusterName, err)
+		return
+	}
+
+	fmt.Fprintf(out, "🔐 List permissions for %s (%s):\n", clusterName, cluster.Context)
+	fmt.Fprint(out, formatPermissionMatrix(checks, cluster.Namespaces))
+
+	cluster.Skipped = deniedScopes(checks)
+	if len(cluster.Skipped) > 0 {
+		fmt.Fprintf(out, "🚫 %d resource/namespace combinations are not permitted and will be marked as not compared\n", len(cluster.Skipped))
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Permissions", func() {
+	podsResource := selectedAPIResource{
+		GVR:        schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Kind:       "Pod",
+		Namespaced: true,
+	}
+	secretsResource := selectedAPIResource{
+		GVR:        schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+		Kind:       "Secret",
+		Namespaced: true,
+	}
+	clusterRolesResource := selectedAPIResource{
+		GVR:        schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+		Kind:       "ClusterRole",
+		Namespaced: false,
+	}
+
+	// newReviewingClient returns a fake clientset that denies listing secrets in kube-system
+	newReviewingClient := func(reviewed *[]authorizationv1.ResourceAttributes) *fake.Clientset {
+		client := fake.NewSimpleClientset()
+		client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			*reviewed = append(*reviewed, *attributes)
+
+			review.Status.Allowed = !(attributes.Resource == "secrets" && attributes.Namespace == "kube-system")
+			if !review.Status.Allowed {
+				review.Status.Reason = "no RBAC policy matched"
+			}
+			return true, review, nil
+		})
+		return client
+	}
+
+	Describe("checkListPermissions function", func() {
+		It("should review list access for every resource and namespace", func() {
+			var reviewed []authorizationv1.ResourceAttributes
+			client := newReviewingClient(&reviewed)
+
+			checks, err := checkListPermissions(context.Background(), client,
+				[]selectedAPIResource{podsResource, secretsResource}, []string{"default", "kube-system"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checks).To(HaveLen(4))
+			Expect(reviewed).To(HaveLen(4))
+			for _, attributes := range reviewed {
+				Expect(attributes.Verb).To(Equal("list"))
+			}
+
+			denied := deniedScopes(checks)
+			Expect(denied).To(HaveLen(1))
+			Expect(denied[0].Resource).To(Equal("secrets"))
+			Expect(denied[0].Kind).To(Equal("Secret"))
+			Expect(denied[0].Namespace).To(Equal("kube-system"))
+			Expect(denied[0].Reason).To(Equal(skipReasonForbidden))
+		})
+
+		It("should review cluster-scoped resources once without a namespace", func() {
+			var reviewed []authorizationv1.ResourceAttributes
+			client := newReviewingClient(&reviewed)
+
+			checks, err := checkListPermissions(context.Background(), client,
+				[]selectedAPIResource{clusterRolesResource}, []string{"default", "kube-system"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checks).To(HaveLen(1))
+			Expect(reviewed[0].Namespace).To(BeEmpty())
+			Expect(reviewed[0].Group).To(Equal("rbac.authorization.k8s.io"))
+			Expect(checks[0].Allowed).To(BeTrue())
+		})
+	})
+
+	Describe("formatPermissionMatrix function", func() {
+		It("should render allowed and denied cells per namespace", func() {
+			checks := []permissionCheck{
+				{ResourceScope: ResourceScope{Resource: "pods", Kind: "Pod", Namespace: "default"}, Allowed: true},
+				{ResourceScope: ResourceScope{Resource: "pods", Kind: "Pod", Namespace: "kube-system"}, Allowed: true},
+				{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "default"}, Allowed: true},
+				{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "kube-system"}, Allowed: false},
+			}
+
+			matrix := formatPermissionMatrix(checks, []string{"default", "kube-system"})
+			Expect(matrix).To(ContainSubstring("RESOURCE"))
+			Expect(matrix).To(MatchRegexp(`pods\s+✓\s+✓`))
+			Expect(matrix).To(MatchRegexp(`secrets\s+✓\s+✗`))
+			Expect(matrix).NotTo(ContainSubstring("(cluster)"))
+		})
+
+		It("should add a cluster column for cluster-scoped resources", func() {
+			checks := []permissionCheck{
+				{ResourceScope: ResourceScope{Resource: "pods", Kind: "Pod", Namespace: "default"}, Allowed: true},
+				{ResourceScope: ResourceScope{Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Kind: "ClusterRole"}, Allowed: false},
+			}
+
+			matrix := formatPermissionMatrix(checks, []string{"default"})
+			Expect(matrix).To(ContainSubstring("(cluster)"))
+			Expect(matrix).To(MatchRegexp(`pods\s+✓\s+-`))
+			Expect(matrix).To(MatchRegexp(`clusterroles\.rbac\.authorization\.k8s\.io\s+-\s+✗`))
+		})
+	})
+})
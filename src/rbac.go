@@ -0,0 +1,383 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// rbacResources are the resource types the RBAC analysis is computed from
+var rbacResources = []string{"roles", "rolebindings", "clusterroles", "clusterrolebindings"}
+
+// rbacClusterWide is the namespace of permissions granted in every namespace by a
+// ClusterRoleBinding
+const rbacClusterWide = "*"
+
+// rbacClusterScope is the namespace of permissions on cluster-scoped resources, which
+// only ClusterRoleBindings grant
+const rbacClusterScope = "(cluster)"
+
+// rbacClusterScopedResources are the built-in cluster-scoped resource types, named like
+// rbacGrant.String names them. Cluster-scoped custom resources are found through their
+// CustomResourceDefinitions.
+var rbacClusterScopedResources = []string{
+	"nodes", "namespaces", "persistentvolumes", "componentstatuses",
+	"clusterroles.rbac.authorization.k8s.io", "clusterrolebindings.rbac.authorization.k8s.io",
+	"storageclasses.storage.k8s.io", "csidrivers.storage.k8s.io", "csinodes.storage.k8s.io", "volumeattachments.storage.k8s.io",
+	"customresourcedefinitions.apiextensions.k8s.io", "apiservices.apiregistration.k8s.io",
+	"mutatingwebhookconfigurations.admissionregistration.k8s.io", "validatingwebhookconfigurations.admissionregistration.k8s.io",
+	"validatingadmissionpolicies.admissionregistration.k8s.io", "validatingadmissionpolicybindings.admissionregistration.k8s.io",
+	"priorityclasses.scheduling.k8s.io", "runtimeclasses.node.k8s.io", "ingressclasses.networking.k8s.io",
+	"certificatesigningrequests.certificates.k8s.io",
+	"flowschemas.flowcontrol.apiserver.k8s.io", "prioritylevelconfigurations.flowcontrol.apiserver.k8s.io",
+	"tokenreviews.authentication.k8s.io", "selfsubjectreviews.authentication.k8s.io",
+	"subjectaccessreviews.authorization.k8s.io", "selfsubjectaccessreviews.authorization.k8s.io", "selfsubjectrulesreviews.authorization.k8s.io",
+}
+
+// RBACPermissionChange is a resource on which a subject has different effective verbs in
+// the two clusters; a missing side has no verbs
+type RBACPermissionChange struct {
+	// Namespace is "*" for permissions in all namespaces, "(cluster)" for permissions on
+	// cluster-scoped resources and empty for namespaced ones when namespaces are not compared
+	Namespace string   `json:"namespace"`
+	Resource  string   `json:"resource"`
+	VerbsA    []string `json:"verbsA"`
+	VerbsB    []string `json:"verbsB"`
+}
+
+// RBACSubjectComparison compares the effective permissions of one user, group or
+// service account in both clusters
+type RBACSubjectComparison struct {
+	Subject string                 `json:"subject"`
+	Status  string                 `json:"status"`
+	Changes []RBACPermissionChange `json:"changes,omitempty"`
+}
+
+// rbacGrant is what a policy rule grants verbs on: a resource, optionally restricted to
+// one object name, in a namespace, or a non-resource URL
+type rbacGrant struct {
+	Namespace string
+	Group     string
+	Resource  string
+	Name      string
+	URL       string
+}
+
+// rbacPermissions maps the grants of a subject to the verbs granted on them
+type rbacPermissions map[rbacGrant]map[string]bool
+
+// withRBACResources adds the resource types the RBAC analysis needs to a selection
+func withRBACResources(resources []string) []string {
+	result := append([]string{}, resources...)
+	for _, resource := range rbacResources {
+		if !contains(result, resource) {
+			result = append(result, resource)
+		}
+	}
+	return result
+}
+
+// effectivePermissions computes the effective verbs of every subject from the Roles,
+// ClusterRoles, RoleBindings and ClusterRoleBindings among the resources. Equivalent
+// policies give the same result whichever way they are split into roles, rules and
+// bindings: rules are expanded per API group and resource, verbs are merged, "*" absorbs
+// the other verbs and verbs already granted by a broader grant are dropped. Bindings to
+// roles that do not exist grant nothing, and neither do RoleBindings on cluster-scoped
+// resources.
+func effectivePermissions(resources []map[string]interface{}, useNamespace bool) map[string]rbacPermissions {
+	clusterScoped := make(map[string]bool)
+	for _, resource := range rbacClusterScopedResources {
+		clusterScoped[resource] = true
+	}
+	roles := make(map[string][]rbacv1.PolicyRule)
+	clusterRoles := make(map[string][]rbacv1.PolicyRule)
+	var roleBindings []rbacv1.RoleBinding
+	var clusterRoleBindings []rbacv1.ClusterRoleBinding
+
+	for _, resource := range resources {
+		switch resourceIdentity(resource).Kind {
+		case "Role":
+			var role rbacv1.Role
+			if runtime.DefaultUnstructuredConverter.FromUnstructured(resource, &role) == nil {
+				roles[role.Namespace+"/"+role.Name] = role.Rules
+			}
+		case "ClusterRole":
+			var role rbacv1.ClusterRole
+			if runtime.DefaultUnstructuredConverter.FromUnstructured(resource, &role) == nil {
+				clusterRoles[role.Name] = role.Rules
+			}
+		case "RoleBinding":
+			var binding rbacv1.RoleBinding
+			if runtime.DefaultUnstructuredConverter.FromUnstructured(resource, &binding) == nil {
+				roleBindings = append(roleBindings, binding)
+			}
+		case "ClusterRoleBinding":
+			var binding rbacv1.ClusterRoleBinding
+			if runtime.DefaultUnstructuredConverter.FromUnstructured(resource, &binding) == nil {
+				clusterRoleBindings = append(clusterRoleBindings, binding)
+			}
+		case "CustomResourceDefinition":
+			spec, _ := resource["spec"].(map[string]interface{})
+			names, _ := spec["names"].(map[string]interface{})
+			group, _ := spec["group"].(string)
+			plural, _ := names["plural"].(string)
+			if spec["scope"] == "Cluster" && plural != "" {
+				clusterScoped[rbacGrant{Group: group, Resource: plural}.String()] = true
+			}
+		}
+	}
+
+	permissions := make(map[string]rbacPermissions)
+	grant := func(subjects []rbacv1.Subject, bindingNamespace string, rules []rbacv1.PolicyRule, namespace string) {
+		for _, subject := range subjects {
+			key := rbacSubjectKey(subject, bindingNamespace, useNamespace)
+			if permissions[key] == nil {
+				permissions[key] = make(rbacPermissions)
+			}
+			for _, rule := range rules {
+				permissions[key].add(rule, namespace, clusterScoped)
+			}
+		}
+	}
+
+	for _, binding := range roleBindings {
+		var rules []rbacv1.PolicyRule
+		switch binding.RoleRef.Kind {
+		case "Role":
+			rules = roles[binding.Namespace+"/"+binding.RoleRef.Name]
+		case "ClusterRole":
+			rules = clusterRoles[binding.RoleRef.Name]
+		}
+		namespace := ""
+		if useNamespace {
+			namespace = binding.Namespace
+		}
+		// Non-resource URLs are not namespaced, so only ClusterRoleBindings grant them
+		var resourceRules []rbacv1.PolicyRule
+		for _, rule := range rules {
+			if len(rule.NonResourceURLs) == 0 {
+				resourceRules = append(resourceRules, rule)
+			}
+		}
+		grant(binding.Subjects, binding.Namespace, resourceRules, namespace)
+	}
+	for _, binding := range clusterRoleBindings {
+		if binding.RoleRef.Kind == "ClusterRole" {
+			grant(binding.Subjects, "", clusterRoles[binding.RoleRef.Name], rbacClusterWide)
+		}
+	}
+
+	for key, granted := range permissions {
+		permissions[key] = granted.withoutRedundantVerbs()
+		if len(permissions[key]) == 0 {
+			delete(permissions, key)
+		}
+	}
+	return permissions
+}
+
+// rbacSubjectKey identifies a subject across clusters, e.g. "User alice" or
+// "ServiceAccount default/builder"
+func rbacSubjectKey(subject rbacv1.Subject, bindingNamespace string, useNamespace bool) string {
+	if subject.Kind != rbacv1.ServiceAccountKind || !useNamespace {
+		return subject.Kind + " " + subject.Name
+	}
+	namespace := subject.Namespace
+	if namespace == "" {
+		namespace = bindingNamespace
+	}
+	return subject.Kind + " " + namespace + "/" + subject.Name
+}
+
+// add expands a policy rule into the grants it covers in a namespace. Cluster-scoped
+// resources, named like rbacGrant.String, are only granted cluster-wide, and then under
+// the rbacClusterScope namespace.
+func (p rbacPermissions) add(rule rbacv1.PolicyRule, namespace string, clusterScoped map[string]bool) {
+	var grants []rbacGrant
+	for _, url := range rule.NonResourceURLs {
+		grants = append(grants, rbacGrant{Namespace: rbacClusterWide, URL: url})
+	}
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			grantNamespace := namespace
+			// Subresources such as nodes/proxy share the scope of their resource
+			base, _, _ := strings.Cut(resource, "/")
+			if clusterScoped[rbacGrant{Group: group, Resource: base}.String()] {
+				if namespace != rbacClusterWide {
+					continue
+				}
+				grantNamespace = rbacClusterScope
+			}
+			if len(rule.ResourceNames) == 0 {
+				grants = append(grants, rbacGrant{Namespace: grantNamespace, Group: group, Resource: resource})
+			}
+			for _, name := range rule.ResourceNames {
+				grants = append(grants, rbacGrant{Namespace: grantNamespace, Group: group, Resource: resource, Name: name})
+			}
+		}
+	}
+
+	for _, grant := range grants {
+		if p[grant] == nil {
+			p[grant] = make(map[string]bool)
+		}
+		for _, verb := range rule.Verbs {
+			p[grant][verb] = true
+		}
+	}
+}
+
+// withoutRedundantVerbs returns the permissions without the verbs that a broader grant
+// already gives, and with "*" standing alone
+func (p rbacPermissions) withoutRedundantVerbs() rbacPermissions {
+	result := make(rbacPermissions)
+	for grant, verbs := range p {
+		remaining := make(map[string]bool)
+		for verb := range verbs {
+			if verbs["*"] && verb != "*" {
+				continue
+			}
+			covered := false
+			for other, otherVerbs := range p {
+				if other != grant && other.covers(grant) && (otherVerbs[verb] || otherVerbs["*"]) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				remaining[verb] = true
+			}
+		}
+		if len(remaining) > 0 {
+			result[grant] = remaining
+		}
+	}
+	return result
+}
+
+// covers reports whether a grant includes everything another grant gives access to
+func (g rbacGrant) covers(other rbacGrant) bool {
+	if g.URL != "" || other.URL != "" {
+		if g.URL == "" || other.URL == "" {
+			return false
+		}
+		return g.URL == other.URL || (strings.HasSuffix(g.URL, "*") && strings.HasPrefix(other.URL, strings.TrimSuffix(g.URL, "*")))
+	}
+	matches := func(pattern, value string) bool { return pattern == "*" || pattern == value }
+	return (g.Namespace == rbacClusterWide || g.Namespace == other.Namespace) &&
+		matches(g.Group, other.Group) && matches(g.Resource, other.Resource) &&
+		(g.Name == "" || g.Name == other.Name)
+}
+
+// String names the resource of a grant like kubectl does, e.g. "deployments.apps",
+// "configmaps/settings" for a single object or "/healthz" for a non-resource URL
+func (g rbacGrant) String() string {
+	if g.URL != "" {
+		return g.URL
+	}
+	resource := g.Resource
+	if g.Group != "" {
+		resource += "." + g.Group
+	}
+	if g.Name != "" {
+		resource += "/" + g.Name
+	}
+	return resource
+}
+
+// compareRBAC compares the effective permissions of every subject in both clusters
+func compareRBAC(config *ComparisonConfig) []RBACSubjectComparison {
+	permissionsA := effectivePermissions(config.ClusterA.Data, config.CompareNamespaces)
+	permissionsB := effectivePermissions(config.ClusterB.Data, config.CompareNamespaces)
+
+	subjects := make(map[string]bool)
+	for subject := range permissionsA {
+		subjects[subject] = true
+	}
+	for subject := range permissionsB {
+		subjects[subject] = true
+	}
+
+	var comparisons []RBACSubjectComparison
+	for _, subject := range sortedKeys(subjects) {
+		grantedA, inA := permissionsA[subject]
+		grantedB, inB := permissionsB[subject]
+
+		comparison := RBACSubjectComparison{Subject: subject, Status: statusIdentical}
+		switch {
+		case !inB:
+			comparison.Status = statusOnlyInA
+		case !inA:
+			comparison.Status = statusOnlyInB
+		}
+		comparison.Changes = rbacPermissionChanges(grantedA, grantedB)
+		if comparison.Status == statusIdentical && len(comparison.Changes) > 0 {
+			comparison.Status = statusDifferent
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+// rbacPermissionChanges lists the grants whose verbs differ, ordered by namespace and resource
+func rbacPermissionChanges(grantedA, grantedB rbacPermissions) []RBACPermissionChange {
+	grants := make(map[rbacGrant]bool)
+	for grant := range grantedA {
+		grants[grant] = true
+	}
+	for grant := range grantedB {
+		grants[grant] = true
+	}
+
+	var changes []RBACPermissionChange
+	for grant := range grants {
+		verbsA, verbsB := sortedKeys(grantedA[grant]), sortedKeys(grantedB[grant])
+		if strings.Join(verbsA, ",") == strings.Join(verbsB, ",") {
+			continue
+		}
+		changes = append(changes, RBACPermissionChange{Namespace: grant.Namespace, Resource: grant.String(), VerbsA: verbsA, VerbsB: verbsB})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Resource < changes[j].Resource
+	})
+	return changes
+}
+
+// String describes a permission change, e.g. "deployments.apps in default: get, list → get".
+// Cluster-scoped resources and non-resource URLs have no namespace.
+func (c RBACPermissionChange) String() string {
+	verbs := func(list []string) string {
+		if len(list) == 0 {
+			return "-"
+		}
+		return strings.Join(list, ", ")
+	}
+
+	scope := c.Resource
+	switch {
+	case strings.HasPrefix(c.Resource, "/"), c.Namespace == rbacClusterScope:
+	case c.Namespace == rbacClusterWide:
+		scope += " in all namespaces"
+	case c.Namespace != "":
+		scope += " in " + c.Namespace
+	}
+	return scope + ": " + verbs(c.VerbsA) + " → " + verbs(c.VerbsB)
+}
+
+// differentRBACSubjects returns the subjects whose effective permissions differ or that
+// have permissions in one cluster only
+func differentRBACSubjects(subjects []RBACSubjectComparison) []RBACSubjectComparison {
+	var different []RBACSubjectComparison
+	for _, subject := range subjects {
+		if subject.Status != statusIdentical {
+			different = append(different, subject)
+		}
+	}
+	return different
+}
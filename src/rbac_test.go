@@ -0,0 +1,230 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testRole returns a Role, or a ClusterRole when namespace is empty, with the given rules
+func testRole(namespace, name string, rules ...map[string]interface{}) map[string]interface{} {
+	kind := "Role"
+	if namespace == "" {
+		kind = "ClusterRole"
+	}
+	role := testResource(kind, namespace, name, nil)
+	delete(role, "spec")
+	role["apiVersion"] = "rbac.authorization.k8s.io/v1"
+	list := make([]interface{}, len(rules))
+	for i, rule := range rules {
+		list[i] = rule
+	}
+	role["rules"] = list
+	return role
+}
+
+// testRule returns a policy rule on resources of one API group
+func testRule(group string, resources, verbs []string) map[string]interface{} {
+	return map[string]interface{}{"apiGroups": []interface{}{group}, "resources": toInterfaces(resources), "verbs": toInterfaces(verbs)}
+}
+
+// testBinding returns a RoleBinding, or a ClusterRoleBinding when namespace is empty,
+// granting a role to subjects given as kind/name pairs
+func testBinding(namespace, name, roleKind, roleName string, subjects ...string) map[string]interface{} {
+	kind := "RoleBinding"
+	if namespace == "" {
+		kind = "ClusterRoleBinding"
+	}
+	binding := testResource(kind, namespace, name, nil)
+	delete(binding, "spec")
+	binding["apiVersion"] = "rbac.authorization.k8s.io/v1"
+	binding["roleRef"] = map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": roleKind, "name": roleName}
+	var list []interface{}
+	for i := 0; i+1 < len(subjects); i += 2 {
+		list = append(list, map[string]interface{}{"kind": subjects[i], "name": subjects[i+1]})
+	}
+	binding["subjects"] = list
+	return binding
+}
+
+// toInterfaces converts strings to the generic form of decoded JSON
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+var _ = Describe("RBAC", func() {
+	Describe("effectivePermissions function", func() {
+		It("should give the same result for equivalent policies", func() {
+			split := effectivePermissions([]map[string]interface{}{
+				testRole("default", "read-pods", testRule("", []string{"pods"}, []string{"get"})),
+				testRole("default", "list-pods", testRule("", []string{"pods"}, []string{"list", "get"})),
+				testBinding("default", "read", "Role", "read-pods", "User", "alice"),
+				testBinding("default", "list", "Role", "list-pods", "User", "alice"),
+			}, true)
+			combined := effectivePermissions([]map[string]interface{}{
+				testRole("", "pod-reader", testRule("", []string{"pods"}, []string{"get", "list"})),
+				testBinding("default", "read", "ClusterRole", "pod-reader", "User", "alice"),
+			}, true)
+
+			Expect(split).To(Equal(combined))
+			Expect(split["User alice"]).To(Equal(rbacPermissions{
+				{Namespace: "default", Resource: "pods"}: {"get": true, "list": true},
+			}))
+		})
+
+		It("should drop verbs granted by broader grants and let * stand alone", func() {
+			permissions := effectivePermissions([]map[string]interface{}{
+				testRole("", "admin", testRule("apps", []string{"*"}, []string{"*", "get"})),
+				testRole("default", "deployer", testRule("apps", []string{"deployments"}, []string{"get", "update"})),
+				testBinding("", "admin", "ClusterRole", "admin", "Group", "ops"),
+				testBinding("default", "deployer", "Role", "deployer", "Group", "ops"),
+			}, true)
+
+			Expect(permissions["Group ops"]).To(Equal(rbacPermissions{
+				{Namespace: rbacClusterWide, Group: "apps", Resource: "*"}: {"*": true},
+			}))
+		})
+
+		It("should grant nothing through bindings to missing roles", func() {
+			permissions := effectivePermissions([]map[string]interface{}{
+				testBinding("default", "orphan", "Role", "missing", "User", "alice"),
+			}, true)
+			Expect(permissions).To(BeEmpty())
+		})
+
+		It("should grant cluster-scoped resources only through ClusterRoleBindings", func() {
+			crd := testResource("CustomResourceDefinition", "", "clusterissuers.cert-manager.io", nil)
+			crd["spec"] = map[string]interface{}{
+				"group": "cert-manager.io", "scope": "Cluster",
+				"names": map[string]interface{}{"plural": "clusterissuers"},
+			}
+			permissions := effectivePermissions([]map[string]interface{}{
+				crd,
+				testRole("", "viewer", testRule("", []string{"nodes", "nodes/proxy", "pods"}, []string{"get"})),
+				testRole("", "issuers", testRule("cert-manager.io", []string{"clusterissuers"}, []string{"get"})),
+				testBinding("", "viewer", "ClusterRole", "viewer", "User", "alice"),
+				testBinding("", "issuers", "ClusterRole", "issuers", "User", "alice"),
+				testBinding("default", "viewer", "ClusterRole", "viewer", "User", "bob"),
+			}, true)
+
+			Expect(permissions["User alice"]).To(Equal(rbacPermissions{
+				{Namespace: rbacClusterScope, Resource: "nodes"}:                                    {"get": true},
+				{Namespace: rbacClusterScope, Resource: "nodes/proxy"}:                              {"get": true},
+				{Namespace: rbacClusterScope, Group: "cert-manager.io", Resource: "clusterissuers"}: {"get": true},
+				{Namespace: rbacClusterWide, Resource: "pods"}:                                      {"get": true},
+			}))
+			Expect(permissions["User bob"]).To(Equal(rbacPermissions{
+				{Namespace: "default", Resource: "pods"}: {"get": true},
+			}))
+		})
+
+		It("should key service accounts by the namespace of the binding when namespaces are compared", func() {
+			resources := []map[string]interface{}{
+				testRole("ci", "builder", testRule("", []string{"configmaps"}, []string{"get"})),
+				testBinding("ci", "builder", "Role", "builder", "ServiceAccount", "builder"),
+			}
+
+			Expect(effectivePermissions(resources, true)).To(HaveKey("ServiceAccount ci/builder"))
+			Expect(effectivePermissions(resources, false)).To(Equal(map[string]rbacPermissions{
+				"ServiceAccount builder": {{Resource: "configmaps"}: {"get": true}},
+			}))
+		})
+	})
+
+	Describe("compareRBAC function", func() {
+		var config *ComparisonConfig
+
+		BeforeEach(func() {
+			config = &ComparisonConfig{
+				ClusterA: ClusterConfig{Data: []map[string]interface{}{
+					testRole("", "view", testRule("", []string{"pods", "configmaps"}, []string{"get", "list"})),
+					testRole("", "metrics", map[string]interface{}{"nonResourceURLs": []interface{}{"/metrics"}, "verbs": []interface{}{"get"}}),
+					testBinding("", "view", "ClusterRole", "view", "User", "alice", "User", "bob"),
+					testBinding("", "metrics", "ClusterRole", "metrics", "User", "prometheus"),
+				}},
+				ClusterB: ClusterConfig{Data: []map[string]interface{}{
+					testRole("", "view", testRule("", []string{"pods"}, []string{"get", "list", "watch"})),
+					testBinding("", "view", "ClusterRole", "view", "User", "alice"),
+					testRole("prod", "view", testRule("", []string{"pods", "configmaps"}, []string{"get", "list"})),
+					testBinding("prod", "view", "Role", "view", "User", "bob"),
+				}},
+				CompareNamespaces: true,
+				AnalyzeRBAC:       true,
+			}
+		})
+
+		It("should report the subjects whose effective permissions differ", func() {
+			subjects := compareRBAC(config)
+
+			Expect(subjects).To(Equal([]RBACSubjectComparison{
+				{Subject: "User alice", Status: statusDifferent, Changes: []RBACPermissionChange{
+					{Namespace: "*", Resource: "configmaps", VerbsA: []string{"get", "list"}, VerbsB: []string{}},
+					{Namespace: "*", Resource: "pods", VerbsA: []string{"get", "list"}, VerbsB: []string{"get", "list", "watch"}},
+				}},
+				{Subject: "User bob", Status: statusDifferent, Changes: []RBACPermissionChange{
+					{Namespace: "*", Resource: "configmaps", VerbsA: []string{"get", "list"}, VerbsB: []string{}},
+					{Namespace: "*", Resource: "pods", VerbsA: []string{"get", "list"}, VerbsB: []string{}},
+					{Namespace: "prod", Resource: "configmaps", VerbsA: []string{}, VerbsB: []string{"get", "list"}},
+					{Namespace: "prod", Resource: "pods", VerbsA: []string{}, VerbsB: []string{"get", "list"}},
+				}},
+				{Subject: "User prometheus", Status: statusOnlyInA, Changes: []RBACPermissionChange{
+					{Namespace: "*", Resource: "/metrics", VerbsA: []string{"get"}, VerbsB: []string{}},
+				}},
+			}))
+		})
+
+		It("should describe permission changes", func() {
+			Expect(RBACPermissionChange{Namespace: "*", Resource: "pods", VerbsA: []string{"get"}}.String()).To(Equal("pods in all namespaces: get → -"))
+			Expect(RBACPermissionChange{Namespace: "prod", Resource: "deployments.apps", VerbsB: []string{"*"}}.String()).To(Equal("deployments.apps in prod: - → *"))
+			Expect(RBACPermissionChange{Namespace: "*", Resource: "/metrics", VerbsA: []string{"get"}}.String()).To(Equal("/metrics: get → -"))
+			Expect(RBACPermissionChange{Namespace: rbacClusterScope, Resource: "nodes", VerbsA: []string{"get"}}.String()).To(Equal("nodes: get → -"))
+		})
+
+		It("should be part of the comparison and its reports when requested", func() {
+			comparison := compareClusters(config)
+			Expect(comparison.RBAC).To(HaveLen(3))
+
+			report := buildDiffReport(config, comparison, time.Now())
+			Expect(report.RBAC).To(Equal(comparison.RBAC))
+
+			var text strings.Builder
+			Expect(renderTextReport(&text, config, comparison, false)).To(Succeed())
+			Expect(text.String()).To(ContainSubstring("🔐 RBAC (3 subjects, 3 differ)"))
+			Expect(text.String()).To(ContainSubstring("User prometheus (only-in-A)\n   /metrics: get → -"))
+
+			markdown, err := renderMarkdownReport(config, comparison)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(markdown).To(ContainSubstring("| User alice | different | `configmaps in all namespaces: get, list → -`<br>`pods in all namespaces: get, list → get, list, watch` |"))
+
+			html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(html).To(ContainSubstring(`onclick="showTab('rbac')"`))
+			Expect(html).To(ContainSubstring(`<li><code>pods in prod: - → get, list</code></li>`))
+			Expect(html).To(ContainSubstring("3 of 3 subjects have different effective permissions."))
+		})
+
+		It("should leave the analysis out unless requested", func() {
+			config.AnalyzeRBAC = false
+			comparison := compareClusters(config)
+			Expect(comparison.RBAC).To(BeNil())
+
+			html, err := generateHTMLTemplate(config, comparison, "2024-01-01_10:00:00")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(html).NotTo(ContainSubstring("showTab('rbac')"))
+		})
+	})
+
+	Describe("withRBACResources function", func() {
+		It("should add the missing RBAC resource types without changing the selection", func() {
+			selected := []string{"pods", "roles"}
+			Expect(withRBACResources(selected)).To(Equal([]string{"pods", "roles", "rolebindings", "clusterroles", "clusterrolebindings"}))
+			Expect(selected).To(Equal([]string{"pods", "roles"}))
+		})
+	})
+})
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Remediation formats: full manifests for server-side apply, or JSON merge patches
+const (
+	remediationApply      = "apply"
+	remediationMergePatch = "merge-patch"
+)
+
+// remediationScript is the script listing the commands that apply a remediation directory
+const remediationScript = "apply.sh"
+
+// remediationFieldManager is the field manager recorded by server-side apply
+const remediationFieldManager = "k8s-compare"
+
+// remediationDroppedFields are fields assigned by the API server or another controller;
+// copying them from Cluster A would be rejected or fight the owner of the field
+var remediationDroppedFields = [][]string{
+	{"status"},
+	{"metadata", "selfLink"},
+	{"metadata", "ownerReferences"},
+	{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"},
+	{"metadata", "annotations", "deployment.kubernetes.io/revision"},
+	{"spec", "clusterIP"},
+	{"spec", "clusterIPs"},
+}
+
+// remediationSummary counts the files written for a comparison
+type remediationSummary struct {
+	Manifests int
+	Patches   int
+	Extra     int
+}
+
+// writeRemediation writes, for each resource that differs or is missing from Cluster B,
+// the change that brings it in line with Cluster A, ignoring the ignored fields:
+//
+//   - <dir>/manifests/<namespace>/<group.kind>/<name>.yaml: full manifests for
+//     `kubectl apply --server-side`, for missing resources and, in apply format, for
+//     differing ones
+//   - <dir>/patches/<namespace>/<group.kind>/<name>.json: JSON merge patches for differing
+//     resources in merge-patch format
+//   - <dir>/apply.sh: the kubectl commands applying them to Cluster B's context, followed
+//     by commented-out deletions of the resources only in Cluster B
+//
+// Nothing is applied; the directory is meant to be reviewed first.
+func writeRemediation(dir, format string, config *ComparisonConfig, comparison *Comparison) (remediationSummary, error) {
+	var summary remediationSummary
+	if format != remediationApply && format != remediationMergePatch {
+		return summary, fmt.Errorf("invalid remediation format %q: must be %s or %s", format, remediationApply, remediationMergePatch)
+	}
+
+	manifestsDir := filepath.Join(dir, "manifests")
+	patchesDir := filepath.Join(dir, "patches")
+	for _, path := range []string{manifestsDir, patchesDir, filepath.Join(dir, remediationScript)} {
+		if err := os.RemoveAll(path); err != nil {
+			return summary, fmt.Errorf("failed to clear %s: %w", path, err)
+		}
+	}
+
+	kubectl := "kubectl --context " + shellQuote(config.ClusterB.Context)
+	var commands, deletions []string
+
+	for _, kind := range comparison.Kinds {
+		for _, resource := range kind.Resources {
+			switch {
+			case resource.Status == statusOnlyInA:
+				path, err := writeRemediationManifest(manifestsDir, desiredObject(resource))
+				if err != nil {
+					return summary, err
+				}
+				summary.Manifests++
+				commands = append(commands, applyCommand(kubectl, dir, path))
+
+			case resource.Status == statusDifferent && format == remediationApply:
+				desired := desiredObject(resource)
+				if reflect.DeepEqual(desired, remediationObject(resource.ObjectB)) {
+					continue
+				}
+				path, err := writeRemediationManifest(manifestsDir, desired)
+				if err != nil {
+					return summary, err
+				}
+				summary.Manifests++
+				commands = append(commands, applyCommand(kubectl, dir, path))
+
+			case resource.Status == statusDifferent:
+				target := resourceIdentity(resource.ObjectB)
+				patch := mergePatch(withoutNamespace(remediationObject(resource.ObjectB)), withoutNamespace(remediationObject(resource.ObjectA)))
+				if patch == nil {
+					continue
+				}
+				path, err := writeMergePatch(patchesDir, resource.ObjectB, patch)
+				if err != nil {
+					return summary, err
+				}
+				summary.Patches++
+				commands = append(commands, fmt.Sprintf("%s patch %s --type merge --patch-file %s",
+					kubectlTarget(kubectl, target), shellQuote(kubectlResourceName(target)+"/"+target.Name), shellQuote(relativeTo(dir, path))))
+
+			case resource.Status == statusOnlyInB:
+				target := resourceIdentity(resource.ObjectB)
+				summary.Extra++
+				deletions = append(deletions, fmt.Sprintf("# %s delete %s", kubectlTarget(kubectl, target),
+					shellQuote(kubectlResourceName(target)+"/"+target.Name)))
+			}
+		}
+	}
+
+	if err := writeRemediationScript(dir, config, commands, deletions); err != nil {
+		return summary, err
+	}
+
+	fmt.Fprintf(config.progress(), "🩹 Wrote %d manifests and %d patches to make Cluster B match Cluster A to %s (review, then run %s)\n",
+		summary.Manifests, summary.Patches, dir, filepath.Join(dir, remediationScript))
+	return summary, nil
+}
+
+// remediationObject returns the comparable part of a resource without the fields that
+// cannot be copied to another cluster
+func remediationObject(object map[string]interface{}) map[string]interface{} {
+	cleaned := normalizeObject(object)
+	for _, path := range remediationDroppedFields {
+		removeNestedField(cleaned, path)
+	}
+	return cleaned
+}
+
+// desiredObject returns the manifest that brings a resource in Cluster B in line with
+// Cluster A. It targets the resource in Cluster B, which may live in another namespace
+// when namespaces are not compared.
+func desiredObject(resource ResourceComparison) map[string]interface{} {
+	desired := remediationObject(resource.ObjectA)
+	if resource.ObjectB != nil {
+		setNamespace(desired, resourceIdentity(resource.ObjectB).Namespace)
+	}
+	return desired
+}
+
+// removeNestedField deletes the field at path, dropping maps it leaves empty
+func removeNestedField(object map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(object, path[0])
+		return
+	}
+	child, ok := object[path[0]].(map[string]interface{})
+	if !ok {
+		return
+	}
+	removeNestedField(child, path[1:])
+	if len(child) == 0 {
+		delete(object, path[0])
+	}
+}
+
+// setNamespace sets the namespace of a namespaced resource
+func setNamespace(object map[string]interface{}, namespace string) {
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok && namespace != "" {
+		metadata["namespace"] = namespace
+	}
+}
+
+// withoutNamespace removes the namespace, which a patch must not change
+func withoutNamespace(object map[string]interface{}) map[string]interface{} {
+	removeNestedField(object, []string{"metadata", "namespace"})
+	return object
+}
+
+// mergePatch returns the RFC 7386 JSON merge patch turning current into desired, or nil
+// when they are equal. Lists cannot be merged, so a changed list is replaced as a whole.
+func mergePatch(current, desired map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for key, desiredValue := range desired {
+		currentValue, found := current[key]
+		if found && reflect.DeepEqual(currentValue, desiredValue) {
+			continue
+		}
+
+		currentMap, currentIsMap := currentValue.(map[string]interface{})
+		desiredMap, desiredIsMap := desiredValue.(map[string]interface{})
+		if found && currentIsMap && desiredIsMap {
+			patch[key] = mergePatch(currentMap, desiredMap)
+			continue
+		}
+		patch[key] = desiredValue
+	}
+	for key := range current {
+		if _, found := desired[key]; !found {
+			patch[key] = nil
+		}
+	}
+
+	if len(patch) == 0 {
+		return nil
+	}
+	return patch
+}
+
+// writeRemediationManifest writes a full manifest at its YAML export path under root
+func writeRemediationManifest(root string, object map[string]interface{}) (string, error) {
+	path := yamlExportPath(root, object)
+	content, err := yaml.Marshal(object)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s as YAML: %w", resourceIdentity(object), err)
+	}
+	return path, writeRemediationFile(path, content)
+}
+
+// writeMergePatch writes a merge patch next to where the target's manifest would be
+func writeMergePatch(root string, target, patch map[string]interface{}) (string, error) {
+	path := strings.TrimSuffix(yamlExportPath(root, target), ".yaml") + ".json"
+	content, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode patch for %s: %w", resourceIdentity(target), err)
+	}
+	return path, writeRemediationFile(path, append(content, '\n'))
+}
+
+// writeRemediationFile writes a file, creating its directory
+func writeRemediationFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeRemediationScript writes apply.sh, which runs the commands from its own directory
+func writeRemediationScript(dir string, config *ComparisonConfig, commands, deletions []string) error {
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&script, "# Brings Cluster B (%s) in line with Cluster A (%s).\n", config.ClusterB.Context, config.ClusterA.Context)
+	fmt.Fprintf(&script, "# Generated by k8s-compare on %s; review the manifests and patches before running it.\n", config.ReportTimestamp)
+	script.WriteString("set -e\ncd \"$(dirname \"$0\")\"\n")
+
+	if len(commands) > 0 {
+		script.WriteString("\n")
+		for _, command := range commands {
+			script.WriteString(command + "\n")
+		}
+	}
+	if len(deletions) > 0 {
+		script.WriteString("\n# Resources only in Cluster B; uncomment to delete them\n")
+		for _, deletion := range deletions {
+			script.WriteString(deletion + "\n")
+		}
+	}
+
+	if err := writeRemediationFile(filepath.Join(dir, remediationScript), []byte(script.String())); err != nil {
+		return err
+	}
+	return os.Chmod(filepath.Join(dir, remediationScript), 0755)
+}
+
+// applyCommand returns the server-side apply command for a manifest
+func applyCommand(kubectl, dir, path string) string {
+	return fmt.Sprintf("%s apply --server-side --field-manager=%s -f %s", kubectl, remediationFieldManager, shellQuote(relativeTo(dir, path)))
+}
+
+// kubectlTarget adds the namespace of a namespaced resource to a kubectl command
+func kubectlTarget(kubectl string, id ResourceIdentity) string {
+	if id.Namespace == "" {
+		return kubectl
+	}
+	return kubectl + " -n " + shellQuote(id.Namespace)
+}
+
+// kubectlResourceName returns the fully qualified kind kubectl resolves a resource by,
+// e.g. deployment.v1.apps, or configmap for the core group
+func kubectlResourceName(id ResourceIdentity) string {
+	kind := strings.ToLower(id.Kind)
+	group, version, found := strings.Cut(id.APIVersion, "/")
+	if !found {
+		return kind
+	}
+	return kind + "." + version + "." + group
+}
+
+// relativeTo returns path relative to dir, or path itself when it is not below dir
+func relativeTo(dir, path string) string {
+	relative, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return relative
+}
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Remediation", func() {
+	var tempDir string
+	var config *ComparisonConfig
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "k8s-compare-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		deploymentA := testResource("Deployment", "default", "web", map[string]interface{}{
+			"replicas": float64(3),
+			"paused":   true,
+		})
+		deploymentA["apiVersion"] = "apps/v1"
+		deploymentA["status"] = map[string]interface{}{"readyReplicas": float64(3)}
+		deploymentA["metadata"].(map[string]interface{})["resourceVersion"] = "100"
+
+		deploymentB := testResource("Deployment", "default", "web", map[string]interface{}{
+			"replicas": float64(1),
+			"selector": map[string]interface{}{"app": "web"},
+		})
+		deploymentB["apiVersion"] = "apps/v1"
+		deploymentB["status"] = map[string]interface{}{"readyReplicas": float64(1)}
+		deploymentB["metadata"].(map[string]interface{})["resourceVersion"] = "200"
+
+		statusOnlyA := testResource("ConfigMap", "default", "status-only", nil)
+		statusOnlyA["status"] = "a"
+		statusOnlyB := testResource("ConfigMap", "default", "status-only", nil)
+		statusOnlyB["status"] = "b"
+
+		config = &ComparisonConfig{
+			ClusterA: ClusterConfig{
+				Context: "staging",
+				Data: []map[string]interface{}{
+					deploymentA,
+					statusOnlyA,
+					testResource("ConfigMap", "default", "settings", map[string]interface{}{"debug": "true"}),
+				},
+			},
+			ClusterB: ClusterConfig{
+				Context: "prod's",
+				Data: []map[string]interface{}{
+					deploymentB,
+					statusOnlyB,
+					testResource("Secret", "default", "legacy", nil),
+				},
+			},
+			CompareNamespaces: true,
+			ReportTimestamp:   "2024-01-01_10:00:00",
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	readYAML := func(path string) map[string]interface{} {
+		content, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		var object map[string]interface{}
+		Expect(yaml.Unmarshal(content, &object)).To(Succeed())
+		return object
+	}
+
+	readScript := func() string {
+		content, err := os.ReadFile(filepath.Join(tempDir, remediationScript))
+		Expect(err).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	Describe("writeRemediation function", func() {
+		It("should write full manifests in apply format", func() {
+			summary, err := writeRemediation(tempDir, remediationApply, config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summary).To(Equal(remediationSummary{Manifests: 2, Extra: 1}))
+
+			deployment := readYAML(filepath.Join(tempDir, "manifests", "default", "apps.Deployment", "web.yaml"))
+			Expect(deployment["spec"]).To(Equal(map[string]interface{}{"replicas": float64(3), "paused": true}))
+			Expect(deployment).NotTo(HaveKey("status"))
+			Expect(deployment["metadata"]).To(Equal(map[string]interface{}{"name": "web", "namespace": "default"}))
+
+			Expect(filepath.Join(tempDir, "manifests", "default", "ConfigMap", "settings.yaml")).To(BeAnExistingFile())
+			Expect(filepath.Join(tempDir, "manifests", "default", "ConfigMap", "status-only.yaml")).NotTo(BeAnExistingFile())
+
+			script := readScript()
+			Expect(script).To(ContainSubstring(`kubectl --context 'prod'\''s' apply --server-side --field-manager=k8s-compare -f 'manifests/default/apps.Deployment/web.yaml'`))
+			Expect(script).To(ContainSubstring(`# kubectl --context 'prod'\''s' -n 'default' delete 'secret/legacy'`))
+
+			info, err := os.Stat(filepath.Join(tempDir, remediationScript))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm() & 0100).NotTo(BeZero())
+		})
+
+		It("should write merge patches for differing resources in merge-patch format", func() {
+			summary, err := writeRemediation(tempDir, remediationMergePatch, config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summary).To(Equal(remediationSummary{Manifests: 1, Patches: 1, Extra: 1}))
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "patches", "default", "apps.Deployment", "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(MatchJSON(`{"spec": {"replicas": 3, "paused": true, "selector": null}}`))
+
+			Expect(filepath.Join(tempDir, "manifests", "default", "ConfigMap", "settings.yaml")).To(BeAnExistingFile())
+			Expect(readScript()).To(ContainSubstring(`-n 'default' patch 'deployment.v1.apps/web' --type merge --patch-file 'patches/default/apps.Deployment/web.json'`))
+		})
+
+		It("should patch the Cluster B resource when namespaces are not compared", func() {
+			config.CompareNamespaces = false
+			config.ClusterA.Data = []map[string]interface{}{testResource("ConfigMap", "staging", "settings", map[string]interface{}{"debug": "true"})}
+			config.ClusterB.Data = []map[string]interface{}{testResource("ConfigMap", "production", "settings", map[string]interface{}{"debug": "false"})}
+
+			_, err := writeRemediation(tempDir, remediationApply, config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+			manifest := readYAML(filepath.Join(tempDir, "manifests", "production", "ConfigMap", "settings.yaml"))
+			Expect(manifest["metadata"]).To(HaveKeyWithValue("namespace", "production"))
+
+			_, err = writeRemediation(tempDir, remediationMergePatch, config, compareClusters(config))
+			Expect(err).NotTo(HaveOccurred())
+			content, err := os.ReadFile(filepath.Join(tempDir, "patches", "production", "ConfigMap", "settings.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(MatchJSON(`{"spec": {"debug": "true"}}`))
+			Expect(filepath.Join(tempDir, "manifests")).NotTo(BeADirectory())
+		})
+
+		It("should reject unknown formats", func() {
+			_, err := writeRemediation(tempDir, "strategic", config, compareClusters(config))
+			Expect(err).To(MatchError(ContainSubstring("invalid remediation format")))
+		})
+	})
+
+	Describe("mergePatch function", func() {
+		It("should return nil for equal objects", func() {
+			object := map[string]interface{}{"spec": map[string]interface{}{"a": "1"}}
+			Expect(mergePatch(object, object)).To(BeNil())
+		})
+
+		It("should replace changed lists and remove missing keys", func() {
+			current := map[string]interface{}{
+				"spec": map[string]interface{}{"ports": []interface{}{float64(80)}, "old": "x", "same": "y"},
+			}
+			desired := map[string]interface{}{
+				"spec": map[string]interface{}{"ports": []interface{}{float64(80), float64(443)}, "same": "y"},
+			}
+
+			patch, err := json.Marshal(mergePatch(current, desired))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(MatchJSON(`{"spec": {"ports": [80, 443], "old": null}}`))
+		})
+	})
+
+	Describe("remediationObject function", func() {
+		It("should drop server-assigned fields and the maps they leave empty", func() {
+			service := testResource("Service", "default", "web", map[string]interface{}{"clusterIP": "10.0.0.1", "type": "ClusterIP"})
+			metadata := service["metadata"].(map[string]interface{})
+			metadata["annotations"] = map[string]interface{}{"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+			metadata["uid"] = "abc"
+
+			Expect(remediationObject(service)).To(Equal(map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+				"spec":       map[string]interface{}{"type": "ClusterIP"},
+			}))
+			Expect(metadata).To(HaveKey("uid"))
+		})
+	})
+
+	Describe("kubectlResourceName function", func() {
+		It("should qualify the kind with its version and group", func() {
+			Expect(kubectlResourceName(ResourceIdentity{APIVersion: "apps/v1", Kind: "Deployment"})).To(Equal("deployment.v1.apps"))
+			Expect(kubectlResourceName(ResourceIdentity{APIVersion: "v1", Kind: "ConfigMap"})).To(Equal("configmap"))
+		})
+	})
+})
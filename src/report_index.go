@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// reportIndexFile is the page in the output directory listing every comparison run
+const reportIndexFile = "index.html"
+
+// reportIndexTemplate is the template of the run index page
+var reportIndexTemplate = template.Must(template.New("report_index.html.tmpl").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).ParseFS(reportAssets, "assets/report_index.html.tmpl"))
+
+// reportIndexRun is one comparison run listed on the index page
+type reportIndexRun struct {
+	servedReport
+	Metadata  *DiffReportMetadata
+	Resources []string
+	OnlyInA   int
+	OnlyInB   int
+	Different int
+	Unknown   int
+}
+
+// Differences is the number of resources that are missing from a cluster or differ
+func (r reportIndexRun) Differences() int {
+	return r.OnlyInA + r.OnlyInB + r.Different
+}
+
+// generateReportIndex writes index.html to the output directory, listing every run that
+// has an HTML report there. The contexts, namespaces and counts of a run are read from
+// its diff-<ts>.json; runs without a readable diff report are listed with a link only.
+func generateReportIndex(out io.Writer, outputDir string) error {
+	reports, err := listReports(outputDir)
+	if err != nil {
+		return err
+	}
+
+	runs := make([]reportIndexRun, 0, len(reports))
+	for _, report := range reports {
+		run := reportIndexRun{servedReport: report}
+		if report.DiffJSON != "" {
+			if err := readReportIndexCounts(filepath.Join(outputDir, report.DiffJSON), &run); err != nil {
+				fmt.Fprintf(out, "⚠️  Warning: Listing %s without details: %v\n", report.HTML, err)
+			}
+		}
+		runs = append(runs, run)
+	}
+
+	var buf bytes.Buffer
+	if err := reportIndexTemplate.Execute(&buf, struct{ Runs []reportIndexRun }{runs}); err != nil {
+		return fmt.Errorf("failed to render report index: %w", err)
+	}
+
+	filename := filepath.Join(outputDir, reportIndexFile)
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write report index: %w", err)
+	}
+
+	fmt.Fprintf(out, "📄 Updated report index: %s\n", filename)
+	return nil
+}
+
+// readReportIndexCounts fills the metadata and status counts of a run from its diff report.
+// Only the schema version, metadata and summary are decoded: the report writes them
+// before the per-kind resources, so reading stops as soon as all three were found.
+func readReportIndexCounts(filename string, run *reportIndexRun) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var report struct {
+		SchemaVersion string
+		Metadata      DiffReportMetadata
+		Summary       DiffReportSummary
+	}
+	fields := map[string]interface{}{
+		"schemaVersion": &report.SchemaVersion,
+		"metadata":      &report.Metadata,
+		"summary":       &report.Summary,
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("invalid diff report: not a JSON object")
+	}
+	for len(fields) > 0 && decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid diff report: %w", err)
+		}
+		key, _ := token.(string)
+		if target, found := fields[key]; found {
+			err = decoder.Decode(target)
+			delete(fields, key)
+		} else {
+			err = skipJSONValue(decoder)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid diff report: %w", err)
+		}
+	}
+
+	if report.SchemaVersion != diffReportSchemaVersion {
+		return fmt.Errorf("unsupported diff report schema %q", report.SchemaVersion)
+	}
+
+	run.Metadata = &report.Metadata
+	run.Resources = mergeUnique(report.Metadata.ClusterA.Resources, report.Metadata.ClusterB.Resources)
+	run.OnlyInA = report.Summary.Counts[statusOnlyInA]
+	run.OnlyInB = report.Summary.Counts[statusOnlyInB]
+	run.Different = report.Summary.Counts[statusDifferent]
+	run.Unknown = report.Summary.Counts[statusUnknown]
+	return nil
+}
+
+// skipJSONValue reads past the next value of a decoder token by token, without holding it
+func skipJSONValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// mergeUnique returns the values of both lists in order, without duplicates
+func mergeUnique(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []string
+	for _, value := range append(append([]string{}, a...), b...) {
+		if !seen[value] {
+			seen[value] = true
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
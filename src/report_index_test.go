@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report index", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "k8s-compare-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	writeFile := func(name, content string) {
+		Expect(os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644)).To(Succeed())
+	}
+
+	writeDiffReport := func(timestamp string, config *ComparisonConfig) {
+		config.ReportTimestamp = timestamp
+		data, err := json.Marshal(buildDiffReport(config, compareClusters(config), time.Now()))
+		Expect(err).NotTo(HaveOccurred())
+		writeFile("diff-"+timestamp+".json", string(data))
+	}
+
+	readIndex := func() string {
+		content, err := os.ReadFile(filepath.Join(tempDir, reportIndexFile))
+		Expect(err).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	Describe("generateReportIndex function", func() {
+		It("should list every run newest first with its details", func() {
+			writeFile("k8s-comparison-report_2024-01-01_10:00:00.html", "")
+			writeFile("k8s-comparison-report_2024-02-01_10:00:00.html", "")
+			writeDiffReport("2024-02-01_10:00:00", &ComparisonConfig{
+				ClusterA: ClusterConfig{
+					Context:    "staging<b>",
+					Namespaces: []string{"default", "web"},
+					Resources:  []string{"configmaps"},
+					Data: []map[string]interface{}{
+						{"kind": "ConfigMap", "metadata": map[string]interface{}{"name": "a", "namespace": "default"}, "data": map[string]interface{}{"k": "1"}},
+						{"kind": "ConfigMap", "metadata": map[string]interface{}{"name": "only-a", "namespace": "default"}},
+					},
+				},
+				ClusterB: ClusterConfig{
+					Context:    "production",
+					Namespaces: []string{"default"},
+					Resources:  []string{"configmaps", "secrets"},
+					Data: []map[string]interface{}{
+						{"kind": "ConfigMap", "metadata": map[string]interface{}{"name": "a", "namespace": "default"}, "data": map[string]interface{}{"k": "2"}},
+					},
+				},
+				CompareNamespaces: true,
+			})
+
+			Expect(generateReportIndex(GinkgoWriter, tempDir)).To(Succeed())
+			index := readIndex()
+
+			Expect(index).To(ContainSubstring("2 runs"))
+			Expect(strings.Index(index, "2024-02-01_10:00:00")).To(BeNumerically("<", strings.Index(index, "2024-01-01_10:00:00")))
+			Expect(index).To(ContainSubstring(`<a href="./k8s-comparison-report_2024-02-01_10:00:00.html">`))
+			Expect(index).To(ContainSubstring(`<a href="./diff-2024-02-01_10:00:00.json">JSON</a>`))
+			Expect(index).To(ContainSubstring("staging&lt;b&gt;"))
+			Expect(index).To(ContainSubstring("default, web"))
+			Expect(index).To(ContainSubstring("configmaps, secrets"))
+			Expect(index).To(ContainSubstring(`<td class="count nonzero" data-value="2">2</td>`))
+			Expect(index).To(ContainSubstring(`<td class="count" data-value="1">1</td>`))
+		})
+
+		It("should list runs without a readable diff report with a link only", func() {
+			writeFile("k8s-comparison-report_2024-01-01_10:00:00.html", "")
+			writeFile("k8s-comparison-report_2024-02-01_10:00:00.html", "")
+			writeFile("diff-2024-02-01_10:00:00.json", "not json")
+
+			Expect(generateReportIndex(GinkgoWriter, tempDir)).To(Succeed())
+			index := readIndex()
+
+			Expect(index).To(ContainSubstring(`<a href="./k8s-comparison-report_2024-01-01_10:00:00.html">`))
+			Expect(index).To(ContainSubstring(`<a href="./k8s-comparison-report_2024-02-01_10:00:00.html">`))
+			Expect(index).To(ContainSubstring(`data-value="-1"`))
+		})
+
+		It("should write an empty index when there are no runs", func() {
+			Expect(generateReportIndex(GinkgoWriter, tempDir)).To(Succeed())
+			Expect(readIndex()).To(ContainSubstring("No comparison runs yet."))
+		})
+	})
+
+	Describe("readReportIndexCounts function", func() {
+		It("should reject diff reports of another schema", func() {
+			writeFile("diff.json", `{"schemaVersion": "k8s-compare.diff/v0"}`)
+
+			var run reportIndexRun
+			err := readReportIndexCounts(filepath.Join(tempDir, "diff.json"), &run)
+			Expect(err).To(MatchError(ContainSubstring("unsupported diff report schema")))
+			Expect(run.Metadata).To(BeNil())
+		})
+
+		It("should stop reading once the metadata and summary were decoded", func() {
+			// The resources after the summary are cut off, as if the file were huge
+			writeFile("diff.json", `{"schemaVersion": "k8s-compare.diff/v1", "extra": {"nested": [1, {"a": 2}]},
+				"metadata": {"reportTimestamp": "20240101-120000", "clusterA": {"resources": ["pods"]}, "clusterB": {"resources": ["secrets"]}},
+				"summary": {"counts": {"only-in-A": 2, "different": 1}}, "kinds": [{"kind": "Pod", "resources": [`)
+
+			var run reportIndexRun
+			Expect(readReportIndexCounts(filepath.Join(tempDir, "diff.json"), &run)).To(Succeed())
+			Expect(run.Metadata.ReportTimestamp).To(Equal("20240101-120000"))
+			Expect(run.Resources).To(Equal([]string{"pods", "secrets"}))
+			Expect(run.OnlyInA).To(Equal(2))
+			Expect(run.Different).To(Equal(1))
+		})
+	})
+
+	Describe("mergeUnique function", func() {
+		It("should keep the first occurrence of each value in order", func() {
+			Expect(mergeUnique([]string{"pods", "services"}, []string{"secrets", "pods"})).To(Equal([]string{"pods", "services", "secrets"}))
+			Expect(mergeUnique(nil, nil)).To(BeEmpty())
+		})
+	})
+})
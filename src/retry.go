@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// retryPolicy controls how API calls failing with transient errors are retried
+type retryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on every retry
+	BaseDelay time.Duration
+	// MaxDelay caps both the exponential backoff and server-suggested delays
+	MaxDelay time.Duration
+}
+
+// defaultRetryPolicy is used for list calls while fetching resources
+var defaultRetryPolicy = retryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// sleepContext waits for the given duration or until the context is done
+var sleepContext = func(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isTransientError reports whether an API call failed for a reason that may go away
+// on its own: throttling, overloaded or restarting API servers, and dropped connections
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsInternalError(err) {
+		return true
+	}
+
+	// A status error that is none of the above (e.g. 404 or 403) will not recover by retrying
+	var statusErr apierrors.APIStatus
+	if errors.As(err, &statusErr) {
+		_, suggestsDelay := apierrors.SuggestsClientDelay(err)
+		return suggestsDelay
+	}
+
+	if utilnet.IsConnectionReset(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsProbableEOF(err) ||
+		utilnet.IsHTTP2ConnectionLost(err) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// delay returns how long to wait before the given retry (0 for the first retry).
+// A Retry-After delay suggested by the server takes precedence over the backoff.
+func (p retryPolicy) delay(retry int, err error) time.Duration {
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		return min(time.Duration(seconds)*time.Second, p.MaxDelay)
+	}
+
+	backoff := p.BaseDelay << retry
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+
+	// Add up to 20% jitter so parallel clients do not retry in lockstep
+	jitter := time.Duration(rand.Int63n(int64(backoff)/5 + 1))
+	return min(backoff+jitter, p.MaxDelay)
+}
+
+// withRetry runs fn, retrying transient failures with exponential backoff and
+// reporting each retry to out. The last error is returned once the attempts are exhausted.
+func withRetry(ctx context.Context, out io.Writer, policy retryPolicy, operation string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransientError(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		delay := policy.delay(attempt-1, err)
+		fmt.Fprintf(out, "⏳ %s failed (%v), retrying in %s (attempt %d/%d)...\n", operation, err, delay.Round(time.Millisecond), attempt+1, policy.MaxAttempts)
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
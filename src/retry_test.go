@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("Retry", func() {
+	podsResource := schema.GroupResource{Resource: "pods"}
+
+	Describe("isTransientError function", func() {
+		It("should retry throttling and unavailable API servers", func() {
+			Expect(isTransientError(apierrors.NewTooManyRequests("slow down", 2))).To(BeTrue())
+			Expect(isTransientError(apierrors.NewServiceUnavailable("restarting"))).To(BeTrue())
+			Expect(isTransientError(apierrors.NewServerTimeout(podsResource, "list", 1))).To(BeTrue())
+			Expect(isTransientError(apierrors.NewInternalError(errors.New("etcdserver: leader changed")))).To(BeTrue())
+		})
+
+		It("should retry dropped connections", func() {
+			Expect(isTransientError(fmt.Errorf("list pods: %w", syscall.ECONNRESET))).To(BeTrue())
+			Expect(isTransientError(fmt.Errorf("list pods: %w", syscall.ECONNREFUSED))).To(BeTrue())
+			Expect(isTransientError(io.ErrUnexpectedEOF)).To(BeTrue())
+		})
+
+		It("should not retry permanent API errors", func() {
+			Expect(isTransientError(apierrors.NewForbidden(podsResource, "", errors.New("rbac")))).To(BeFalse())
+			Expect(isTransientError(apierrors.NewNotFound(podsResource, "web"))).To(BeFalse())
+			Expect(isTransientError(apierrors.NewUnauthorized("expired"))).To(BeFalse())
+		})
+
+		It("should not retry nil or unrelated errors", func() {
+			Expect(isTransientError(nil)).To(BeFalse())
+			Expect(isTransientError(errors.New("invalid selector"))).To(BeFalse())
+		})
+	})
+
+	Describe("retryPolicy delay", func() {
+		policy := retryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+		It("should back off exponentially with bounded jitter", func() {
+			err := apierrors.NewServiceUnavailable("restarting")
+			Expect(policy.delay(0, err)).To(BeNumerically("~", time.Second, 200*time.Millisecond))
+			Expect(policy.delay(2, err)).To(BeNumerically(">=", 4*time.Second))
+			Expect(policy.delay(2, err)).To(BeNumerically("<=", 4800*time.Millisecond))
+		})
+
+		It("should cap the backoff at the maximum delay", func() {
+			Expect(policy.delay(10, apierrors.NewServiceUnavailable("restarting"))).To(Equal(10 * time.Second))
+		})
+
+		It("should honour the server's Retry-After", func() {
+			Expect(policy.delay(0, apierrors.NewTooManyRequests("slow down", 7))).To(Equal(7 * time.Second))
+			Expect(policy.delay(0, apierrors.NewTooManyRequests("slow down", 60))).To(Equal(10 * time.Second))
+		})
+	})
+
+	Describe("withRetry function", func() {
+		var originalSleep func(context.Context, time.Duration) error
+		var delays []time.Duration
+		policy := retryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+		BeforeEach(func() {
+			originalSleep = sleepContext
+			delays = nil
+			sleepContext = func(ctx context.Context, delay time.Duration) error {
+				delays = append(delays, delay)
+				return nil
+			}
+		})
+
+		AfterEach(func() {
+			sleepContext = originalSleep
+		})
+
+		It("should retry transient errors until the call succeeds", func() {
+			attempts := 0
+			err := withRetry(context.Background(), GinkgoWriter, policy, "Listing pods", func() error {
+				attempts++
+				if attempts < 3 {
+					return apierrors.NewTooManyRequests("slow down", 1)
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attempts).To(Equal(3))
+			Expect(delays).To(Equal([]time.Duration{time.Second, time.Second}))
+		})
+
+		It("should return the last error once attempts are exhausted", func() {
+			attempts := 0
+			err := withRetry(context.Background(), GinkgoWriter, policy, "Listing pods", func() error {
+				attempts++
+				return apierrors.NewServiceUnavailable("restarting")
+			})
+			Expect(apierrors.IsServiceUnavailable(err)).To(BeTrue())
+			Expect(attempts).To(Equal(3))
+			Expect(delays).To(HaveLen(2))
+		})
+
+		It("should not retry permanent errors", func() {
+			attempts := 0
+			err := withRetry(context.Background(), GinkgoWriter, policy, "Listing pods", func() error {
+				attempts++
+				return apierrors.NewForbidden(podsResource, "", errors.New("rbac"))
+			})
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+			Expect(attempts).To(Equal(1))
+			Expect(delays).To(BeEmpty())
+		})
+
+		It("should stop waiting when the context is cancelled", func() {
+			sleepContext = originalSleep
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			attempts := 0
+			err := withRetry(ctx, GinkgoWriter, retryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}, "Listing pods", func() error {
+				attempts++
+				return apierrors.NewServiceUnavailable("restarting")
+			})
+			Expect(apierrors.IsServiceUnavailable(err)).To(BeTrue())
+			Expect(attempts).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,644 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// serveIndexTemplate is the page listing the reports and the form to start a comparison
+var serveIndexTemplate = template.Must(template.ParseFS(reportAssets, "assets/serve_index.html.tmpl"))
+
+// reportFilePrefix and reportFileSuffix surround the timestamp in HTML report file names
+const (
+	reportFilePrefix = "k8s-comparison-report_"
+	reportFileSuffix = ".html"
+)
+
+// newServeCommand creates the `serve` subcommand
+func newServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the reports in the output directory and run comparisons from a browser",
+		Long:  `Start a local web server that lists every report in the output directory, serves the reports and offers a form to run a new comparison in the background, streaming its progress to the page.`,
+		Run:   runServe,
+	}
+
+	cmd.Flags().String("addr", "localhost:8080", "Address to listen on; use :8080 to accept connections from other hosts")
+	cmd.Flags().StringP("output-dir", "o", "reports", "Directory the reports are read from and written to")
+	cmd.Flags().String("kubeconfig", "", "Path to the kubeconfig file(s) for both clusters (defaults to $KUBECONFIG or ~/.kube/config)")
+	cmd.Flags().String("kubeconfig-a", "", "Path to the kubeconfig file(s) for Cluster A (overrides --kubeconfig)")
+	cmd.Flags().String("kubeconfig-b", "", "Path to the kubeconfig file(s) for Cluster B (overrides --kubeconfig)")
+	cmd.Flags().String("gcloud-auth", string(gcloudAuthNone), "How to refresh expired Google Cloud credentials: adc, service-account or none (interactive logins are not possible from the browser)")
+	cmd.Flags().String("gcloud-key-file", "", "Service account key file used to authenticate gcloud (implies --gcloud-auth=service-account)")
+
+	return cmd
+}
+
+// runServe starts the report server
+func runServe(cmd *cobra.Command, args []string) {
+	addr, _ := cmd.Flags().GetString("addr")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+
+	kubeconfigA, kubeconfigB, err := getKubeconfigFlags(cmd)
+	if err != nil {
+		log.Fatalf("Failed to get kubeconfig flags: %v", err)
+	}
+
+	gcloudAuthMode, _ := cmd.Flags().GetString("gcloud-auth")
+	gcloudKeyFile, _ := cmd.Flags().GetString("gcloud-key-file")
+	gcloudAuth, err = parseGCloudAuthOptions(gcloudAuthMode, gcloudKeyFile)
+	if err != nil {
+		log.Fatalf("Invalid Google Cloud auth options: %v", err)
+	}
+	if gcloudAuth.Mode == gcloudAuthBrowser || gcloudAuth.Mode == gcloudAuthNoBrowser {
+		log.Fatalf("--gcloud-auth=%s needs a terminal; use adc, service-account or none with serve", gcloudAuth.Mode)
+	}
+
+	srv := newReportServer(addr, outputDir, kubeconfigA, kubeconfigB)
+	if !isLoopbackAddr(addr) {
+		fmt.Printf("⚠️  %s is not a loopback address: anyone who can reach it can read the reports and run comparisons with your kubeconfig credentials\n", addr)
+	}
+	fmt.Printf("🌐 Serving reports from %s at http://%s\n", outputDir, addr)
+	if err := http.ListenAndServe(addr, srv.routes()); err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
+
+// reportServer serves the reports of an output directory and runs comparisons
+type reportServer struct {
+	// addr is the address the server listens on; requests must name it in their Host header
+	addr        string
+	outputDir   string
+	kubeconfigA string
+	kubeconfigB string
+
+	// The cluster access is swappable so the handlers can be tested without clusters
+	listContexts      func(kubeconfig string) ([]string, error)
+	listNamespaces    func(kubeconfig, contextName string) ([]string, error)
+	listResourceTypes func(kubeconfig, contextName string) ([]string, error)
+	runComparison     func(config *ComparisonConfig) error
+
+	mu      sync.Mutex
+	jobs    map[string]*comparisonJob
+	lastID  int
+	running bool
+}
+
+// newReportServer creates a report server listening on addr, backed by the given kubeconfig selection
+func newReportServer(addr, outputDir, kubeconfigA, kubeconfigB string) *reportServer {
+	return &reportServer{
+		addr:              addr,
+		outputDir:         outputDir,
+		kubeconfigA:       kubeconfigA,
+		kubeconfigB:       kubeconfigB,
+		listContexts:      getAvailableContexts,
+		listNamespaces:    listServedNamespaces,
+		listResourceTypes: getAvailableResourceTypes,
+		runComparison:     runServedComparison,
+		jobs:              make(map[string]*comparisonJob),
+	}
+}
+
+// routes returns the HTTP handler of the server
+func (s *reportServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /reports/{name}", s.handleReportFile)
+	mux.HandleFunc("GET /api/namespaces", s.handleNamespaces)
+	mux.HandleFunc("GET /api/resources", s.handleResources)
+	mux.HandleFunc("POST /api/comparisons", s.handleStartComparison)
+	mux.HandleFunc("GET /api/comparisons/{id}/events", s.handleComparisonEvents)
+	return s.sameOrigin(mux)
+}
+
+// sameOrigin rejects requests that do not come from the server's own pages: the Host
+// header must name the address the server listens on, which defeats DNS rebinding, and
+// the Origin header browsers send with cross-site requests must be the server itself.
+// Otherwise any page the user visits could start comparisons with their credentials.
+func (s *reportServer) sameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			http.Error(w, "unexpected Host header", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether a Host header names the address the server listens on.
+// Loopback addresses accept every loopback name; wildcard addresses accept IP addresses,
+// localhost and the machine's host name, but no other DNS name.
+func (s *reportServer) allowedHost(host string) bool {
+	bindHost, bindPort, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return false
+	}
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, "80"
+	}
+	if port != bindPort {
+		return false
+	}
+
+	if ip := net.ParseIP(bindHost); bindHost == "" || (ip != nil && ip.IsUnspecified()) {
+		hostname, _ := os.Hostname()
+		return net.ParseIP(name) != nil || isLoopbackHost(name) || (hostname != "" && strings.EqualFold(name, hostname))
+	}
+	if isLoopbackHost(bindHost) {
+		return isLoopbackHost(name)
+	}
+	return strings.EqualFold(name, bindHost)
+}
+
+// isLoopbackHost reports whether a host name or IP address refers to the local machine only
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local connections
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	return err == nil && isLoopbackHost(host)
+}
+
+// servedFile reports whether an output file is served: HTML and Markdown reports, JSON
+// diff reports and the run index. Cluster snapshots are not, since they include Secret data.
+func servedFile(name string) bool {
+	switch {
+	case name == reportIndexFile:
+		return true
+	case strings.HasPrefix(name, reportFilePrefix):
+		return strings.HasSuffix(name, reportFileSuffix) || strings.HasSuffix(name, ".md")
+	case strings.HasPrefix(name, "diff-"):
+		return strings.HasSuffix(name, ".json")
+	}
+	return false
+}
+
+// handleReportFile serves a report file of the output directory, without directory listings
+func (s *reportServer) handleReportFile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if filepath.Base(name) != name || strings.ContainsAny(name, `/\`) || !servedFile(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := os.Open(filepath.Join(s.outputDir, name))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
+
+// servedReport is one HTML report in the output directory, with its sibling files
+type servedReport struct {
+	Timestamp string
+	HTML      string
+	Markdown  string
+	DiffJSON  string
+}
+
+// listReports returns the HTML reports in dir, newest first. A missing directory has no reports.
+func listReports(dir string) ([]servedReport, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+
+	files := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		files[entry.Name()] = true
+	}
+
+	var reports []servedReport
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, reportFilePrefix) || !strings.HasSuffix(name, reportFileSuffix) {
+			continue
+		}
+
+		timestamp := strings.TrimSuffix(strings.TrimPrefix(name, reportFilePrefix), reportFileSuffix)
+		report := servedReport{Timestamp: timestamp, HTML: name}
+		if markdown := reportFilePrefix + timestamp + ".md"; files[markdown] {
+			report.Markdown = markdown
+		}
+		if diff := "diff-" + timestamp + ".json"; files[diff] {
+			report.DiffJSON = diff
+		}
+		reports = append(reports, report)
+	}
+
+	// Timestamps are formatted so that they sort chronologically
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Timestamp > reports[j].Timestamp })
+	return reports, nil
+}
+
+// reportURL returns the URL the server serves an output file at
+func reportURL(name string) string {
+	return "/reports/" + url.PathEscape(name)
+}
+
+// handleIndex renders the list of reports and the comparison form
+func (s *reportServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	reports, err := listReports(s.outputDir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contextsA, errA := s.listContexts(s.kubeconfigA)
+	contextsB, errB := s.listContexts(s.kubeconfigB)
+	var contextError string
+	if err := firstError(errA, errB); err != nil {
+		contextError = err.Error()
+	}
+
+	data := struct {
+		OutputDir    string
+		Reports      []servedReport
+		ContextsA    []string
+		ContextsB    []string
+		ContextError string
+	}{s.outputDir, reports, contextsA, contextsB, contextError}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := serveIndexTemplate.Execute(w, data); err != nil {
+		log.Printf("Failed to render index: %v", err)
+	}
+}
+
+// handleNamespaces lists the namespaces of a context of cluster a or b
+func (s *reportServer) handleNamespaces(w http.ResponseWriter, r *http.Request) {
+	kubeconfig := s.kubeconfigA
+	if r.URL.Query().Get("cluster") == "b" {
+		kubeconfig = s.kubeconfigB
+	}
+
+	namespaces, err := s.listNamespaces(kubeconfig, r.URL.Query().Get("context"))
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, namespaces)
+}
+
+// handleResources lists the resource types of a Cluster A context, like the CLI does
+func (s *reportServer) handleResources(w http.ResponseWriter, r *http.Request) {
+	resources, err := s.listResourceTypes(s.kubeconfigA, r.URL.Query().Get("context"))
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, reorderResourcesByPriority(resources))
+}
+
+// handleStartComparison validates the form and starts a comparison in the background
+func (s *reportServer) handleStartComparison(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config, err := s.comparisonFromForm(r.PostForm)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	job, err := s.startJob(config)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"id":     job.ID,
+		"events": "/api/comparisons/" + job.ID + "/events",
+	})
+}
+
+// comparisonFromForm builds a comparison config from the submitted form, applying the
+// same rules as the interactive setup
+func (s *reportServer) comparisonFromForm(form url.Values) (*ComparisonConfig, error) {
+	config := &ComparisonConfig{
+		ClusterA: ClusterConfig{
+			Kubeconfig: s.kubeconfigA,
+			Context:    form.Get("contextA"),
+			Namespaces: form["namespacesA"],
+			Resources:  form["resources"],
+		},
+		ClusterB: ClusterConfig{
+			Kubeconfig: s.kubeconfigB,
+			Context:    form.Get("contextB"),
+			Namespaces: form["namespacesB"],
+			Resources:  form["resources"],
+		},
+		OutputDir:         s.outputDir,
+		CompareNamespaces: form.Get("compareNamespaces") != "",
+		// Comparisons run in the background, so nobody is at the terminal to answer login prompts
+		NonInteractive: true,
+	}
+
+	for _, cluster := range []struct {
+		config *ClusterConfig
+		name   string
+	}{{&config.ClusterA, "Cluster A"}, {&config.ClusterB, "Cluster B"}} {
+		contexts, err := s.listContexts(cluster.config.Kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get contexts for %s: %w", cluster.name, err)
+		}
+		if !contains(contexts, cluster.config.Context) {
+			return nil, fmt.Errorf("unknown context %q for %s", cluster.config.Context, cluster.name)
+		}
+		if len(cluster.config.Namespaces) == 0 {
+			return nil, fmt.Errorf("no namespaces selected for %s", cluster.name)
+		}
+	}
+
+	if config.ClusterA.Kubeconfig == config.ClusterB.Kubeconfig && config.ClusterA.Context == config.ClusterB.Context {
+		return nil, fmt.Errorf("select two different contexts for Cluster A and Cluster B")
+	}
+	if len(config.ClusterA.Resources) == 0 {
+		return nil, fmt.Errorf("no resource types selected")
+	}
+
+	return config, nil
+}
+
+// startJob starts a comparison unless one is already running. Comparisons run one at
+// a time so they do not compete for the clusters or name their reports alike.
+func (s *reportServer) startJob(config *ComparisonConfig) (*comparisonJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return nil, fmt.Errorf("a comparison is already running; wait for it to finish")
+	}
+	s.running = true
+	s.lastID++
+
+	config.ReportTimestamp = newReportTimestamp()
+	job := newComparisonJob(strconv.Itoa(s.lastID))
+	s.jobs[job.ID] = job
+
+	output := &jobOutput{job: job, echo: os.Stdout}
+	config.Progress = output
+
+	go func() {
+		var err error
+		defer func() {
+			// A panicking comparison fails its job instead of leaving it running forever
+			if recovered := recover(); recovered != nil {
+				log.Printf("Comparison %s panicked: %v\n%s", job.ID, recovered, debug.Stack())
+				err = fmt.Errorf("comparison failed unexpectedly: %v", recovered)
+			}
+			output.flush()
+
+			s.mu.Lock()
+			s.running = false
+			s.mu.Unlock()
+
+			report := ""
+			if err == nil {
+				report = reportURL(fmt.Sprintf("%s%s%s", reportFilePrefix, config.ReportTimestamp, reportFileSuffix))
+			}
+			job.finish(report, err)
+		}()
+
+		err = s.runComparison(config)
+	}()
+
+	return job, nil
+}
+
+// handleComparisonEvents streams the progress of a comparison as server-sent events:
+// one message per output line, then a "done" event with the report URL or a "failed"
+// event with the error. Lines printed before the client connected are replayed.
+func (s *reportServer) handleComparisonEvents(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if job == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	sent := 0
+	for {
+		state := job.since(sent)
+		for _, line := range state.lines {
+			writeServerSentEvent(w, "", line)
+		}
+		sent += len(state.lines)
+
+		if state.done {
+			if state.err != nil {
+				writeServerSentEvent(w, "failed", state.err.Error())
+			} else {
+				writeServerSentEvent(w, "done", state.report)
+			}
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-state.changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeServerSentEvent writes one event; multi-line data is split into data fields
+func writeServerSentEvent(w io.Writer, event, data string) {
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// firstError returns the first non-nil error
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listServedNamespaces lists the namespaces of a context without prompting for a login
+// when its credentials are rejected
+func listServedNamespaces(kubeconfig, contextName string) ([]string, error) {
+	return listNamespaces(kubeconfig, contextName, authSession{out: os.Stdout})
+}
+
+// runServedComparison runs the non-interactive part of a comparison: it checks the
+// credentials of both contexts, fetches their resources and writes the reports
+func runServedComparison(config *ComparisonConfig) error {
+	if err := authenticateClusters(config); err != nil {
+		return err
+	}
+	if err := fetchResources(config); err != nil {
+		return err
+	}
+	return generateOutputFiles(config, compareClusters(config))
+}
+
+// comparisonJob is a comparison running in the background and its captured output
+type comparisonJob struct {
+	ID string
+
+	mu      sync.Mutex
+	lines   []string
+	done    bool
+	report  string
+	err     error
+	changed chan struct{}
+}
+
+// jobState is the part of a job's output a client has not seen yet
+type jobState struct {
+	lines   []string
+	done    bool
+	report  string
+	err     error
+	changed <-chan struct{}
+}
+
+// newComparisonJob creates a running job
+func newComparisonJob(id string) *comparisonJob {
+	return &comparisonJob{ID: id, changed: make(chan struct{})}
+}
+
+// appendLine records an output line and wakes up the clients
+func (j *comparisonJob) appendLine(line string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.lines = append(j.lines, line)
+	j.notify()
+}
+
+// finish records the outcome of the job and wakes up the clients
+func (j *comparisonJob) finish(report string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.done, j.report, j.err = true, report, err
+	j.notify()
+}
+
+// notify closes the changed channel and replaces it; callers hold the lock
+func (j *comparisonJob) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// since returns the lines after the first sent ones and the channel that is closed on the next change
+func (j *comparisonJob) since(sent int) jobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return jobState{
+		lines:   append([]string(nil), j.lines[sent:]...),
+		done:    j.done,
+		report:  j.report,
+		err:     j.err,
+		changed: j.changed,
+	}
+}
+
+// jobOutput is the progress writer of a job: it passes each complete line to the job
+// and echoes everything to the server's own output
+type jobOutput struct {
+	job  *comparisonJob
+	echo io.Writer
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+// Write records the complete lines of p and keeps the rest until the next write
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.echo.Write(p)
+	o.partial = append(o.partial, p...)
+	for {
+		end := bytes.IndexByte(o.partial, '\n')
+		if end < 0 {
+			break
+		}
+		o.job.appendLine(string(o.partial[:end]))
+		o.partial = o.partial[end+1:]
+	}
+	return len(p), nil
+}
+
+// flush records a last line that did not end with a newline
+func (o *jobOutput) flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.partial) > 0 {
+		o.job.appendLine(string(o.partial))
+		o.partial = nil
+	}
+}
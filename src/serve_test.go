@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report server", func() {
+	var tempDir string
+	var srv *reportServer
+	var httpServer *httptest.Server
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "k8s-compare-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		httpServer = httptest.NewUnstartedServer(nil)
+		srv = newReportServer(httpServer.Listener.Addr().String(), tempDir, "", "")
+		srv.listContexts = func(string) ([]string, error) { return []string{"dev", "prod"}, nil }
+		srv.listNamespaces = func(_, contextName string) ([]string, error) {
+			return []string{contextName + "-ns"}, nil
+		}
+		srv.listResourceTypes = func(string, string) ([]string, error) { return []string{"widgets", "pods"}, nil }
+		httpServer.Config.Handler = srv.routes()
+		httpServer.Start()
+	})
+
+	AfterEach(func() {
+		httpServer.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	get := func(path string) (int, string) {
+		response, err := http.Get(httpServer.URL + path)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return response.StatusCode, string(body)
+	}
+
+	startComparison := func(form url.Values) (int, string) {
+		response, err := http.PostForm(httpServer.URL+"/api/comparisons", form)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return response.StatusCode, string(body)
+	}
+
+	validForm := url.Values{
+		"contextA":          {"dev"},
+		"contextB":          {"prod"},
+		"namespacesA":       {"default"},
+		"namespacesB":       {"default"},
+		"resources":         {"pods"},
+		"compareNamespaces": {"true"},
+	}
+
+	Describe("listReports function", func() {
+		It("should list reports newest first with their sibling files", func() {
+			for _, name := range []string{
+				"k8s-comparison-report_2024-01-01_10:00:00.html",
+				"k8s-comparison-report_2024-02-01_10:00:00.html",
+				"k8s-comparison-report_2024-02-01_10:00:00.md",
+				"diff-2024-02-01_10:00:00.json",
+				"cluster-a-2024-02-01_10:00:00.json",
+			} {
+				Expect(os.WriteFile(filepath.Join(tempDir, name), []byte("x"), 0644)).To(Succeed())
+			}
+
+			reports, err := listReports(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reports).To(Equal([]servedReport{
+				{
+					Timestamp: "2024-02-01_10:00:00",
+					HTML:      "k8s-comparison-report_2024-02-01_10:00:00.html",
+					Markdown:  "k8s-comparison-report_2024-02-01_10:00:00.md",
+					DiffJSON:  "diff-2024-02-01_10:00:00.json",
+				},
+				{Timestamp: "2024-01-01_10:00:00", HTML: "k8s-comparison-report_2024-01-01_10:00:00.html"},
+			}))
+		})
+
+		It("should return no reports for a missing directory", func() {
+			reports, err := listReports(filepath.Join(tempDir, "missing"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reports).To(BeEmpty())
+		})
+	})
+
+	Describe("index page", func() {
+		It("should list the reports and offer the contexts", func() {
+			Expect(os.WriteFile(filepath.Join(tempDir, "k8s-comparison-report_2024-01-01_10:00:00.html"), []byte("<html></html>"), 0644)).To(Succeed())
+
+			status, body := get("/")
+
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(ContainSubstring("2024-01-01_10:00:00"))
+			Expect(body).To(ContainSubstring(`href="/reports/k8s-comparison-report_2024-01-01_10:00:00.html"`))
+			Expect(body).To(ContainSubstring(`<option value="prod">prod</option>`))
+		})
+
+		It("should serve report files but nothing outside the output directory", func() {
+			Expect(os.WriteFile(filepath.Join(tempDir, "k8s-comparison-report_x.html"), []byte("report body"), 0644)).To(Succeed())
+
+			status, body := get("/reports/k8s-comparison-report_x.html")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("report body"))
+
+			status, _ = get("/reports/../serve_test.go")
+			Expect(status).NotTo(Equal(http.StatusOK))
+		})
+
+		It("should serve reports, diff reports and the run index but no snapshots or listings", func() {
+			for _, name := range []string{
+				"k8s-comparison-report_x.md",
+				"diff-x.json",
+				reportIndexFile,
+				"cluster-a-x.json",
+				"cluster-b-x.json",
+			} {
+				Expect(os.WriteFile(filepath.Join(tempDir, name), []byte("content"), 0644)).To(Succeed())
+			}
+
+			for _, name := range []string{"k8s-comparison-report_x.md", "diff-x.json", reportIndexFile} {
+				status, body := get("/reports/" + name)
+				Expect(status).To(Equal(http.StatusOK), name)
+				Expect(body).To(Equal("content"))
+			}
+			for _, path := range []string{"/reports/cluster-a-x.json", "/reports/cluster-b-x.json", "/reports/", "/reports/diff-x.json%2F..%2Fcluster-a-x.json"} {
+				status, _ := get(path)
+				Expect(status).To(Equal(http.StatusNotFound), path)
+			}
+		})
+	})
+
+	Describe("request origin checks", func() {
+		send := func(method, path, host, origin string) int {
+			request, err := http.NewRequest(method, httpServer.URL+path, strings.NewReader(validForm.Encode()))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if host != "" {
+				request.Host = host
+			}
+			if origin != "" {
+				request.Header.Set("Origin", origin)
+			}
+			response, err := http.DefaultClient.Do(request)
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			return response.StatusCode
+		}
+
+		It("should reject comparisons started from other origins", func() {
+			srv.runComparison = func(*ComparisonConfig) error { return nil }
+
+			Expect(send(http.MethodPost, "/api/comparisons", "", "http://evil.example")).To(Equal(http.StatusForbidden))
+			Expect(send(http.MethodPost, "/api/comparisons", "", httpServer.URL)).To(Equal(http.StatusAccepted))
+		})
+
+		It("should reject requests for other host names", func() {
+			_, port, _ := strings.Cut(strings.TrimPrefix(httpServer.URL, "http://"), ":")
+
+			Expect(send(http.MethodGet, "/", "evil.example:"+port, "")).To(Equal(http.StatusForbidden))
+			Expect(send(http.MethodGet, "/", "localhost:"+port, "")).To(Equal(http.StatusOK))
+			Expect(send(http.MethodGet, "/", "localhost:1", "")).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	Describe("allowedHost method", func() {
+		It("should only accept the bound address", func() {
+			Expect((&reportServer{addr: "10.0.0.5:8080"}).allowedHost("10.0.0.5:8080")).To(BeTrue())
+			Expect((&reportServer{addr: "10.0.0.5:8080"}).allowedHost("localhost:8080")).To(BeFalse())
+			Expect((&reportServer{addr: ":8080"}).allowedHost("192.168.1.20:8080")).To(BeTrue())
+			Expect((&reportServer{addr: ":8080"}).allowedHost("rebound.example:8080")).To(BeFalse())
+			Expect((&reportServer{addr: "[::1]:8080"}).allowedHost("127.0.0.1:8080")).To(BeTrue())
+		})
+
+		It("should tell loopback listen addresses apart", func() {
+			Expect(isLoopbackAddr("localhost:8080")).To(BeTrue())
+			Expect(isLoopbackAddr("127.0.0.1:8080")).To(BeTrue())
+			Expect(isLoopbackAddr(":8080")).To(BeFalse())
+			Expect(isLoopbackAddr("0.0.0.0:8080")).To(BeFalse())
+		})
+	})
+
+	Describe("option endpoints", func() {
+		It("should list the namespaces of a context", func() {
+			status, body := get("/api/namespaces?cluster=b&context=prod")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(MatchJSON(`["prod-ns"]`))
+		})
+
+		It("should list resource types with common ones first", func() {
+			status, body := get("/api/resources?context=dev")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(MatchJSON(`["pods", "widgets"]`))
+		})
+
+		It("should report lookup failures", func() {
+			srv.listNamespaces = func(string, string) ([]string, error) { return nil, errors.New("forbidden") }
+
+			status, body := get("/api/namespaces?context=dev")
+			Expect(status).To(Equal(http.StatusBadGateway))
+			Expect(body).To(MatchJSON(`{"error": "forbidden"}`))
+		})
+	})
+
+	Describe("starting a comparison", func() {
+		It("should reject incomplete forms", func() {
+			for field, message := range map[string]string{
+				"contextA":    "unknown context",
+				"namespacesB": "no namespaces selected for Cluster B",
+				"resources":   "no resource types selected",
+			} {
+				form := url.Values{}
+				for key, values := range validForm {
+					if key != field {
+						form[key] = values
+					}
+				}
+
+				status, body := startComparison(form)
+				Expect(status).To(Equal(http.StatusBadRequest))
+				Expect(body).To(ContainSubstring(message))
+			}
+		})
+
+		It("should reject the same context for both clusters", func() {
+			form := url.Values{}
+			for key, values := range validForm {
+				form[key] = values
+			}
+			form.Set("contextB", "dev")
+
+			status, body := startComparison(form)
+			Expect(status).To(Equal(http.StatusBadRequest))
+			Expect(body).To(ContainSubstring("two different contexts"))
+		})
+
+		It("should stream the progress and the report of a comparison", func() {
+			var received *ComparisonConfig
+			srv.runComparison = func(config *ComparisonConfig) error {
+				received = config
+				fmt.Fprintln(config.progress(), "🔍 Fetching resources from Cluster A (dev)...")
+				fmt.Fprint(config.progress(), "✅ Cluster A: Found 3 resources")
+				return nil
+			}
+
+			status, body := startComparison(validForm)
+			Expect(status).To(Equal(http.StatusAccepted))
+			Expect(body).To(ContainSubstring(`"events":"/api/comparisons/1/events"`))
+
+			status, events := get("/api/comparisons/1/events")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(events).To(ContainSubstring("data: 🔍 Fetching resources from Cluster A (dev)...\n\n"))
+			Expect(events).To(ContainSubstring("data: ✅ Cluster A: Found 3 resources\n\n"))
+			Expect(events).To(MatchRegexp(`event: done\ndata: /reports/k8s-comparison-report_.+\.html\n\n$`))
+
+			Expect(received.ClusterA.Context).To(Equal("dev"))
+			Expect(received.ClusterB.Namespaces).To(Equal([]string{"default"}))
+			Expect(received.ClusterB.Resources).To(Equal([]string{"pods"}))
+			Expect(received.OutputDir).To(Equal(tempDir))
+			Expect(received.CompareNamespaces).To(BeTrue())
+			Expect(received.NonInteractive).To(BeTrue())
+		})
+
+		It("should report a failed comparison", func() {
+			srv.runComparison = func(*ComparisonConfig) error { return errors.New("cluster unreachable\nretry later") }
+
+			status, _ := startComparison(validForm)
+			Expect(status).To(Equal(http.StatusAccepted))
+
+			_, events := get("/api/comparisons/1/events")
+			Expect(events).To(HaveSuffix("event: failed\ndata: cluster unreachable\ndata: retry later\n\n"))
+		})
+
+		It("should run one comparison at a time", func() {
+			release := make(chan struct{})
+			srv.runComparison = func(*ComparisonConfig) error {
+				<-release
+				return nil
+			}
+
+			status, _ := startComparison(validForm)
+			Expect(status).To(Equal(http.StatusAccepted))
+
+			status, body := startComparison(validForm)
+			Expect(status).To(Equal(http.StatusConflict))
+			Expect(body).To(ContainSubstring("already running"))
+
+			close(release)
+			_, events := get("/api/comparisons/1/events")
+			Expect(events).To(ContainSubstring("event: done"))
+
+			status, _ = startComparison(validForm)
+			Expect(status).To(Equal(http.StatusAccepted))
+			get("/api/comparisons/2/events")
+		})
+
+		It("should fail a panicking comparison and accept the next one", func() {
+			srv.runComparison = func(*ComparisonConfig) error { panic("nil map") }
+
+			status, _ := startComparison(validForm)
+			Expect(status).To(Equal(http.StatusAccepted))
+
+			_, events := get("/api/comparisons/1/events")
+			Expect(events).To(HaveSuffix("event: failed\ndata: comparison failed unexpectedly: nil map\n\n"))
+
+			srv.runComparison = func(*ComparisonConfig) error { return nil }
+			status, _ = startComparison(validForm)
+			Expect(status).To(Equal(http.StatusAccepted))
+			get("/api/comparisons/2/events")
+		})
+
+		It("should return 404 for unknown comparisons", func() {
+			status, _ := get("/api/comparisons/42/events")
+			Expect(status).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("jobOutput writer", func() {
+		It("should pass complete lines to the job and echo the output", func() {
+			job := newComparisonJob("1")
+			var echo strings.Builder
+			output := &jobOutput{job: job, echo: &echo}
+
+			fmt.Fprintln(output, "first")
+			fmt.Fprint(output, "second\nthi")
+			Expect(job.since(0).lines).To(Equal([]string{"first", "second"}))
+
+			fmt.Fprint(output, "rd")
+			output.flush()
+			Expect(job.since(0).lines).To(Equal([]string{"first", "second", "third"}))
+			Expect(echo.String()).To(Equal("first\nsecond\nthird"))
+		})
+	})
+
+	It("should split multi-line event data into data fields", func() {
+		var builder strings.Builder
+		writeServerSentEvent(&builder, "failed", "a\nb")
+		Expect(builder.String()).To(Equal("event: failed\ndata: a\ndata: b\n\n"))
+	})
+})
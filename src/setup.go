@@ -11,74 +11,107 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// setupComparison handles the interactive setup process
-func setupComparison(outputDir string, compareNamespaces bool) (*ComparisonConfig, error) {
+// setupComparison handles the interactive setup process. The caller provides the
+// non-interactive settings (output directory, kubeconfig selection, ...) in config
+// and the remaining fields are filled in from the user's selections.
+func setupComparison(config *ComparisonConfig) error {
 	// Get available contexts
-	contexts, err := getAvailableContexts()
+	contextsA, err := getAvailableContexts(config.ClusterA.Kubeconfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get contexts: %w", err)
+		return fmt.Errorf("failed to get contexts for Cluster A: %w", err)
 	}
 
-	if len(contexts) < 2 {
-		return nil, fmt.Errorf("need at least 2 contexts, found %d", len(contexts))
+	contextsB := contextsA
+	sameKubeconfig := config.ClusterA.Kubeconfig == config.ClusterB.Kubeconfig
+	if !sameKubeconfig {
+		contextsB, err = getAvailableContexts(config.ClusterB.Kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to get contexts for Cluster B: %w", err)
+		}
 	}
 
-	config := &ComparisonConfig{
-		OutputDir:         outputDir,
-		ReportTimestamp:   time.Now().Format("2006-01-02_15:04:05"),
-		CompareNamespaces: compareNamespaces,
+	if sameKubeconfig && len(contextsA) < 2 {
+		return fmt.Errorf("need at least 2 contexts, found %d", len(contextsA))
+	}
+	if len(contextsA) == 0 {
+		return fmt.Errorf("no contexts found in kubeconfig for Cluster A")
 	}
+	if len(contextsB) == 0 {
+		return fmt.Errorf("no contexts found in kubeconfig for Cluster B")
+	}
+
+	config.ReportTimestamp = newReportTimestamp()
 
 	// Select contexts
 	fmt.Println("📍 Step 1: Select Kubernetes contexts")
-	config.ClusterA.Context, err = selectFromList("Select Cluster A context:", contexts)
+	config.ClusterA.Context, err = selectFromList("Select Cluster A context:", contextsA)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	remainingContexts := removeFromSlice(contexts, config.ClusterA.Context)
+	// The same context may legitimately be chosen twice when it comes from
+	// two different kubeconfig files (e.g. both files name their context "admin")
+	remainingContexts := contextsB
+	if sameKubeconfig {
+		remainingContexts = removeFromSlice(contextsB, config.ClusterA.Context)
+	}
 	config.ClusterB.Context, err = selectFromList("Select Cluster B context:", remainingContexts)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Early authentication check for Google Cloud contexts
-	fmt.Println("\n🔐 Checking authentication for selected contexts...")
-
-	if err := ensureGCloudAuth(config.ClusterA.Context); err != nil {
-		return nil, fmt.Errorf("authentication failed for Cluster A (%s): %w", config.ClusterA.Context, err)
-	}
-
-	if err := ensureGCloudAuth(config.ClusterB.Context); err != nil {
-		return nil, fmt.Errorf("authentication failed for Cluster B (%s): %w", config.ClusterB.Context, err)
+	// Early authentication check for the credential plugins used by each context
+	if err := authenticateClusters(config); err != nil {
+		return err
 	}
 
 	// Select namespaces for each cluster
 	fmt.Println("\n🏠 Step 2: Select namespaces")
-	config.ClusterA.Namespaces, err = selectNamespaces(config.ClusterA.Context, "Cluster A")
+	config.ClusterA.Namespaces, err = selectNamespaces(config.ClusterA.Kubeconfig, config.ClusterA.Context, "Cluster A", config.authSession())
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	config.ClusterB.Namespaces, err = selectNamespaces(config.ClusterB.Context, "Cluster B")
+	config.ClusterB.Namespaces, err = selectNamespaces(config.ClusterB.Kubeconfig, config.ClusterB.Context, "Cluster B", config.authSession())
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Select resource types
 	fmt.Println("\n📦 Step 3: Select resource types")
-	availableResources, err := getAvailableResourceTypes(config.ClusterA.Context)
+	availableResources, err := getAvailableResourceTypes(config.ClusterA.Kubeconfig, config.ClusterA.Context)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get available resource types: %w", err)
+		return fmt.Errorf("failed to get available resource types: %w", err)
 	}
 
 	config.ClusterA.Resources, err = selectMultipleFromList("Select resource types to compare:", availableResources)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	config.ClusterB.Resources = config.ClusterA.Resources
 
-	return config, nil
+	return nil
+}
+
+// newReportTimestamp returns the timestamp that names the files of a new comparison
+func newReportTimestamp() string {
+	return time.Now().Format("2006-01-02_15:04:05")
+}
+
+// authenticateClusters checks, and if needed refreshes, the credentials of both contexts
+func authenticateClusters(config *ComparisonConfig) error {
+	session := config.authSession()
+	fmt.Fprintln(session.out, "\n🔐 Checking authentication for selected contexts...")
+
+	if err := ensureClusterAuth(config.ClusterA.Kubeconfig, config.ClusterA.Context, session); err != nil {
+		return fmt.Errorf("authentication failed for Cluster A (%s): %w", config.ClusterA.Context, err)
+	}
+
+	if err := ensureClusterAuth(config.ClusterB.Kubeconfig, config.ClusterB.Context, session); err != nil {
+		return fmt.Errorf("authentication failed for Cluster B (%s): %w", config.ClusterB.Context, err)
+	}
+
+	return nil
 }
 
 // selectFromList presents a single-select list to the user
@@ -169,53 +202,53 @@ func reorderResourcesByPriority(items []string) []string {
 }
 
 // selectNamespaces handles namespace selection for a cluster
-func selectNamespaces(contextName, clusterName string) ([]string, error) {
-	// Ensure Google Cloud authentication if needed
-	if err := ensureGCloudAuth(contextName); err != nil {
-		return nil, fmt.Errorf("google cloud authentication failed: %w", err)
+func selectNamespaces(kubeconfig, contextName, clusterName string, session authSession) ([]string, error) {
+	fmt.Fprintf(session.out, "📋 Fetching namespaces from %s...\n", clusterName)
+	nsNames, err := listNamespaces(kubeconfig, contextName, session)
+	if err != nil {
+		return nil, err
 	}
 
-	client, err := getKubernetesClient(contextName)
+	selectedNs, err := selectMultipleFromList(fmt.Sprintf("Select namespaces for %s:", clusterName), nsNames)
 	if err != nil {
-		if isGoogleCloudContext(contextName) && (strings.Contains(err.Error(), "gke-gcloud-auth-plugin") ||
-			strings.Contains(err.Error(), "credential") ||
-			strings.Contains(err.Error(), "auth")) {
-			fmt.Println("\n🔄 Authentication issue detected, attempting to refresh credentials...")
-			if authErr := promptGCloudLogin(); authErr != nil {
-				return nil, fmt.Errorf("failed to authenticate with Google Cloud: %w", authErr)
-			}
-			// Retry after authentication
-			client, err = getKubernetesClient(contextName)
-			if err != nil {
-				return nil, fmt.Errorf("failed to connect even after authentication: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("failed to create Kubernetes client for context %s: %w", contextName, err)
-		}
+		return nil, err
+	}
+
+	if len(selectedNs) == 0 {
+		return nil, fmt.Errorf("no namespaces selected")
+	}
+
+	return selectedNs, nil
+}
+
+// listNamespaces returns the sorted namespace names of a context, refreshing the
+// credentials once if they are rejected
+func listNamespaces(kubeconfig, contextName string, session authSession) ([]string, error) {
+	client, err := getKubernetesClient(kubeconfig, contextName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client for context %s: %w", contextName, err)
 	}
 
-	fmt.Printf("📋 Fetching namespaces from %s...\n", clusterName)
 	namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		if isGoogleCloudContext(contextName) && (strings.Contains(err.Error(), "credential") ||
-			strings.Contains(err.Error(), "auth") ||
-			strings.Contains(err.Error(), "token")) {
-			fmt.Println("\n🔄 Token expired, refreshing authentication...")
-			if authErr := promptGCloudLogin(); authErr != nil {
-				return nil, fmt.Errorf("failed to refresh authentication: %w", authErr)
-			}
-			// Retry after re-authentication
-			client, err = getKubernetesClient(contextName)
-			if err != nil {
-				return nil, fmt.Errorf("failed to reconnect after auth refresh: %w", err)
-			}
-			namespaces, err = client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				return nil, fmt.Errorf("failed to list namespaces even after re-authentication: %w", err)
-			}
-		} else {
+		if !isAuthError(err, kubeconfig, contextName) {
 			return nil, fmt.Errorf("failed to list namespaces: %w", err)
 		}
+
+		fmt.Fprintln(session.out, "\n🔄 Credentials rejected, refreshing authentication...")
+		if authErr := reauthenticateContext(kubeconfig, contextName, session); authErr != nil {
+			return nil, fmt.Errorf("failed to refresh authentication: %w", authErr)
+		}
+
+		// Retry after re-authentication with a fresh client so no cached credentials are reused
+		client, err = getKubernetesClient(kubeconfig, contextName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconnect after auth refresh: %w", err)
+		}
+		namespaces, err = client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces even after re-authentication: %w", err)
+		}
 	}
 
 	var nsNames []string
@@ -224,14 +257,5 @@ func selectNamespaces(contextName, clusterName string) ([]string, error) {
 	}
 	sort.Strings(nsNames)
 
-	selectedNs, err := selectMultipleFromList(fmt.Sprintf("Select namespaces for %s:", clusterName), nsNames)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(selectedNs) == 0 {
-		return nil, fmt.Errorf("no namespaces selected")
-	}
-
-	return selectedNs, nil
+	return nsNames, nil
 }
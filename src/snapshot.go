@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// snapshotWriter writes resources to a newline-delimited JSON file, gzip-compressed
+// when the file name ends in .gz, one resource at a time
+type snapshotWriter struct {
+	file     *os.File
+	gzip     *gzip.Writer
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+	count    int
+}
+
+// createSnapshot creates an NDJSON snapshot file
+func createSnapshot(path string) (*snapshotWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &snapshotWriter{file: file}
+	var out io.Writer = file
+	if strings.HasSuffix(path, ".gz") {
+		writer.gzip = gzip.NewWriter(file)
+		out = writer.gzip
+	}
+	writer.buffered = bufio.NewWriter(out)
+	writer.encoder = json.NewEncoder(writer.buffered)
+	return writer, nil
+}
+
+// Write appends a value as one line of the snapshot
+func (w *snapshotWriter) Write(value interface{}) error {
+	w.count++
+	return w.encoder.Encode(value)
+}
+
+// appendNDJSON copies count values already encoded as NDJSON to the snapshot
+func (w *snapshotWriter) appendNDJSON(reader io.Reader, count int) error {
+	w.count += count
+	_, err := io.Copy(w.buffered, reader)
+	return err
+}
+
+// Close flushes and closes the snapshot file
+func (w *snapshotWriter) Close() error {
+	err := w.buffered.Flush()
+	if w.gzip != nil {
+		err = errors.Join(err, w.gzip.Close())
+	}
+	return errors.Join(err, w.file.Close())
+}
+
+// snapshotSink is a resourceSink writing to a snapshot. The resources of the scope being
+// listed are staged in an uncompressed file next to it and appended on commit, so a scope
+// that fails part-way is dropped without holding it in memory.
+type snapshotSink struct {
+	writer  *snapshotWriter
+	staging *snapshotWriter
+	path    string
+}
+
+// newSnapshotSink creates the staging file of a snapshot
+func newSnapshotSink(writer *snapshotWriter, snapshotPath string) (*snapshotSink, error) {
+	path := snapshotPath + ".scope"
+	staging, err := createSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotSink{writer: writer, staging: staging, path: path}, nil
+}
+
+func (s *snapshotSink) emit(object map[string]interface{}) error {
+	return s.staging.Write(object)
+}
+
+func (s *snapshotSink) discard() error {
+	s.staging.buffered.Reset(s.staging.file)
+	s.staging.count = 0
+	if err := s.staging.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := s.staging.file.Seek(0, io.SeekStart)
+	return err
+}
+
+func (s *snapshotSink) commit() error {
+	if err := s.staging.buffered.Flush(); err != nil {
+		return err
+	}
+	if _, err := s.staging.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := s.writer.appendNDJSON(s.staging.file, s.staging.count); err != nil {
+		return err
+	}
+	return s.discard()
+}
+
+// Close removes the staging file
+func (s *snapshotSink) Close() error {
+	return errors.Join(s.staging.Close(), os.Remove(s.path))
+}
+
+// snapshotReader reads the values of an NDJSON snapshot, decompressing .gz files
+type snapshotReader struct {
+	file    *os.File
+	gzip    *gzip.Reader
+	decoder *json.Decoder
+}
+
+// openSnapshot opens an NDJSON snapshot file for reading
+func openSnapshot(path string) (*snapshotReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &snapshotReader{file: file}
+	var in io.Reader = bufio.NewReader(file)
+	if strings.HasSuffix(path, ".gz") {
+		reader.gzip, err = gzip.NewReader(in)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		in = reader.gzip
+	}
+	reader.decoder = json.NewDecoder(in)
+	// Keep integers exact instead of converting them to float64
+	reader.decoder.UseNumber()
+	return reader, nil
+}
+
+// Read decodes the next value into target, returning io.EOF at the end of the snapshot
+func (r *snapshotReader) Read(target interface{}) error {
+	return r.decoder.Decode(target)
+}
+
+// Close closes the snapshot file
+func (r *snapshotReader) Close() error {
+	if r.gzip != nil {
+		r.gzip.Close()
+	}
+	return r.file.Close()
+}
+
+// eachSnapshotResource calls visit with every resource of an NDJSON snapshot, in file order
+func eachSnapshotResource(path string, visit func(map[string]interface{}) error) error {
+	reader, err := openSnapshot(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		var resource map[string]interface{}
+		if err := reader.Read(&resource); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err := visit(resource); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// writeTestSnapshot writes resources to a snapshot file for tests
+func writeTestSnapshot(path string, resources ...map[string]interface{}) {
+	writer, err := createSnapshot(path)
+	Expect(err).NotTo(HaveOccurred())
+	for _, resource := range resources {
+		Expect(writer.Write(resource)).To(Succeed())
+	}
+	Expect(writer.Close()).To(Succeed())
+}
+
+// readTestSnapshot reads all resources of a snapshot file for tests
+func readTestSnapshot(path string) []map[string]interface{} {
+	reader, err := openSnapshot(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer reader.Close()
+
+	var resources []map[string]interface{}
+	for {
+		var resource map[string]interface{}
+		err := reader.Read(&resource)
+		if errors.Is(err, io.EOF) {
+			return resources
+		}
+		Expect(err).NotTo(HaveOccurred())
+		resources = append(resources, resource)
+	}
+}
+
+var _ = Describe("Snapshot", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "k8s-compare-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("should write one JSON object per line", func() {
+		path := filepath.Join(tempDir, "snapshot.ndjson")
+		writeTestSnapshot(path,
+			testResource("ConfigMap", "default", "a", nil),
+			testResource("ConfigMap", "default", "b", nil),
+		)
+
+		content, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(HaveSuffix("\n"))
+		Expect(string(content)).To(MatchRegexp(`^\{[^\n]*"name":"a"[^\n]*\}\n\{[^\n]*"name":"b"[^\n]*\}\n$`))
+	})
+
+	It("should compress snapshots ending in .gz", func() {
+		path := filepath.Join(tempDir, "snapshot.ndjson.gz")
+		writeTestSnapshot(path, testResource("ConfigMap", "default", "a", map[string]interface{}{"replicas": int64(3)}))
+
+		content, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content[:2]).To(Equal([]byte{0x1f, 0x8b}))
+
+		resources := readTestSnapshot(path)
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0]["spec"]).To(Equal(map[string]interface{}{"replicas": json.Number("3")}))
+	})
+
+	It("should read an empty snapshot", func() {
+		path := filepath.Join(tempDir, "empty.ndjson")
+		writeTestSnapshot(path)
+
+		Expect(readTestSnapshot(path)).To(BeEmpty())
+	})
+
+	It("should only keep the committed scopes of a snapshot sink", func() {
+		path := filepath.Join(tempDir, "snapshot.ndjson.gz")
+		writer, err := createSnapshot(path)
+		Expect(err).NotTo(HaveOccurred())
+		sink, err := newSnapshotSink(writer, path)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sink.emit(testResource("ConfigMap", "default", "a", nil))).To(Succeed())
+		Expect(sink.emit(testResource("ConfigMap", "default", "b", nil))).To(Succeed())
+		Expect(sink.commit()).To(Succeed())
+		Expect(sink.emit(testResource("Secret", "default", "partial", nil))).To(Succeed())
+		Expect(sink.discard()).To(Succeed())
+		Expect(sink.emit(testResource("Secret", "other", "c", nil))).To(Succeed())
+		Expect(sink.commit()).To(Succeed())
+		Expect(sink.Close()).To(Succeed())
+		Expect(writer.Close()).To(Succeed())
+
+		var names []string
+		for _, resource := range readTestSnapshot(path) {
+			names = append(names, resourceIdentity(resource).Name)
+		}
+		Expect(names).To(Equal([]string{"a", "b", "c"}))
+		Expect(writer.count).To(Equal(3))
+		Expect(path + ".scope").NotTo(BeAnExistingFile())
+	})
+})
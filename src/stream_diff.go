@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// snapshotExtension returns the file extension of NDJSON snapshots
+func snapshotExtension(compress bool) string {
+	if compress {
+		return ".ndjson.gz"
+	}
+	return ".ndjson"
+}
+
+// runStreamingComparison compares two clusters with bounded memory: resources are
+// streamed to NDJSON snapshots as pages arrive, both snapshots are sorted on disk, and
+// the sorted snapshots are compared in a single pass, writing one line per resource to
+// diff-<ts>.ndjson. Only the resources being compared are held in memory.
+func runStreamingComparison(config *ComparisonConfig, compress bool) error {
+	if err := os.MkdirAll(config.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	extension := snapshotExtension(compress)
+	pathA := fmt.Sprintf("%s/cluster-a-%s%s", config.OutputDir, config.ReportTimestamp, extension)
+	pathB := fmt.Sprintf("%s/cluster-b-%s%s", config.OutputDir, config.ReportTimestamp, extension)
+	diffPath := fmt.Sprintf("%s/diff-%s%s", config.OutputDir, config.ReportTimestamp, extension)
+
+	if err := fetchResourcesToSnapshots(config, pathA, pathB); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(config.progress(), "\n🔀 Comparing snapshots...")
+	summaries, err := compareSnapshots(pathA, pathB, diffPath, config)
+	if err != nil {
+		return fmt.Errorf("failed to compare snapshots: %w", err)
+	}
+	fmt.Fprintf(config.progress(), "📄 Generated NDJSON diff report: %s\n\n", diffPath)
+
+	writeSummaryTable(os.Stdout, summaries)
+	return nil
+}
+
+// compareSnapshots compares two NDJSON snapshots by sorting both on the resource key and
+// walking them in lockstep, and writes every resource comparison to diffPath. Helm release
+// records are left out and, unless ExpandOwned is set, controller-generated resources are
+// counted under their owner, like in compareClusters.
+func compareSnapshots(pathA, pathB, diffPath string, config *ComparisonConfig) ([]kindSummary, error) {
+	key := func(object map[string]interface{}) string {
+		return resourceKey(object, config.CompareNamespaces)
+	}
+
+	summaries := newSummaryCollector()
+
+	ownedA, ownedB := &ownedScan{}, &ownedScan{}
+	if !config.ExpandOwned {
+		var err error
+		if ownedA, err = scanOwnedResources(pathA); err != nil {
+			return nil, err
+		}
+		if ownedB, err = scanOwnedResources(pathB); err != nil {
+			return nil, err
+		}
+	}
+
+	sortedA, err := sortSnapshot(pathA, key, sortChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	defer sortedA.Close()
+
+	sortedB, err := sortSnapshot(pathB, key, sortChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	defer sortedB.Close()
+
+	writer, err := createSnapshot(diffPath)
+	if err != nil {
+		return nil, err
+	}
+
+	iterA := &lastPerKeyIterator{inner: sortedA, skip: ownedA.excluded, onRead: func(object map[string]interface{}) {
+		summaries.get(resourceIdentity(object).Kind).CountA++
+	}}
+	iterB := &lastPerKeyIterator{inner: sortedB, skip: ownedB.excluded, onRead: func(object map[string]interface{}) {
+		summaries.get(resourceIdentity(object).Kind).CountB++
+	}}
+
+	err = mergeSortedResources(iterA, iterB, config, func(result ResourceComparison) error {
+		tallyA, tallyB := ownedA.tallyOf(result.ObjectA), ownedB.tallyOf(result.ObjectB)
+		if len(tallyA) > 0 || len(tallyB) > 0 {
+			result.Owned = summarizeTallies(tallyA, tallyB)
+		}
+		summaries.get(result.Kind).Counts[result.Status]++
+		return writer.Write(result)
+	})
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return summaries.sorted(), nil
+}
+
+// mergeSortedResources walks two iterators sorted by key and emits the comparison of
+// every key, matching the statuses produced by compareClusters
+func mergeSortedResources(iterA, iterB resourceIterator, config *ComparisonConfig, emit func(ResourceComparison) error) error {
+	a, okA, err := iterA.Next()
+	if err != nil {
+		return err
+	}
+	b, okB, err := iterB.Next()
+	if err != nil {
+		return err
+	}
+
+	for okA || okB {
+		var result ResourceComparison
+		advanceA, advanceB := false, false
+
+		switch {
+		case okA && (!okB || a.Key < b.Key):
+			result = missingResource(a.Object, statusOnlyInA, config.ClusterB.Skipped, config.CompareNamespaces)
+			advanceA = true
+		case okB && (!okA || b.Key < a.Key):
+			result = missingResource(b.Object, statusOnlyInB, config.ClusterA.Skipped, config.CompareNamespaces)
+			advanceB = true
+		default:
+			result = compareResourcePair(a.Object, b.Object)
+			advanceA, advanceB = true, true
+		}
+
+		if err := emit(result); err != nil {
+			return err
+		}
+
+		if advanceA {
+			if a, okA, err = iterA.Next(); err != nil {
+				return err
+			}
+		}
+		if advanceB {
+			if b, okB, err = iterB.Next(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// lastPerKeyIterator yields only the last of consecutive resources with the same key,
+// mirroring how compareClusters indexes resources, and reports every resource read.
+// Resources for which skip returns true are reported but never yielded.
+type lastPerKeyIterator struct {
+	inner   resourceIterator
+	pending *keyedResource
+	done    bool
+	onRead  func(map[string]interface{})
+	skip    func(map[string]interface{}) bool
+}
+
+// Next returns the next resource with a key different from the previous one
+func (it *lastPerKeyIterator) Next() (keyedResource, bool, error) {
+	if it.pending == nil && !it.done {
+		if err := it.read(); err != nil {
+			return keyedResource{}, false, err
+		}
+	}
+	if it.pending == nil {
+		return keyedResource{}, false, nil
+	}
+
+	current := *it.pending
+	for {
+		if err := it.read(); err != nil {
+			return keyedResource{}, false, err
+		}
+		if it.pending == nil || it.pending.Key != current.Key {
+			return current, true, nil
+		}
+		current = *it.pending
+	}
+}
+
+// read advances the inner iterator into pending, past skipped resources
+func (it *lastPerKeyIterator) read() error {
+	for {
+		resource, ok, err := it.inner.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			it.pending = nil
+			it.done = true
+			return nil
+		}
+		if it.onRead != nil {
+			it.onRead(resource.Object)
+		}
+		if it.skip == nil || !it.skip(resource.Object) {
+			it.pending = &resource
+			return nil
+		}
+	}
+}
+
+// Close closes the inner iterator
+func (it *lastPerKeyIterator) Close() error {
+	return it.inner.Close()
+}
+
+// ownedScan records which resources of a snapshot are collapsed into a fetched owner,
+// by their ownerIndexKey, and the counts of the resources collapsed into each owner
+type ownedScan struct {
+	collapsed map[string]bool
+	tallies   map[string]ownedTally
+}
+
+// controlledResource is the identity of a resource that has a controller, and whether
+// it is a ready Pod
+type controlledResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Ready     bool
+}
+
+// scanOwnedResources finds the controller-generated resources of a snapshot whose owner
+// is in the snapshot too, like collapseOwnedResources. Only the identities of controlled
+// resources and of their owners are held in memory: a first pass indexes the
+// controllers, and a second one marks which of them were fetched.
+func scanOwnedResources(path string) (*ownedScan, error) {
+	owners := make(ownerIndex)
+	derived := make(ownerIndex)
+	var controlled []controlledResource
+	err := eachSnapshotResource(path, func(resource map[string]interface{}) error {
+		owner, owned := controllerOwner(resource)
+		if !owned {
+			return nil
+		}
+		id := resourceIdentity(resource)
+		owners[ownerIndexKey(id.Kind, id.Namespace, id.Name)] = owner
+		controlled = append(controlled, controlledResource{
+			Kind:      id.Kind,
+			Namespace: id.Namespace,
+			Name:      id.Name,
+			Ready:     id.Kind == "Pod" && isPodReady(resource),
+		})
+		if key, deployment, found := derivedDeployment(resource); found {
+			if _, seen := derived[key]; !seen {
+				derived[key] = deployment
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for key, deployment := range derived {
+		if _, found := owners[key]; !found {
+			owners[key] = deployment
+		}
+	}
+
+	referenced := make(map[string]bool)
+	for _, resource := range controlled {
+		for _, owner := range owners.ownerChain(resource.Kind, resource.Namespace, resource.Name) {
+			referenced[ownerIndexKey(owner.Kind, resource.Namespace, owner.Name)] = true
+		}
+	}
+	fetched := make(map[string]bool)
+	err = eachSnapshotResource(path, func(resource map[string]interface{}) error {
+		id := resourceIdentity(resource)
+		if key := ownerIndexKey(id.Kind, id.Namespace, id.Name); referenced[key] {
+			fetched[key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scan := &ownedScan{collapsed: make(map[string]bool), tallies: make(map[string]ownedTally)}
+	for _, resource := range controlled {
+		owner, found := owners.topFetchedOwner(resource.Kind, resource.Namespace, resource.Name, fetched)
+		if !found {
+			continue
+		}
+		scan.collapsed[ownerIndexKey(resource.Kind, resource.Namespace, resource.Name)] = true
+		key := ownerIndexKey(owner.Kind, resource.Namespace, owner.Name)
+		if scan.tallies[key] == nil {
+			scan.tallies[key] = make(ownedTally)
+		}
+		scan.tallies[key].count(resource.Kind, resource.Ready)
+	}
+	return scan, nil
+}
+
+// excluded reports whether a resource is left out of the resource comparison, like in
+// compareClusters: it is counted under its owner or is a decodable Helm release record
+func (s *ownedScan) excluded(resource map[string]interface{}) bool {
+	if _, ok := decodedHelmRelease(resource); ok {
+		return true
+	}
+	return s.isCollapsed(resource)
+}
+
+// isCollapsed reports whether a resource is counted under its owner instead of compared
+func (s *ownedScan) isCollapsed(resource map[string]interface{}) bool {
+	id := resourceIdentity(resource)
+	return s.collapsed[ownerIndexKey(id.Kind, id.Namespace, id.Name)]
+}
+
+// tallyOf returns the counts of the resources collapsed into a resource, if any
+func (s *ownedScan) tallyOf(resource map[string]interface{}) ownedTally {
+	if resource == nil {
+		return nil
+	}
+	id := resourceIdentity(resource)
+	return s.tallies[ownerIndexKey(id.Kind, id.Namespace, id.Name)]
+}
+
+// summaryCollector accumulates per-kind counts while comparing
+type summaryCollector struct {
+	byKind map[string]*kindSummary
+}
+
+// newSummaryCollector creates an empty summary collector
+func newSummaryCollector() *summaryCollector {
+	return &summaryCollector{byKind: make(map[string]*kindSummary)}
+}
+
+// get returns the summary of a kind, creating it on first use
+func (c *summaryCollector) get(kind string) *kindSummary {
+	summary, ok := c.byKind[kind]
+	if !ok {
+		summary = &kindSummary{Kind: kind, Counts: make(map[string]int)}
+		c.byKind[kind] = summary
+	}
+	return summary
+}
+
+// sorted returns the summaries ordered by kind
+func (c *summaryCollector) sorted() []kindSummary {
+	kinds := make(map[string]bool, len(c.byKind))
+	for kind := range c.byKind {
+		kinds[kind] = true
+	}
+
+	var summaries []kindSummary
+	for _, kind := range sortedKeys(kinds) {
+		summaries = append(summaries, *c.byKind[kind])
+	}
+	return summaries
+}
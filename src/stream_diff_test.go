@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Streaming comparison", func() {
+	var tempDir string
+	var config *ComparisonConfig
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "k8s-compare-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		config = &ComparisonConfig{
+			ClusterA: ClusterConfig{
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(2)}),
+					testResource("ConfigMap", "default", "same", map[string]interface{}{"a": "1"}),
+					testResource("ConfigMap", "default", "only-a", nil),
+					testResource("Secret", "kube-system", "token", nil),
+				},
+			},
+			ClusterB: ClusterConfig{
+				Data: []map[string]interface{}{
+					testResource("ConfigMap", "default", "same", map[string]interface{}{"a": "1"}),
+					testResource("Service", "default", "only-b", nil),
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(3)}),
+				},
+				Skipped: []SkippedScope{
+					{ResourceScope: ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "kube-system"}, Reason: skipReasonForbidden},
+				},
+			},
+			CompareNamespaces: true,
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	compare := func(extension string) ([]kindSummary, []map[string]interface{}) {
+		pathA := filepath.Join(tempDir, "cluster-a"+extension)
+		pathB := filepath.Join(tempDir, "cluster-b"+extension)
+		diffPath := filepath.Join(tempDir, "diff"+extension)
+		writeTestSnapshot(pathA, config.ClusterA.Data...)
+		writeTestSnapshot(pathB, config.ClusterB.Data...)
+
+		summaries, err := compareSnapshots(pathA, pathB, diffPath, config)
+		Expect(err).NotTo(HaveOccurred())
+		return summaries, readTestSnapshot(diffPath)
+	}
+
+	Describe("compareSnapshots function", func() {
+		It("should produce the same counts as the in-memory comparison", func() {
+			summaries, _ := compare(".ndjson")
+
+			Expect(summaries).To(Equal(compareClusters(config).Summaries()))
+		})
+
+		It("should write one comparison per resource", func() {
+			_, results := compare(".ndjson.gz")
+
+			Expect(results).To(HaveLen(5))
+			statuses := map[string]string{}
+			for _, result := range results {
+				statuses[result["name"].(string)] = result["status"].(string)
+			}
+			Expect(statuses).To(Equal(map[string]string{
+				"only-a": statusOnlyInA,
+				"same":   statusIdentical,
+				"web":    statusDifferent,
+				"token":  statusNotCompared,
+				"only-b": statusOnlyInB,
+			}))
+		})
+
+		It("should include the field changes of differing resources", func() {
+			_, results := compare(".ndjson")
+
+			Expect(results[0]["name"]).To(Equal("only-a"))
+			Expect(results[2]["name"]).To(Equal("web"))
+			Expect(results[2]["changes"]).To(ConsistOf(map[string]interface{}{
+				"path": "spec.replicas", "type": changeModified, "valueA": json.Number("2"), "valueB": json.Number("3"),
+			}))
+		})
+
+		It("should keep the last of duplicate resources like the in-memory comparison", func() {
+			config.CompareNamespaces = false
+			config.ClusterA.Data = []map[string]interface{}{
+				testResource("ConfigMap", "team-a", "app", map[string]interface{}{"a": "1"}),
+				testResource("ConfigMap", "team-b", "app", map[string]interface{}{"a": "2"}),
+			}
+			config.ClusterB.Data = []map[string]interface{}{
+				testResource("ConfigMap", "team-b", "app", map[string]interface{}{"a": "2"}),
+			}
+
+			summaries, results := compare(".ndjson")
+
+			Expect(results).To(HaveLen(1))
+			Expect(results[0]["status"]).To(Equal(statusIdentical))
+			Expect(summaries).To(Equal(compareClusters(config).Summaries()))
+		})
+
+		It("should leave Helm release records out like the in-memory comparison", func() {
+			config.ClusterA.Data = append(config.ClusterA.Data, helmReleaseSecret("web", "default", 2, "1.2.0", "deployed", nil))
+			config.ClusterB.Data = append(config.ClusterB.Data, helmReleaseSecret("web", "default", 7, "1.2.0", "deployed", nil))
+
+			summaries, results := compare(".ndjson")
+
+			Expect(summaries).To(Equal(compareClusters(config).Summaries()))
+			Expect(results).To(HaveLen(5))
+			for _, result := range results {
+				Expect(result["name"]).NotTo(HavePrefix(helmReleasePrefix))
+			}
+		})
+
+		Context("with controller-generated resources", func() {
+			BeforeEach(func() {
+				config.ClusterA.Data = []map[string]interface{}{
+					testResource("Deployment", "default", "web", nil),
+					withController(testResource("ReplicaSet", "default", "web-6d4f", nil), "Deployment", "web"),
+					testReplicaPod("default", "web-6d4f", "web-6d4f-x2k", true),
+					testReplicaPod("default", "web-6d4f", "web-6d4f-p9q", true),
+				}
+				config.ClusterB.Data = []map[string]interface{}{
+					testResource("Deployment", "default", "web", nil),
+					withController(testResource("ReplicaSet", "default", "web-7c8b", nil), "Deployment", "web"),
+					testReplicaPod("default", "web-7c8b", "web-7c8b-m4n", false),
+					testReplicaPod("default", "orphan", "orphan-z1", true),
+				}
+				config.ClusterB.Skipped = nil
+			})
+
+			It("should count them under their owner like the in-memory comparison", func() {
+				summaries, results := compare(".ndjson.gz")
+
+				Expect(summaries).To(Equal(compareClusters(config).Summaries()))
+				Expect(results).To(HaveLen(2))
+				Expect(results[0]["kind"]).To(Equal("Deployment"))
+				Expect(results[0]["owned"]).To(ConsistOf(
+					map[string]interface{}{"kind": "Pod", "countA": json.Number("2"), "countB": json.Number("1"), "readyA": json.Number("2"), "readyB": json.Number("0")},
+					map[string]interface{}{"kind": "ReplicaSet", "countA": json.Number("1"), "countB": json.Number("1")},
+				))
+				Expect(results[1]["name"]).To(Equal("orphan-z1"))
+				Expect(results[1]["status"]).To(Equal(statusOnlyInB))
+			})
+
+			It("should count Pods under a Deployment whose ReplicaSets were not fetched", func() {
+				pod := testReplicaPod("default", "web-6d4f", "web-6d4f-x2k", true)
+				pod["metadata"].(map[string]interface{})["labels"] = map[string]interface{}{podTemplateHashLabel: "6d4f"}
+				path := filepath.Join(tempDir, "pods.ndjson")
+				writeTestSnapshot(path, testResource("Deployment", "default", "web", nil), pod)
+
+				scan, err := scanOwnedResources(path)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(scan.isCollapsed(pod)).To(BeTrue())
+				Expect(scan.tallyOf(testResource("Deployment", "default", "web", nil))).To(Equal(ownedTally{"Pod": {Total: 1, Ready: 1}}))
+			})
+
+			It("should compare them one by one with ExpandOwned", func() {
+				config.ExpandOwned = true
+
+				summaries, results := compare(".ndjson")
+
+				Expect(summaries).To(Equal(compareClusters(config).Summaries()))
+				Expect(results).To(HaveLen(7))
+				for _, result := range results {
+					Expect(result).NotTo(HaveKey("owned"))
+				}
+			})
+		})
+	})
+})
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// Confirmation granularity of the sync command
+const (
+	syncConfirmResource = "resource"
+	syncConfirmKind     = "kind"
+)
+
+// newSyncCommand creates the `sync` subcommand
+func newSyncCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Apply selected differences from Cluster A to Cluster B with server-side apply",
+		Long: `Compares two clusters like the main command, then applies the resources that are missing
+from or differ in Cluster B, using server-side apply with the k8s-compare field manager.
+Every change is dry-run on the server first and its diff shown; nothing is applied
+without confirmation, and kinds not on the --allow-kinds list are never touched.
+Resources only in Cluster B are not deleted.`,
+		Run: runSyncCommand,
+	}
+
+	cmd.Flags().BoolP("compare-namespaces", "c", true, "Match resources by namespace as well as name")
+	cmd.Flags().String("kubeconfig", "", "Path to the kubeconfig file(s) for both clusters (defaults to $KUBECONFIG or ~/.kube/config)")
+	cmd.Flags().String("kubeconfig-a", "", "Path to the kubeconfig file(s) for Cluster A (overrides --kubeconfig)")
+	cmd.Flags().String("kubeconfig-b", "", "Path to the kubeconfig file(s) for Cluster B (overrides --kubeconfig)")
+	cmd.Flags().String("gcloud-auth", string(gcloudAuthBrowser), "How to refresh expired Google Cloud credentials: browser, no-browser, adc, service-account or none")
+	cmd.Flags().String("gcloud-key-file", "", "Service account key file used to authenticate gcloud (implies --gcloud-auth=service-account)")
+	cmd.Flags().StringSlice("allow-kinds", nil, "Kinds sync may create or change, with their API group as in kubectl (e.g. ConfigMap,Deployment.apps); resources of other kinds are reported and left alone")
+	cmd.Flags().String("confirm", syncConfirmResource, "Ask for confirmation per resource or once per kind: resource or kind")
+	cmd.Flags().Bool("force-conflicts", false, "Take ownership of fields managed by another field manager instead of failing")
+
+	return cmd
+}
+
+// runSyncCommand is the entry point of the sync subcommand
+func runSyncCommand(cmd *cobra.Command, args []string) {
+	fmt.Println("🔁 Kubernetes Cluster Resource Sync")
+	fmt.Println("===================================")
+	fmt.Println()
+
+	if !stdinIsTerminal() {
+		log.Fatalf("sync asks for confirmation before every change and needs a terminal")
+	}
+
+	compareNamespaces, _ := cmd.Flags().GetBool("compare-namespaces")
+	allowedKinds, _ := cmd.Flags().GetStringSlice("allow-kinds")
+	if len(allowedKinds) == 0 {
+		log.Fatalf("--allow-kinds is required: list the kinds sync may change, e.g. --allow-kinds ConfigMap,Deployment.apps")
+	}
+	confirmMode, _ := cmd.Flags().GetString("confirm")
+	forceConflicts, _ := cmd.Flags().GetBool("force-conflicts")
+	if confirmMode != syncConfirmResource && confirmMode != syncConfirmKind {
+		log.Fatalf("Invalid confirmation mode %q: must be %s or %s", confirmMode, syncConfirmResource, syncConfirmKind)
+	}
+
+	kubeconfigA, kubeconfigB, err := getKubeconfigFlags(cmd)
+	if err != nil {
+		log.Fatalf("Failed to get kubeconfig flags: %v", err)
+	}
+
+	gcloudAuthMode, _ := cmd.Flags().GetString("gcloud-auth")
+	gcloudKeyFile, _ := cmd.Flags().GetString("gcloud-key-file")
+	gcloudAuth, err = parseGCloudAuthOptions(gcloudAuthMode, gcloudKeyFile)
+	if err != nil {
+		log.Fatalf("Invalid Google Cloud auth options: %v", err)
+	}
+
+	config := &ComparisonConfig{
+		ClusterA:          ClusterConfig{Kubeconfig: kubeconfigA},
+		ClusterB:          ClusterConfig{Kubeconfig: kubeconfigB},
+		CompareNamespaces: compareNamespaces,
+	}
+
+	if err := setupComparison(config); err != nil {
+		log.Fatalf("Setup failed: %v", err)
+	}
+	if err := fetchResources(config); err != nil {
+		log.Fatalf("Failed to fetch resources: %v", err)
+	}
+
+	plans, refused := planSync(compareClusters(config), allowedKinds)
+	for _, kind := range sortedKeys(refused) {
+		fmt.Printf("🚫 Not syncing %s: the kind is not in --allow-kinds\n", kind)
+	}
+	if len(plans) == 0 {
+		fmt.Println("\n✅ Nothing to sync: Cluster B already matches Cluster A for the allowed kinds")
+		return
+	}
+
+	applier, err := newDynamicSyncApplier(config.ClusterB.Kubeconfig, config.ClusterB.Context, config.ClusterB.Resources, forceConflicts)
+	if err != nil {
+		log.Fatalf("Failed to connect to Cluster B: %v", err)
+	}
+
+	session := &syncSession{
+		applier: applier,
+		confirm: confirmWithForm,
+		palette: palette{enabled: shouldUseColor(false)},
+		context: config.ClusterB.Context,
+	}
+	result := session.run(context.Background(), plans, confirmMode)
+
+	fmt.Printf("\n🔁 Sync finished: %d applied, %d declined, %d already up to date, %d failed\n",
+		result.Applied, result.Declined, result.Unchanged, result.Failed)
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// syncKindPlan is the set of changes sync proposes for one kind
+type syncKindPlan struct {
+	Kind    string
+	Changes []syncChange
+}
+
+// syncChange is a resource to create or update in Cluster B
+type syncChange struct {
+	ResourceIdentity
+	// Current is the comparable part of the resource in Cluster B, nil when it is missing
+	Current map[string]interface{}
+	// Desired is the manifest applied to Cluster B
+	Desired map[string]interface{}
+}
+
+// planSync lists, per allowed kind, the resources missing from or differing in Cluster B,
+// and returns the kinds with changes that are not allowed. Kinds are named with their API
+// group, as syncGroupKind does, and matched case-insensitively, so a custom resource
+// never passes as the built-in kind of the same name.
+func planSync(comparison *Comparison, allowedKinds []string) ([]syncKindPlan, map[string]bool) {
+	var plans []syncKindPlan
+	refused := make(map[string]bool)
+
+	for _, kind := range comparison.Kinds {
+		// A kind name can be served by several API groups, which are planned separately
+		var groupKinds []string
+		byGroupKind := make(map[string]*syncKindPlan)
+		for _, resource := range kind.Resources {
+			var change syncChange
+			switch resource.Status {
+			case statusOnlyInA:
+				change = syncChange{ResourceIdentity: resource.ResourceIdentity, Desired: desiredObject(resource)}
+			case statusDifferent:
+				change = syncChange{
+					ResourceIdentity: resourceIdentity(resource.ObjectB),
+					Current:          remediationObject(resource.ObjectB),
+					Desired:          desiredObject(resource),
+				}
+				if reflect.DeepEqual(change.Current, change.Desired) {
+					continue
+				}
+			default:
+				continue
+			}
+
+			groupKind := syncGroupKind(change.Desired)
+			if byGroupKind[groupKind] == nil {
+				groupKinds = append(groupKinds, groupKind)
+				byGroupKind[groupKind] = &syncKindPlan{Kind: groupKind}
+			}
+			byGroupKind[groupKind].Changes = append(byGroupKind[groupKind].Changes, change)
+		}
+
+		for _, groupKind := range groupKinds {
+			if !containsFold(allowedKinds, groupKind) {
+				refused[groupKind] = true
+				continue
+			}
+			plans = append(plans, *byGroupKind[groupKind])
+		}
+	}
+
+	return plans, refused
+}
+
+// syncGroupKind names the kind of a manifest with its API group the way kubectl does,
+// e.g. "Deployment.apps", or "ConfigMap" for the core group
+func syncGroupKind(object map[string]interface{}) string {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	groupVersion, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		// Named so that no allow-list entry matches it
+		return fmt.Sprintf("%s (apiVersion %q)", kind, apiVersion)
+	}
+	return schema.GroupKind{Group: groupVersion.Group, Kind: kind}.String()
+}
+
+// containsFold checks if a slice contains a string, ignoring case
+func containsFold(slice []string, item string) bool {
+	for _, s := range slice {
+		if strings.EqualFold(s, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncApplier applies manifests to Cluster B with server-side apply and returns the
+// resulting object; a dry run returns the object the server would store
+type syncApplier interface {
+	Apply(ctx context.Context, object map[string]interface{}, dryRun bool) (map[string]interface{}, error)
+}
+
+// syncResult counts the outcome of the proposed changes
+type syncResult struct {
+	Applied   int
+	Declined  int
+	Unchanged int
+	Failed    int
+}
+
+// syncSession applies planned changes after a dry run and a confirmation
+type syncSession struct {
+	applier syncApplier
+	confirm func(title string) (bool, error)
+	palette palette
+	context string
+}
+
+// run dry-runs every change, shows its diff and applies the confirmed ones: each
+// resource right after its own confirmation, or all resources of a kind at once
+func (s *syncSession) run(ctx context.Context, plans []syncKindPlan, confirmMode string) syncResult {
+	var result syncResult
+
+	for _, plan := range plans {
+		fmt.Printf("\n📦 %s: %d changes\n", plan.Kind, len(plan.Changes))
+
+		var pending []syncChange
+		for _, change := range plan.Changes {
+			if !s.dryRun(ctx, change, &result) {
+				continue
+			}
+			if confirmMode == syncConfirmKind {
+				pending = append(pending, change)
+				continue
+			}
+			if s.confirmed(fmt.Sprintf("Apply %s to %s?", change.ResourceIdentity, s.context), 1, &result) {
+				s.apply(ctx, change, &result)
+			}
+		}
+
+		if len(pending) > 0 && s.confirmed(fmt.Sprintf("Apply these %d %s changes to %s?", len(pending), plan.Kind, s.context), len(pending), &result) {
+			for _, change := range pending {
+				s.apply(ctx, change, &result)
+			}
+		}
+	}
+
+	return result
+}
+
+// dryRun runs a server-side dry-run apply of a change and prints the diff it would make.
+// It returns false when the change fails or would not modify the resource.
+func (s *syncSession) dryRun(ctx context.Context, change syncChange, result *syncResult) bool {
+	outcome, err := s.applier.Apply(ctx, change.Desired, true)
+	if err != nil {
+		fmt.Printf("❌ Dry run of %s failed: %v\n", change.ResourceIdentity, err)
+		result.Failed++
+		return false
+	}
+
+	hunks, err := syncDiffHunks(change.Current, remediationObject(outcome))
+	if err != nil {
+		fmt.Printf("❌ Failed to show the diff of %s: %v\n", change.ResourceIdentity, err)
+		result.Failed++
+		return false
+	}
+	if len(hunks) == 0 {
+		fmt.Printf("✅ %s is already up to date after server defaulting\n", change.ResourceIdentity)
+		result.Unchanged++
+		return false
+	}
+
+	action := "update"
+	if change.Current == nil {
+		action = "create"
+	}
+	fmt.Println()
+	fmt.Println(s.palette.paint(ansiBold, fmt.Sprintf("%s (dry run: %s)", change.ResourceIdentity, action)))
+	writeDiffHunks(os.Stdout, s.palette, hunks)
+
+	return true
+}
+
+// confirmed asks for confirmation; count changes are declined when it is refused
+func (s *syncSession) confirmed(title string, count int, result *syncResult) bool {
+	ok, err := s.confirm(title)
+	if err != nil {
+		fmt.Printf("⚠️  Confirmation failed: %v\n", err)
+	}
+	if !ok || err != nil {
+		result.Declined += count
+		return false
+	}
+	return true
+}
+
+// apply applies a dry-run change for real
+func (s *syncSession) apply(ctx context.Context, change syncChange, result *syncResult) {
+	if _, err := s.applier.Apply(ctx, change.Desired, false); err != nil {
+		fmt.Printf("❌ Failed to apply %s: %v\n", change.ResourceIdentity, err)
+		result.Failed++
+		return
+	}
+	fmt.Printf("✅ Applied %s\n", change.ResourceIdentity)
+	result.Applied++
+}
+
+// syncDiffHunks returns the diff between a resource and its dry-run outcome; a missing
+// resource is diffed against an empty document
+func syncDiffHunks(current, outcome map[string]interface{}) ([]diffHunk, error) {
+	var before []byte
+	if current != nil {
+		var err error
+		if before, err = yaml.Marshal(current); err != nil {
+			return nil, err
+		}
+	}
+	after, err := yaml.Marshal(outcome)
+	if err != nil {
+		return nil, err
+	}
+	return yamlDiffHunks(string(before), string(after)), nil
+}
+
+// confirmWithForm asks a yes/no question in the terminal
+func confirmWithForm(title string) (bool, error) {
+	var confirm bool
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(title).
+				Affirmative("Apply").
+				Negative("Skip").
+				Value(&confirm),
+		),
+	).Run()
+	return confirm, err
+}
+
+// dynamicSyncApplier applies manifests with the dynamic client
+type dynamicSyncApplier struct {
+	client         dynamic.Interface
+	resources      map[schema.GroupKind]selectedAPIResource
+	forceConflicts bool
+}
+
+// newDynamicSyncApplier connects to a cluster and resolves the selected resource types,
+// which are the only ones sync can apply
+func newDynamicSyncApplier(kubeconfig, contextName string, resources []string, forceConflicts bool) (*dynamicSyncApplier, error) {
+	client, discoveryClient, err := getDynamicClient(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	apiResourceLists, err := discoveryClient.ServerPreferredResources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API resources: %w", err)
+	}
+
+	applier := &dynamicSyncApplier{
+		client:         client,
+		resources:      make(map[schema.GroupKind]selectedAPIResource),
+		forceConflicts: forceConflicts,
+	}
+	for _, resource := range resolveAPIResources(apiResourceLists, resources) {
+		applier.resources[schema.GroupKind{Group: resource.GVR.Group, Kind: resource.Kind}] = resource
+	}
+	return applier, nil
+}
+
+// Apply applies a manifest with server-side apply under the k8s-compare field manager.
+// The manifest's own API version is used, so fields are interpreted as in Cluster A.
+func (a *dynamicSyncApplier) Apply(ctx context.Context, object map[string]interface{}, dryRun bool) (map[string]interface{}, error) {
+	manifest := &unstructured.Unstructured{Object: object}
+	gvk := manifest.GroupVersionKind()
+
+	resource, found := a.resources[gvk.GroupKind()]
+	if !found {
+		return nil, fmt.Errorf("%s is not served by the cluster", gvk.GroupKind())
+	}
+	gvr := resource.GVR
+	gvr.Version = gvk.Version
+
+	var resourceInterface dynamic.ResourceInterface = a.client.Resource(gvr)
+	if resource.Namespaced {
+		resourceInterface = a.client.Resource(gvr).Namespace(manifest.GetNamespace())
+	}
+
+	options := metav1.ApplyOptions{FieldManager: remediationFieldManager, Force: a.forceConflicts}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+
+	applied, err := resourceInterface.Apply(ctx, manifest.GetName(), manifest, options)
+	if err != nil {
+		return nil, err
+	}
+	return applied.Object, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSyncApplier records applied manifests and returns them, optionally changed by mutate
+type fakeSyncApplier struct {
+	dryRuns []string
+	applied []string
+	mutate  func(object map[string]interface{}) map[string]interface{}
+	fail    map[string]error
+}
+
+func (f *fakeSyncApplier) Apply(ctx context.Context, object map[string]interface{}, dryRun bool) (map[string]interface{}, error) {
+	id := resourceIdentity(object).String()
+	if dryRun {
+		f.dryRuns = append(f.dryRuns, id)
+	} else {
+		f.applied = append(f.applied, id)
+	}
+	if err := f.fail[id]; err != nil {
+		return nil, err
+	}
+	if f.mutate != nil {
+		return f.mutate(object), nil
+	}
+	return object, nil
+}
+
+var _ = Describe("Sync", func() {
+	var comparison *Comparison
+
+	BeforeEach(func() {
+		secretA := testResource("Secret", "default", "token", map[string]interface{}{"value": "a"})
+		secretB := testResource("Secret", "default", "token", map[string]interface{}{"value": "b"})
+		statusA := testResource("ConfigMap", "default", "status-only", nil)
+		statusA["status"] = "a"
+		statusB := testResource("ConfigMap", "default", "status-only", nil)
+		statusB["status"] = "b"
+
+		comparison = compareClusters(&ComparisonConfig{
+			ClusterA: ClusterConfig{Data: []map[string]interface{}{
+				testResource("ConfigMap", "default", "new", map[string]interface{}{"debug": "true"}),
+				testResource("ConfigMap", "default", "changed", map[string]interface{}{"debug": "true"}),
+				statusA,
+				secretA,
+			}},
+			ClusterB: ClusterConfig{Data: []map[string]interface{}{
+				testResource("ConfigMap", "default", "changed", map[string]interface{}{"debug": "false"}),
+				testResource("ConfigMap", "default", "extra", nil),
+				statusB,
+				secretB,
+			}},
+			CompareNamespaces: true,
+		})
+	})
+
+	Describe("planSync function", func() {
+		It("should plan creations and updates of allowed kinds only", func() {
+			plans, refused := planSync(comparison, []string{"configmap"})
+
+			Expect(refused).To(Equal(map[string]bool{"Secret": true}))
+			Expect(plans).To(HaveLen(1))
+			Expect(plans[0].Kind).To(Equal("ConfigMap"))
+			Expect(plans[0].Changes).To(HaveLen(2))
+
+			changed, created := plans[0].Changes[0], plans[0].Changes[1]
+			Expect(changed.Name).To(Equal("changed"))
+			Expect(changed.Current["spec"]).To(Equal(map[string]interface{}{"debug": "false"}))
+			Expect(changed.Desired["spec"]).To(Equal(map[string]interface{}{"debug": "true"}))
+			Expect(created.Name).To(Equal("new"))
+			Expect(created.Current).To(BeNil())
+		})
+
+		It("should match allowed kinds on their API group", func() {
+			knative := testResource("Service", "default", "web", map[string]interface{}{"template": "a"})
+			knative["apiVersion"] = "serving.knative.dev/v1"
+			deployment := testResource("Deployment", "default", "api", nil)
+			deployment["apiVersion"] = "apps/v1"
+			comparison := compareClusters(&ComparisonConfig{
+				ClusterA: ClusterConfig{Data: []map[string]interface{}{
+					knative, deployment, testResource("Service", "default", "db", nil),
+				}},
+				CompareNamespaces: true,
+			})
+
+			plans, refused := planSync(comparison, []string{"Service", "deployment.apps"})
+
+			Expect(refused).To(Equal(map[string]bool{"Service.serving.knative.dev": true}))
+			Expect(plans).To(HaveLen(2))
+			Expect(plans[0].Kind).To(Equal("Deployment.apps"))
+			Expect(plans[1].Kind).To(Equal("Service"))
+			Expect(plans[1].Changes).To(HaveLen(1))
+			Expect(plans[1].Changes[0].Name).To(Equal("db"))
+		})
+
+		It("should not let a resource with an invalid API version pass as a core kind", func() {
+			Expect(syncGroupKind(map[string]interface{}{"apiVersion": "a/b/c", "kind": "Service"})).NotTo(Equal("Service"))
+		})
+
+		It("should refuse every kind that is not allowed", func() {
+			plans, refused := planSync(comparison, nil)
+			Expect(plans).To(BeEmpty())
+			Expect(refused).To(HaveLen(2))
+		})
+	})
+
+	Describe("syncSession run method", func() {
+		var applier *fakeSyncApplier
+		var questions []string
+		var answer bool
+		var session *syncSession
+		var plans []syncKindPlan
+
+		BeforeEach(func() {
+			applier = &fakeSyncApplier{}
+			questions = nil
+			answer = true
+			session = &syncSession{
+				applier: applier,
+				confirm: func(title string) (bool, error) {
+					questions = append(questions, title)
+					return answer, nil
+				},
+				context: "prod",
+			}
+			plans, _ = planSync(comparison, []string{"ConfigMap"})
+		})
+
+		It("should dry-run and confirm every resource before applying it", func() {
+			result := session.run(context.Background(), plans, syncConfirmResource)
+
+			Expect(result).To(Equal(syncResult{Applied: 2}))
+			Expect(applier.dryRuns).To(Equal([]string{"ConfigMap default/changed", "ConfigMap default/new"}))
+			Expect(applier.applied).To(Equal([]string{"ConfigMap default/changed", "ConfigMap default/new"}))
+			Expect(questions).To(Equal([]string{"Apply ConfigMap default/changed to prod?", "Apply ConfigMap default/new to prod?"}))
+		})
+
+		It("should confirm once per kind", func() {
+			result := session.run(context.Background(), plans, syncConfirmKind)
+
+			Expect(result).To(Equal(syncResult{Applied: 2}))
+			Expect(questions).To(Equal([]string{"Apply these 2 ConfigMap changes to prod?"}))
+		})
+
+		It("should not apply declined changes", func() {
+			answer = false
+			result := session.run(context.Background(), plans, syncConfirmKind)
+
+			Expect(result).To(Equal(syncResult{Declined: 2}))
+			Expect(applier.dryRuns).To(HaveLen(2))
+			Expect(applier.applied).To(BeEmpty())
+		})
+
+		It("should not apply changes whose dry run fails", func() {
+			applier.fail = map[string]error{"ConfigMap default/new": errors.New("namespace not found")}
+			result := session.run(context.Background(), plans, syncConfirmResource)
+
+			Expect(result).To(Equal(syncResult{Applied: 1, Failed: 1}))
+			Expect(applier.applied).To(Equal([]string{"ConfigMap default/changed"}))
+		})
+
+		It("should skip updates the server would not change", func() {
+			current := plans[0].Changes[0].Current
+			applier.mutate = func(object map[string]interface{}) map[string]interface{} {
+				if resourceIdentity(object).Name == "changed" {
+					return current
+				}
+				return object
+			}
+
+			result := session.run(context.Background(), plans, syncConfirmResource)
+
+			Expect(result).To(Equal(syncResult{Applied: 1, Unchanged: 1}))
+			Expect(questions).To(HaveLen(1))
+		})
+	})
+
+	Describe("syncDiffHunks function", func() {
+		It("should diff a new resource against an empty document", func() {
+			hunks, err := syncDiffHunks(nil, map[string]interface{}{"kind": "ConfigMap"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hunks).To(HaveLen(1))
+			Expect(hunks[0].Lines).To(Equal([]diffLine{{Op: lineAdded, Text: "kind: ConfigMap"}}))
+		})
+	})
+})
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/term"
+)
+
+// Output formats of the comparison report
+const (
+	formatHTML = "html"
+	formatText = "text"
+)
+
+// ANSI escape sequences used by the text report
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+	ansiGray   = "\033[90m"
+)
+
+// stdoutIsTerminal reports whether stdout is attached to a terminal
+var stdoutIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// palette applies ANSI colors to the text report, or nothing when colors are disabled
+type palette struct {
+	enabled bool
+}
+
+// paint wraps text in the given escape sequence
+func (p palette) paint(code, text string) string {
+	if !p.enabled || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// shouldUseColor decides whether the text report is colorized: colors are disabled by
+// --no-color, by the NO_COLOR environment variable and when stdout is not a terminal
+func shouldUseColor(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return stdoutIsTerminal()
+}
+
+// renderTextReport writes the comparison as a per-kind summary table followed by a
+// unified YAML diff of every resource that differs between the clusters
+func renderTextReport(w io.Writer, config *ComparisonConfig, comparison *Comparison, color bool) error {
+	p := palette{enabled: color}
+
+	fmt.Fprintf(w, "%s\n", p.paint(ansiBold, fmt.Sprintf("📊 Cluster A (%s) vs Cluster B (%s)", config.ClusterA.Context, config.ClusterB.Context)))
+	fmt.Fprintln(w)
+	writeSummaryTable(w, comparison.Summaries())
+	writeOwnedDifferences(w, p, comparison.OwnedDifferences())
+	writeHelmReleases(w, p, comparison.HelmReleases)
+	writeImages(w, p, comparison.Images)
+	writeRBAC(w, p, comparison.RBAC)
+
+	counts := comparison.StatusCounts()
+	if counts[statusDifferent] > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "%s\n", p.paint(ansiBold, fmt.Sprintf("🔀 Differences (%d)", counts[statusDifferent])))
+		for _, kind := range comparison.Kinds {
+			for _, resource := range kind.Resources {
+				if resource.Status != statusDifferent {
+					continue
+				}
+				if err := writeResourceDiff(w, p, config, resource); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	writeResourceList(w, p, "➖ Only in Cluster A", statusOnlyInA, comparison, ansiRed)
+	writeResourceList(w, p, "➕ Only in Cluster B", statusOnlyInB, comparison, ansiGreen)
+	writeResourceList(w, p, "🚫 Not Compared", statusNotCompared, comparison, ansiGray)
+	writeResourceList(w, p, "❓ Unknown (Fetch Failed)", statusUnknown, comparison, ansiYellow)
+
+	if counts[statusDifferent]+counts[statusOnlyInA]+counts[statusOnlyInB] == 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, p.paint(ansiGreen, "✅ No differences found"))
+	}
+
+	return nil
+}
+
+// writeSummaryTable writes the number of resources per kind and status
+func writeSummaryTable(w io.Writer, summaries []kindSummary) {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "KIND\tIN A\tIN B\tONLY IN A\tONLY IN B\tDIFFERENT\tIDENTICAL\tNOT COMPARED\tUNKNOWN\t")
+
+	writeRow := func(summary kindSummary) {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t\n", summary.Kind, summary.CountA, summary.CountB,
+			summary.Counts[statusOnlyInA], summary.Counts[statusOnlyInB], summary.Counts[statusDifferent],
+			summary.Counts[statusIdentical], summary.Counts[statusNotCompared], summary.Counts[statusUnknown])
+	}
+
+	total := kindSummary{Kind: "TOTAL", Counts: make(map[string]int)}
+	for _, summary := range summaries {
+		writeRow(summary)
+		total.add(summary)
+	}
+	writeRow(total)
+
+	writer.Flush()
+}
+
+// writeOwnedDifferences writes the resources whose controller-generated resources differ in
+// number or readiness between the clusters, with the counts per kind
+func writeOwnedDifferences(w io.Writer, p palette, owners []ResourceComparison) {
+	if len(owners) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("🧩 Generated Resources (%d owners differ)", len(owners))))
+	for _, owner := range owners {
+		fmt.Fprintln(w, p.paint(ansiBold, owner.String()))
+		for _, summary := range owner.Owned {
+			fmt.Fprintln(w, p.paint(ansiYellow, "   "+summary.String()))
+		}
+	}
+}
+
+// writeHelmReleases writes the chart and status of each Helm release in both clusters,
+// followed by the differences of the releases that differ
+func writeHelmReleases(w io.Writer, p palette, releases []HelmReleaseComparison) {
+	if len(releases) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("⎈ Helm Releases (%d)", len(releases))))
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAMESPACE\tRELEASE\tSTATUS\tCLUSTER A\tCLUSTER B\t")
+	for _, release := range releases {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t\n", release.Namespace, release.Name, release.Status, release.ClusterA, release.ClusterB)
+	}
+	writer.Flush()
+
+	for _, release := range releases {
+		lines := release.ChangeLines()
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("%s/%s", release.Namespace, release.Name)))
+		for _, line := range lines {
+			fmt.Fprintln(w, p.paint(ansiYellow, "   "+line))
+		}
+	}
+}
+
+// writeImages writes the workload containers whose image differs between the clusters or
+// that exist in one cluster only, with the parts of the image references that differ,
+// followed by the containers whose running image digests drift
+func writeImages(w io.Writer, p palette, images []ContainerImageComparison) {
+	if len(images) == 0 {
+		return
+	}
+
+	different := differentImages(images)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("🐳 Container Images (%d containers, %d differ)", len(images), len(different))))
+	if len(different) > 0 {
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "NAMESPACE\tWORKLOAD\tCONTAINER\tSTATUS\tCLUSTER A\tCLUSTER B\tDIFFERS IN\t")
+		for _, image := range different {
+			fmt.Fprintf(writer, "%s\t%s/%s\t%s\t%s\t%s\t%s\t%s\t\n", image.Namespace, image.Kind, image.Workload, image.Container,
+				image.Status, orDash(image.ImageA), orDash(image.ImageB), orDash(strings.Join(image.Differences, ", ")))
+		}
+		writer.Flush()
+	}
+
+	drifting := driftingImages(images)
+	if len(drifting) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("🧬 Running Digest Drift (%d)", len(drifting))))
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAMESPACE\tWORKLOAD\tCONTAINER\tDRIFT\tDIGESTS A\tDIGESTS B\t")
+	for _, image := range drifting {
+		fmt.Fprintf(writer, "%s\t%s/%s\t%s\t%s\t%s\t%s\t\n", image.Namespace, image.Kind, image.Workload, image.Container,
+			strings.Join(image.DigestDrift, ", "), orDash(shortDigests(image.DigestsA)), orDash(shortDigests(image.DigestsB)))
+	}
+	writer.Flush()
+}
+
+// writeRBAC writes the subjects whose effective RBAC permissions differ between the
+// clusters, with the verbs they have on each differing resource
+func writeRBAC(w io.Writer, p palette, subjects []RBACSubjectComparison) {
+	if len(subjects) == 0 {
+		return
+	}
+
+	different := differentRBACSubjects(subjects)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("🔐 RBAC (%d subjects, %d differ)", len(subjects), len(different))))
+	for _, subject := range different {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("%s (%s)", subject.Subject, subject.Status)))
+		for _, change := range subject.Changes {
+			fmt.Fprintln(w, p.paint(ansiYellow, "   "+change.String()))
+		}
+	}
+}
+
+// orDash returns the value, or "-" when it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// writeResourceDiff writes the unified YAML diff of a resource present in both clusters.
+// Ignored fields are left out so the diff only shows the changes that count.
+func writeResourceDiff(w io.Writer, p palette, config *ComparisonConfig, resource ResourceComparison) error {
+	hunks, err := resourceDiffHunks(resource)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, p.paint(ansiBold, resource.String()))
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("--- cluster-a (%s)", config.ClusterA.Context)))
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("+++ cluster-b (%s)", config.ClusterB.Context)))
+	writeDiffHunks(w, p, hunks)
+
+	return nil
+}
+
+// writeDiffHunks writes unified diff hunks, coloring removed and added lines
+func writeDiffHunks(w io.Writer, p palette, hunks []diffHunk) {
+	for _, hunk := range hunks {
+		fmt.Fprintln(w, p.paint(ansiCyan, hunk.Header()))
+		for _, line := range hunk.Lines {
+			text := string(line.Op) + line.Text
+			switch line.Op {
+			case lineRemoved:
+				text = p.paint(ansiRed, text)
+			case lineAdded:
+				text = p.paint(ansiGreen, text)
+			}
+			fmt.Fprintln(w, text)
+		}
+	}
+}
+
+// writeResourceList writes the resources with the given status under a heading
+func writeResourceList(w io.Writer, p palette, title, status string, comparison *Comparison, code string) {
+	var entries []string
+	for _, kind := range comparison.Kinds {
+		for _, resource := range kind.Resources {
+			if resource.Status != status {
+				continue
+			}
+			entry := "   " + resource.String()
+			if resource.Skipped != nil && resource.Skipped.Error != "" {
+				entry += " (fetch failed: " + resource.Skipped.Error + ")"
+			}
+			entries = append(entries, p.paint(code, entry))
+		}
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, p.paint(ansiBold, fmt.Sprintf("%s (%d)", title, len(entries))))
+	for _, entry := range entries {
+		fmt.Fprintln(w, entry)
+	}
+}
+
+// pageOutput shows the output through $PAGER (or less) when stdout is a terminal, and
+// writes it directly otherwise or when no pager is available
+func pageOutput(output string, usePager bool) error {
+	if usePager && stdoutIsTerminal() {
+		pager := strings.Fields(os.Getenv("PAGER"))
+		if len(pager) == 0 {
+			// -F quits when the output fits on one screen, -R keeps colors, -X keeps the output on exit
+			pager = []string{"less", "-FRX"}
+		}
+
+		cmd := exec.Command(pager[0], pager[1:]...)
+		cmd.Stdin = strings.NewReader(output)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("pager %s failed: %w", pager[0], err)
+		}
+	}
+
+	_, err := io.WriteString(os.Stdout, output)
+	return err
+}
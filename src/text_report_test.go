@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Text report", func() {
+	var config *ComparisonConfig
+
+	BeforeEach(func() {
+		config = &ComparisonConfig{
+			ClusterA: ClusterConfig{
+				Context: "staging",
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(2)}),
+					testResource("ConfigMap", "default", "legacy", nil),
+				},
+			},
+			ClusterB: ClusterConfig{
+				Context: "production",
+				Data: []map[string]interface{}{
+					testResource("Deployment", "default", "web", map[string]interface{}{"replicas": int64(3)}),
+				},
+			},
+			CompareNamespaces: true,
+		}
+	})
+
+	Describe("renderTextReport function", func() {
+		It("should print a summary table and a unified diff per differing resource", func() {
+			var output strings.Builder
+			Expect(renderTextReport(&output, config, compareClusters(config), false)).To(Succeed())
+
+			report := output.String()
+			Expect(report).To(ContainSubstring("Cluster A (staging) vs Cluster B (production)"))
+			Expect(report).To(MatchRegexp(`KIND\s+IN A\s+IN B\s+ONLY IN A\s+ONLY IN B\s+DIFFERENT`))
+			Expect(report).To(MatchRegexp(`ConfigMap\s+1\s+0\s+1\s+0\s+0`))
+			Expect(report).To(MatchRegexp(`Deployment\s+1\s+1\s+0\s+0\s+1`))
+			Expect(report).To(MatchRegexp(`TOTAL\s+2\s+1\s+1\s+0\s+1`))
+			Expect(report).To(ContainSubstring("Deployment default/web\n--- cluster-a (staging)\n+++ cluster-b (production)\n"))
+			Expect(report).To(ContainSubstring("-  replicas: 2\n+  replicas: 3\n"))
+			Expect(report).To(ContainSubstring("Only in Cluster A (1)\n   ConfigMap default/legacy"))
+			Expect(report).NotTo(ContainSubstring("\033["))
+		})
+
+		It("should colorize the diff when colors are enabled", func() {
+			var output strings.Builder
+			Expect(renderTextReport(&output, config, compareClusters(config), true)).To(Succeed())
+
+			Expect(output.String()).To(ContainSubstring(ansiRed + "-  replicas: 2" + ansiReset))
+			Expect(output.String()).To(ContainSubstring(ansiGreen + "+  replicas: 3" + ansiReset))
+		})
+
+		It("should leave ignored fields out of the diff", func() {
+			config.ClusterA.Data[0]["metadata"].(map[string]interface{})["resourceVersion"] = "100"
+			config.ClusterB.Data[0]["metadata"].(map[string]interface{})["resourceVersion"] = "200"
+
+			var output strings.Builder
+			Expect(renderTextReport(&output, config, compareClusters(config), false)).To(Succeed())
+
+			Expect(output.String()).NotTo(ContainSubstring("resourceVersion"))
+		})
+
+		It("should report when the clusters match", func() {
+			config.ClusterA.Data = config.ClusterB.Data
+
+			var output strings.Builder
+			Expect(renderTextReport(&output, config, compareClusters(config), false)).To(Succeed())
+
+			Expect(output.String()).To(ContainSubstring("No differences found"))
+			Expect(output.String()).NotTo(ContainSubstring("Differences ("))
+		})
+	})
+
+	Describe("shouldUseColor function", func() {
+		var originalIsTerminal func() bool
+
+		BeforeEach(func() {
+			originalIsTerminal = stdoutIsTerminal
+			stdoutIsTerminal = func() bool { return true }
+			os.Unsetenv("NO_COLOR")
+		})
+
+		AfterEach(func() {
+			stdoutIsTerminal = originalIsTerminal
+			os.Unsetenv("NO_COLOR")
+		})
+
+		It("should use colors on a terminal", func() {
+			Expect(shouldUseColor(false)).To(BeTrue())
+		})
+
+		It("should respect --no-color and NO_COLOR", func() {
+			Expect(shouldUseColor(true)).To(BeFalse())
+
+			os.Setenv("NO_COLOR", "1")
+			Expect(shouldUseColor(false)).To(BeFalse())
+		})
+
+		It("should not use colors when stdout is not a terminal", func() {
+			stdoutIsTerminal = func() bool { return false }
+			Expect(shouldUseColor(false)).To(BeFalse())
+		})
+	})
+})
@@ -1,11 +1,55 @@
 package main
 
+import (
+	"io"
+	"os"
+)
+
 // ClusterConfig holds configuration for a single cluster
 type ClusterConfig struct {
+	Kubeconfig string
 	Context    string
 	Namespaces []string
 	Resources  []string
 	Data       []map[string]interface{}
+	Skipped    []SkippedScope
+}
+
+// ResourceScope identifies one resource type in one namespace of a cluster.
+// Namespace is empty for cluster-scoped resources.
+type ResourceScope struct {
+	Group     string `json:"group"`
+	Resource  string `json:"resource"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+}
+
+// String formats the scope for log output, e.g. "secrets in kube-system"
+func (s ResourceScope) String() string {
+	resource := s.Resource
+	if s.Group != "" {
+		resource += "." + s.Group
+	}
+	if s.Namespace == "" {
+		return resource + " (cluster-scoped)"
+	}
+	return resource + " in " + s.Namespace
+}
+
+// Reasons for which a resource scope was not fetched from a cluster
+const (
+	// skipReasonForbidden means the user may not list the scope; its resources are "not compared"
+	skipReasonForbidden = "forbidden"
+	// skipReasonError means listing failed even after retries; its resources are "unknown"
+	skipReasonError = "error"
+)
+
+// SkippedScope is a resource scope that was not fetched from a cluster. Resources in
+// a skipped scope are reported as not compared or unknown rather than as missing.
+type SkippedScope struct {
+	ResourceScope
+	Reason string `json:"reason"`
+	Error  string `json:"error,omitempty"`
 }
 
 // ComparisonConfig holds configuration for comparing two clusters
@@ -15,4 +59,27 @@ type ComparisonConfig struct {
 	OutputDir         string
 	ReportTimestamp   string
 	CompareNamespaces bool
+	// AnalyzeRBAC adds the comparison of effective RBAC permissions to the reports
+	AnalyzeRBAC bool
+	// ExpandOwned compares controller-generated resources individually instead of
+	// counting them under their owner
+	ExpandOwned bool
+	// Progress receives the progress messages of the comparison; nil means stdout
+	Progress io.Writer
+	// NonInteractive stops the comparison from prompting for logins, for runs that
+	// have nobody at the terminal
+	NonInteractive bool
+}
+
+// progress returns the writer the comparison reports its progress to
+func (c *ComparisonConfig) progress() io.Writer {
+	if c.Progress == nil {
+		return os.Stdout
+	}
+	return c.Progress
+}
+
+// authSession returns the authentication settings of the comparison
+func (c *ComparisonConfig) authSession() authSession {
+	return authSession{out: c.progress(), interactive: !c.NonInteractive}
 }
@@ -170,4 +170,16 @@ var _ = Describe("Types", func() {
 			})
 		})
 	})
+
+	Describe("ResourceScope struct", func() {
+		It("should describe namespaced scopes", func() {
+			scope := ResourceScope{Resource: "secrets", Kind: "Secret", Namespace: "kube-system"}
+			Expect(scope.String()).To(Equal("secrets in kube-system"))
+		})
+
+		It("should describe cluster-scoped resources with their group", func() {
+			scope := ResourceScope{Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Kind: "ClusterRole"}
+			Expect(scope.String()).To(Equal("clusterroles.rbac.authorization.k8s.io (cluster-scoped)"))
+		})
+	})
 })
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// clusterScopedDir is the namespace directory used for cluster-scoped resources
+const clusterScopedDir = "_cluster"
+
+// exportYAMLTree writes the normalized resources of both clusters to
+// <dir>/cluster-a and <dir>/cluster-b, one YAML file per resource
+func exportYAMLTree(dir string, config *ComparisonConfig) error {
+	if err := writeYAMLTree(filepath.Join(dir, "cluster-a"), config.ClusterA.Data); err != nil {
+		return fmt.Errorf("failed to export Cluster A: %w", err)
+	}
+	if err := writeYAMLTree(filepath.Join(dir, "cluster-b"), config.ClusterB.Data); err != nil {
+		return fmt.Errorf("failed to export Cluster B: %w", err)
+	}
+
+	fmt.Fprintf(config.progress(), "📁 Exported YAML resources to %s (compare with: git diff --no-index %s %s)\n", dir,
+		filepath.Join(dir, "cluster-a"), filepath.Join(dir, "cluster-b"))
+	return nil
+}
+
+// writeYAMLTree writes each resource to <root>/<namespace>/<group.kind>/<name>.yaml.
+// The root is recreated so that resources deleted since a previous export disappear.
+// Keys are sorted, so re-exporting an unchanged resource produces an identical file.
+func writeYAMLTree(root string, resources []map[string]interface{}) error {
+	if err := os.RemoveAll(root); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", root, err)
+	}
+
+	for _, resource := range resources {
+		path := yamlExportPath(root, resource)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+
+		content, err := yaml.Marshal(normalizeObject(resource))
+		if err != nil {
+			return fmt.Errorf("failed to render %s as YAML: %w", resourceIdentity(resource), err)
+		}
+
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// yamlExportPath returns the file a resource is exported to, e.g.
+// <root>/default/apps.Deployment/web.yaml or <root>/_cluster/ClusterRole/admin.yaml
+func yamlExportPath(root string, resource map[string]interface{}) string {
+	id := resourceIdentity(resource)
+
+	namespace := id.Namespace
+	if namespace == "" {
+		namespace = clusterScopedDir
+	}
+
+	groupKind := id.Kind
+	if group, _, found := strings.Cut(id.APIVersion, "/"); found && group != "" {
+		groupKind = group + "." + id.Kind
+	}
+
+	return filepath.Join(root, safePathSegment(namespace), safePathSegment(groupKind), safePathSegment(id.Name)+".yaml")
+}
+
+// safePathSegment makes a name usable as a single path segment
+func safePathSegment(name string) string {
+	name = strings.NewReplacer("/", "_", `\`, "_").Replace(name)
+	if name == "." || name == ".." {
+		return "_" + name
+	}
+	return name
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("YAML export", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "k8s-compare-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	Describe("yamlExportPath function", func() {
+		It("should place namespaced resources under their namespace and group", func() {
+			deployment := testResource("Deployment", "default", "web", nil)
+			deployment["apiVersion"] = "apps/v1"
+
+			Expect(yamlExportPath("out", deployment)).To(Equal(filepath.Join("out", "default", "apps.Deployment", "web.yaml")))
+		})
+
+		It("should use the kind alone for the core group", func() {
+			Expect(yamlExportPath("out", testResource("ConfigMap", "default", "settings", nil))).To(Equal(filepath.Join("out", "default", "ConfigMap", "settings.yaml")))
+		})
+
+		It("should place cluster-scoped resources under _cluster", func() {
+			role := testResource("ClusterRole", "", "system:admin", nil)
+			role["apiVersion"] = "rbac.authorization.k8s.io/v1"
+
+			Expect(yamlExportPath("out", role)).To(Equal(filepath.Join("out", "_cluster", "rbac.authorization.k8s.io.ClusterRole", "system:admin.yaml")))
+		})
+
+		It("should not let names escape their directory", func() {
+			Expect(yamlExportPath("out", testResource("ConfigMap", "default", "..", nil))).To(Equal(filepath.Join("out", "default", "ConfigMap", "_...yaml")))
+			Expect(yamlExportPath("out", testResource("ConfigMap", "default", "a/b", nil))).To(Equal(filepath.Join("out", "default", "ConfigMap", "a_b.yaml")))
+		})
+	})
+
+	Describe("exportYAMLTree function", func() {
+		var config *ComparisonConfig
+
+		BeforeEach(func() {
+			configMap := testResource("ConfigMap", "default", "settings", map[string]interface{}{"b": "2", "a": "1"})
+			configMap["metadata"].(map[string]interface{})["resourceVersion"] = "12345"
+
+			config = &ComparisonConfig{
+				ClusterA: ClusterConfig{Data: []map[string]interface{}{configMap}},
+				ClusterB: ClusterConfig{Data: []map[string]interface{}{testResource("Namespace", "", "default", nil)}},
+			}
+		})
+
+		It("should write normalized resources with sorted keys", func() {
+			Expect(exportYAMLTree(tempDir, config)).To(Succeed())
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "cluster-a", "default", "ConfigMap", "settings.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: default\nspec:\n  a: \"1\"\n  b: \"2\"\n"))
+
+			Expect(filepath.Join(tempDir, "cluster-b", "_cluster", "Namespace", "default.yaml")).To(BeAnExistingFile())
+		})
+
+		It("should remove resources left over from a previous export", func() {
+			stale := filepath.Join(tempDir, "cluster-a", "default", "ConfigMap", "deleted.yaml")
+			Expect(os.MkdirAll(filepath.Dir(stale), 0755)).To(Succeed())
+			Expect(os.WriteFile(stale, []byte("kind: ConfigMap\n"), 0644)).To(Succeed())
+
+			Expect(exportYAMLTree(tempDir, config)).To(Succeed())
+
+			Expect(stale).NotTo(BeAnExistingFile())
+		})
+	})
+})